@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"strings"
 	"github.com/joho/godotenv"
     "github.com/LengLKR/auth-microservice/config"
-    "github.com/LengLKR/auth-microservice/internal/repository"
-	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+    "github.com/LengLKR/auth-microservice/internal/keys"
+    "github.com/LengLKR/auth-microservice/internal/mail"
     "github.com/LengLKR/auth-microservice/internal/service"
     "github.com/LengLKR/auth-microservice/internal/transport"
+    "github.com/go-webauthn/webauthn/webauthn"
     "google.golang.org/grpc"
 )
 
@@ -19,47 +24,172 @@ func main(){
 	// โหลด config จาก env
 	cfg := config.Load()
 
-	// เชื่อมต่อ MongoDB
-	client, err := config.InitMongo(cfg)
+	// เชื่อมต่อ storage ตาม STORAGE_DRIVER (mongo, postgres, sqlite หรือ memory)
+	repos, err := openStorage(cfg)
 	if err != nil {
-	log.Fatalf("failed to connect to MongoDB: %v", err)
+		log.Fatalf("failed to open %s storage: %v", cfg.StorageDriver, err)
 	}
-	db := client.Database(cfg.MongoDatabase)
 
-	// สร้าง repository & service
-	userCol := db.Collection("users")
-	userRepo := repository.NewMongoUserRepository(userCol)
+	// ตัวนับ login ผิดตาม RATE_LIMIT_DRIVER (memory, mongo หรือ redis)
+	limiter, err := openRateLimiter(cfg)
+	if err != nil {
+		log.Fatalf("failed to open %s rate limiter: %v", cfg.RateLimitDriver, err)
+	}
+	accountPolicy, ipPolicy := loginPolicies(cfg)
+
+	hasher, err := newPasswordHasher(cfg)
+	if err != nil {
+		log.Fatalf("invalid password hashing config: %v", err)
+	}
+	passwordPolicy, err := newPasswordPolicy(cfg)
+	if err != nil {
+		log.Fatalf("invalid password policy: %v", err)
+	}
 
-	//สร้าง token repository สำหรับ Logout blacklist
-	tokenCol := db.Collection("invalidated_tokens")
-	tokenRepo := repository.NewMongoTokenRepository(tokenCol)
+	// Passkey (WebAuthn) config
+	origins := cfg.WebAuthnRPOrigins
+	if len(origins) == 0 {
+		origins = []string{"http://" + cfg.WebAuthnRPID}
+	}
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPName,
+		RPOrigins:     origins,
+	})
+	if err != nil {
+		log.Fatalf("invalid WebAuthn config: %v", err)
+	}
 
-	// Password reset repo
-    resetCol := db.Collection("password_resets")
-    resetRepo := pr.NewMongoResetRepo(resetCol)
+	// Mailer สำหรับอีเมล reset password / ยืนยันอีเมล
+	var mailer mail.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		mailer = mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case "memory":
+		mailer = mail.NewMemoryMailer()
+	case "file":
+		mailer, err = mail.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+		if err != nil {
+			log.Fatalf("failed to create mail directory: %v", err)
+		}
+	default:
+		log.Fatalf("unknown MAIL_DRIVER %q", cfg.MailDriver)
+	}
 
-	
-	// สร้าง AuthService พร้อมทั้ง userRepo, tokenRepo, และ jwtSecret
+	// โหลด key สำหรับเซ็น JWT
+	signingKey, err := keys.Load(cfg.JWTSigningAlg, cfg.JWTKeyID, cfg.JWTPrivateKeyFile, cfg.JWTSecret)
+	if err != nil && cfg.JWTSigningAlg != keys.HS256 && cfg.JWTPrivateKeyFile == "" {
+		// ไม่มีไฟล์ key: สร้าง key ชั่วคราว (token จะใช้ไม่ได้หลัง restart)
+		log.Printf("JWT_PRIVATE_KEY_FILE not set; generating ephemeral %s key", cfg.JWTSigningAlg)
+		signingKey, err = keys.Generate(cfg.JWTSigningAlg)
+	}
+	if err != nil {
+		log.Fatalf("failed to load JWT signing key: %v", err)
+	}
+	keyRing, err := keys.NewRing(signingKey, cfg.JWTKeyOverlap)
+	if err != nil {
+		log.Fatalf("failed to build key ring: %v", err)
+	}
+	// key เก่าที่ยังต้องตรวจสอบ token ที่ออกไปแล้วได้
+	for _, secret := range cfg.JWTPreviousSecrets {
+		k, err := keys.NewHMACKey("", secret)
+		if err != nil {
+			log.Fatalf("invalid previous JWT secret: %v", err)
+		}
+		keyRing.AddVerifyOnly(k)
+	}
+	for _, file := range cfg.JWTVerifyKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("failed to read verify key %s: %v", file, err)
+		}
+		k, err := keys.ParsePublicKeyPEM("", data)
+		if err != nil {
+			log.Fatalf("invalid verify key %s: %v", file, err)
+		}
+		keyRing.AddVerifyOnly(k)
+	}
+	if cfg.JWTRotationInterval > 0 {
+		keyRing.StartRotation(context.Background(), cfg.JWTRotationInterval, func() (*keys.Key, error) {
+			return keys.Generate(cfg.JWTSigningAlg)
+		})
+	}
+
+	// สร้าง AuthService พร้อมทั้ง userRepo, tokenRepo, refreshRepo และ signing key
 	authSvc := service.NewAuthService(
-        userRepo,
-        tokenRepo,
-        resetRepo,
-        cfg.JWTSecret,
+        repos.users,
+        repos.tokens,
+        repos.resets,
+        repos.verifications,
+        repos.refreshTokens,
+        keyRing,
+        mailer,
+        service.WithTokenTTL(cfg.AccessTokenTTL, cfg.RefreshTokenTTL),
+        service.WithTOTPIssuer(cfg.TOTPIssuer),
+        service.WithRequireVerifiedEmail(cfg.RequireVerifiedEmail),
+        service.WithLinkBaseURL(cfg.AppBaseURL),
+        service.WithWebAuthn(wa, repos.credentials, repos.passkeySessions),
+        service.WithRateLimiter(limiter, accountPolicy, ipPolicy),
+        service.WithPasswordHasher(hasher),
+        service.WithPasswordPolicy(passwordPolicy),
+        service.WithAuditLog(repos.audit),
+        service.WithOAuth(repos.oauthClients, repos.authCodes, repos.consents),
+        service.WithIssuer(cfg.OIDCIssuer),
+        service.WithMachineClients(repos.machineClients),
     )
+	if err := authSvc.EnsureAdmins(context.Background(), cfg.AdminEmails); err != nil {
+		log.Fatalf("failed to assign admin roles: %v", err)
+	}
+	if !authSvc.OIDCEnabled() {
+		log.Printf("JWT_SIGNING_ALG is %s; OpenID Connect is disabled (ID tokens need RS256, ES256 or EdDSA)", cfg.JWTSigningAlg)
+	}
 
 
+	// key ที่ REST gateway แนบไปกับทุก call: มีแค่ call จาก gateway ที่ใช้ X-Forwarded-For เป็น IP ของ client
+	gatewayKey, err := transport.NewGatewayKey()
+	if err != nil {
+		log.Fatalf("failed to generate gateway key: %v", err)
+	}
+
 	// สร้าง gRPC server และ register
-	grpcServer := grpc.NewServer()
+	authInterceptor := transport.NewAuthInterceptor(authSvc, transport.PublicMethods, transport.ScopedMethods)
+	clientInterceptor := transport.NewClientInterceptor(gatewayKey)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(transport.UnaryErrorInterceptor, clientInterceptor.Unary(), authInterceptor.Unary()),
+		grpc.ChainStreamInterceptor(transport.StreamErrorInterceptor, clientInterceptor.Stream(), authInterceptor.Stream()),
+	)
 	transport.RegisterAuthServiceServer(grpcServer, transport.NewServer(authSvc))
 
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
 
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	log.Println("gRPC server listening on :50051")
+	log.Printf("gRPC server listening on %s", cfg.GRPCAddr)
+
+	// REST/JSON gateway เรียกกลับเข้า gRPC server ตัวนี้ เพื่อให้ผ่าน interceptor ชุดเดียวกัน
+	gateway, err := transport.NewGateway(context.Background(), dialAddr(cfg.GRPCAddr), gatewayKey)
+	if err != nil {
+		log.Fatalf("failed to create gateway: %v", err)
+	}
+
+	// HTTP server สำหรับ /.well-known/..., /oauth/..., /openapi.json และ REST /v1/...
+	go func() {
+		log.Printf("HTTP server listening on %s", cfg.HTTPAddr)
+		if err := http.ListenAndServe(cfg.HTTPAddr, transport.NewHTTPHandler(authSvc, gateway)); err != nil {
+			log.Fatalf("failed to serve HTTP: %v", err)
+		}
+	}()
 
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// dialAddr แปลง listen address (เช่น ":50051") เป็น address ที่ dial ได้จากในเครื่อง
+func dialAddr(listen string) string {
+	if strings.HasPrefix(listen, ":") {
+		return "localhost" + listen
+	}
+	return listen
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/LengLKR/auth-microservice/config"
+	"github.com/LengLKR/auth-microservice/internal/password"
+)
+
+// newPasswordHasher สร้าง hasher ตาม PASSWORD_HASH_ALG
+// hash เดิมที่ใช้ algorithm หรือ parameter อื่นจะถูก hash ใหม่เมื่อผู้ใช้ login สำเร็จ
+func newPasswordHasher(cfg *config.Config) (password.Hasher, error) {
+	switch cfg.PasswordHashAlg {
+	case "argon2id":
+		h := password.NewArgon2id()
+		if cfg.Argon2Memory > 0 {
+			h.Memory = uint32(cfg.Argon2Memory)
+		}
+		if cfg.Argon2Iterations > 0 {
+			h.Iterations = uint32(cfg.Argon2Iterations)
+		}
+		if cfg.Argon2Parallelism > 0 && cfg.Argon2Parallelism <= 255 {
+			h.Parallelism = uint8(cfg.Argon2Parallelism)
+		}
+		return h, nil
+	case "bcrypt":
+		return password.NewBcrypt(cfg.BcryptCost), nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_HASH_ALG %q", cfg.PasswordHashAlg)
+	}
+}
+
+// newPasswordPolicy สร้าง policy ของรหัสผ่านใหม่จาก PASSWORD_* และโหลดชุดรหัสที่รั่วถ้ากำหนดไว้
+func newPasswordPolicy(cfg *config.Config) (*password.Policy, error) {
+	p := password.DefaultPolicy()
+	p.MinLength = cfg.PasswordMinLength
+	p.MaxBytes = cfg.PasswordMaxBytes
+	for _, class := range cfg.PasswordRequire {
+		switch class {
+		case "lower":
+			p.RequireLowercase = true
+		case "upper":
+			p.RequireUppercase = true
+		case "digit":
+			p.RequireDigit = true
+		case "symbol":
+			p.RequireSymbol = true
+		default:
+			return nil, fmt.Errorf("unknown PASSWORD_REQUIRE class %q", class)
+		}
+	}
+	if cfg.PasswordBreachCorpus != "" {
+		checker, err := password.LoadBreachChecker(cfg.PasswordBreachCorpus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load PASSWORD_BREACH_CORPUS: %w", err)
+		}
+		p.Breached = checker
+	}
+	return p, nil
+}
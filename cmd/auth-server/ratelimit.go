@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/config"
+	"github.com/LengLKR/auth-microservice/internal/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// openRateLimiter สร้าง rate limiter ตาม RATE_LIMIT_DRIVER
+// memory ใช้ได้กับ instance เดียว ถ้ารันหลาย replica ให้ใช้ mongo หรือ redis
+func openRateLimiter(cfg *config.Config) (ratelimit.RateLimiter, error) {
+	switch cfg.RateLimitDriver {
+	case "memory":
+		return ratelimit.NewMemoryLimiter(), nil
+	case "mongo":
+		client, err := config.InitMongo(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return ratelimit.NewMongoLimiter(client.Database(cfg.MongoDatabase).Collection("rate_limits")), nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		client := redis.NewClient(opts)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, err
+		}
+		return ratelimit.NewRedisLimiter(client, "auth:ratelimit:"), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_DRIVER %q", cfg.RateLimitDriver)
+	}
+}
+
+// loginPolicies คืน policy ต่อบัญชีและต่อ IP จาก config
+func loginPolicies(cfg *config.Config) (account, ip ratelimit.Policy) {
+	account = ratelimit.Policy{
+		MaxAttempts: cfg.LoginMaxAttempts,
+		Window:      cfg.LoginWindow,
+		Lockout:     cfg.LoginLockout,
+		MaxLockout:  cfg.LoginLockoutMax,
+		ResetAfter:  ratelimit.DefaultPolicy.ResetAfter,
+	}
+	ip = account
+	ip.MaxAttempts = cfg.LoginIPMaxAttempts
+	return account, ip
+}
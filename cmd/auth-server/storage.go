@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/config"
+	"github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/LengLKR/auth-microservice/internal/repository/deadline"
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+	"github.com/LengLKR/auth-microservice/internal/repository/postgres"
+	"github.com/LengLKR/auth-microservice/internal/repository/sqlite"
+)
+
+// repositories รวม repository ทุกตัวที่ service ใช้ จาก storage driver เดียวกัน
+type repositories struct {
+	users           repository.UserRepository
+	tokens          repository.TokenRepository
+	resets          pr.PasswordResetRepository
+	verifications   ev.EmailVerificationRepository
+	refreshTokens   repository.RefreshTokenRepository
+	credentials     repository.WebAuthnCredentialRepository
+	passkeySessions repository.WebAuthnSessionRepository
+	audit           repository.AuditRepository
+	oauthClients    repository.OAuthClientRepository
+	authCodes       repository.AuthorizationCodeRepository
+	consents        repository.ConsentRepository
+	machineClients  repository.MachineClientRepository
+}
+
+// openStorage สร้าง repository ตาม STORAGE_DRIVER แล้วครอบด้วย timeout ต่อ operation (DB_TIMEOUT)
+func openStorage(cfg *config.Config) (*repositories, error) {
+	repos, err := openDriver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return repos.withTimeout(cfg.DBTimeout), nil
+}
+
+func openDriver(cfg *config.Config) (*repositories, error) {
+	switch cfg.StorageDriver {
+	case "mongo":
+		return openMongo(cfg)
+	case "postgres":
+		return openPostgres(cfg)
+	case "sqlite":
+		return openSQLite(cfg)
+	case "memory":
+		// ข้อมูลหายเมื่อ restart ใช้สำหรับ local dev เท่านั้น
+		return &repositories{
+			users:           memory.NewUserRepository(),
+			tokens:          memory.NewTokenRepository(),
+			resets:          memory.NewPasswordResetRepository(),
+			verifications:   memory.NewEmailVerificationRepository(),
+			refreshTokens:   memory.NewRefreshTokenRepository(),
+			credentials:     memory.NewWebAuthnCredentialRepository(),
+			passkeySessions: memory.NewWebAuthnSessionRepository(),
+			audit:           memory.NewAuditRepository(),
+			oauthClients:    memory.NewOAuthClientRepository(),
+			authCodes:       memory.NewAuthorizationCodeRepository(),
+			consents:        memory.NewConsentRepository(),
+			machineClients:  memory.NewMachineClientRepository(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.StorageDriver)
+	}
+}
+
+// withTimeout ครอบ repository ทุกตัวให้แต่ละ operation ใช้เวลาไม่เกิน d
+func (r *repositories) withTimeout(d time.Duration) *repositories {
+	return &repositories{
+		users:           deadline.Users(r.users, d),
+		tokens:          deadline.Tokens(r.tokens, d),
+		resets:          deadline.PasswordResets(r.resets, d),
+		verifications:   deadline.EmailVerifications(r.verifications, d),
+		refreshTokens:   deadline.RefreshTokens(r.refreshTokens, d),
+		credentials:     deadline.WebAuthnCredentials(r.credentials, d),
+		passkeySessions: deadline.WebAuthnSessions(r.passkeySessions, d),
+		audit:           deadline.Audit(r.audit, d),
+		oauthClients:    deadline.OAuthClients(r.oauthClients, d),
+		authCodes:       deadline.AuthorizationCodes(r.authCodes, d),
+		consents:        deadline.Consents(r.consents, d),
+		machineClients:  deadline.MachineClients(r.machineClients, d),
+	}
+}
+
+func openMongo(cfg *config.Config) (*repositories, error) {
+	client, err := config.InitMongo(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := client.Database(cfg.MongoDatabase)
+	return &repositories{
+		users:           repository.NewMongoUserRepository(db.Collection("users")),
+		tokens:          repository.NewMongoTokenRepository(db.Collection("invalidated_tokens")),
+		resets:          pr.NewMongoResetRepo(db.Collection("password_resets")),
+		verifications:   ev.NewMongoVerificationRepo(db.Collection("email_verifications")),
+		refreshTokens:   repository.NewMongoRefreshTokenRepository(db.Collection("refresh_tokens")),
+		credentials:     repository.NewMongoWebAuthnCredentialRepository(db.Collection("webauthn_credentials")),
+		passkeySessions: repository.NewMongoWebAuthnSessionRepository(db.Collection("webauthn_sessions")),
+		audit:           repository.NewMongoAuditRepository(db.Collection("audit_events")),
+		oauthClients:    repository.NewMongoOAuthClientRepository(db.Collection("oauth_clients")),
+		authCodes:       repository.NewMongoAuthorizationCodeRepository(db.Collection("oauth_authorization_codes")),
+		consents:        repository.NewMongoConsentRepository(db.Collection("oauth_consents")),
+		machineClients:  repository.NewMongoMachineClientRepository(db.Collection("machine_clients")),
+	}, nil
+}
+
+func openPostgres(cfg *config.Config) (*repositories, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool, err := postgres.Open(ctx, cfg.PostgresDSN)
+	if err != nil {
+		return nil, err
+	}
+	return &repositories{
+		users:           postgres.NewUserRepository(pool),
+		tokens:          postgres.NewTokenRepository(pool),
+		resets:          postgres.NewPasswordResetRepository(pool),
+		verifications:   postgres.NewEmailVerificationRepository(pool),
+		refreshTokens:   postgres.NewRefreshTokenRepository(pool),
+		credentials:     postgres.NewWebAuthnCredentialRepository(pool),
+		passkeySessions: postgres.NewWebAuthnSessionRepository(pool),
+		audit:           postgres.NewAuditRepository(pool),
+		oauthClients:    postgres.NewOAuthClientRepository(pool),
+		authCodes:       postgres.NewAuthorizationCodeRepository(pool),
+		consents:        postgres.NewConsentRepository(pool),
+		machineClients:  postgres.NewMachineClientRepository(pool),
+	}, nil
+}
+
+func openSQLite(cfg *config.Config) (*repositories, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	db, err := sqlite.Open(ctx, cfg.SQLitePath)
+	if err != nil {
+		return nil, err
+	}
+	return &repositories{
+		users:           sqlite.NewUserRepository(db),
+		tokens:          sqlite.NewTokenRepository(db),
+		resets:          sqlite.NewPasswordResetRepository(db),
+		verifications:   sqlite.NewEmailVerificationRepository(db),
+		refreshTokens:   sqlite.NewRefreshTokenRepository(db),
+		credentials:     sqlite.NewWebAuthnCredentialRepository(db),
+		passkeySessions: sqlite.NewWebAuthnSessionRepository(db),
+		audit:           sqlite.NewAuditRepository(db),
+		oauthClients:    sqlite.NewOAuthClientRepository(db),
+		authCodes:       sqlite.NewAuthorizationCodeRepository(db),
+		consents:        sqlite.NewConsentRepository(db),
+		machineClients:  sqlite.NewMachineClientRepository(db),
+	}, nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// ประเภทของ audit event
+const (
+	AuditRegister             = "register"
+	AuditLogin                = "login"
+	AuditPasskeyLogin         = "login.passkey"
+	AuditMFAVerify            = "login.mfa"
+	AuditTokenRefresh         = "token.refresh"
+	AuditLogout               = "logout"
+	AuditLogoutAll            = "logout.all"
+	AuditProfileUpdate        = "profile.update"
+	AuditProfileDelete        = "profile.delete"
+	AuditPasswordResetRequest = "password_reset.request"
+	AuditPasswordReset        = "password_reset.complete"
+	AuditRoleAssign           = "role.assign"
+	AuditRoleRevoke           = "role.revoke"
+	AuditMachineClientCreate  = "machine_client.create"
+	AuditMachineClientRotate  = "machine_client.rotate_secret"
+	AuditMachineClientDisable = "machine_client.disable"
+	AuditClientCredentials    = "token.client_credentials"
+	AuditTokenRevoke          = "token.revoke"
+)
+
+// ผลของ audit event
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent คือบันทึกหนึ่งรายการของการกระทำที่เกี่ยวกับความปลอดภัย (เขียนแล้วไม่แก้ไขหรือลบ)
+type AuditEvent struct {
+	ID        string    `bson:"_id"`
+	Type      string    `bson:"type"`
+	Outcome   string    `bson:"outcome"`
+	ActorID   string    `bson:"actorID,omitempty"`   // ผู้กระทำ (ว่างถ้ายังไม่รู้ตัวตน เช่น login ผิด)
+	SubjectID string    `bson:"subjectID,omitempty"` // บัญชีที่ถูกกระทำ
+	Email     string    `bson:"email,omitempty"`     // อีเมลที่ใช้ ณ ตอนนั้น
+	IP        string    `bson:"ip,omitempty"`
+	UserAgent string    `bson:"userAgent,omitempty"`
+	Reason    string    `bson:"reason,omitempty"` // reason ของ error เมื่อ failure หรือรายละเอียดเพิ่มเติม เช่น MFA_REQUIRED
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// AuditFilter คือเงื่อนไขค้นหา audit event ช่องที่ว่างหรือเป็น zero time ไม่ใช้กรอง
+type AuditFilter struct {
+	Type      string
+	Outcome   string
+	ActorID   string
+	SubjectID string
+	Since     time.Time // ตั้งแต่ (รวม)
+	Until     time.Time // ก่อน (ไม่รวม)
+}
@@ -0,0 +1,34 @@
+// internal/domain/machine_client.go
+package domain
+
+import "time"
+
+// ScopeIntrospect ให้ machine client เรียก IntrospectToken ได้ (ใช้กับ service ปลายทางที่ต้องตรวจ token เอง)
+const ScopeIntrospect = PermIntrospect
+
+// MachineClient คือ client แบบ service-to-service ที่ขอ token ในนามตัวเองด้วย client credentials grant
+// token ที่ได้มี sub เป็น ID ของ client และมีได้แค่ scope ที่ลงทะเบียนไว้
+type MachineClient struct {
+	ID         string     `bson:"_id"`
+	Name       string     `bson:"name"`
+	SecretHash string     `bson:"secretHash"` // SHA-256 (hex) ของ client secret
+	Scopes     []string   `bson:"scopes"`
+	CreatedAt  time.Time  `bson:"createdAt"`
+	RotatedAt  *time.Time `bson:"rotatedAt,omitempty"`  // เปลี่ยน secret ครั้งล่าสุด
+	DisabledAt *time.Time `bson:"disabledAt,omitempty"` // ปิดแล้วขอ token ใหม่ไม่ได้
+}
+
+// Disabled บอกว่า client ถูกปิดไปแล้วหรือยัง
+func (c *MachineClient) Disabled() bool {
+	return c.DisabledAt != nil
+}
+
+// AllowsScopes เช็คว่าทุก scope อยู่ในรายการที่ client ขอได้
+func (c *MachineClient) AllowsScopes(scopes []string) bool {
+	for _, s := range scopes {
+		if !contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+// internal/domain/oauth.go
+package domain
+
+import "time"
+
+// Scope ที่ client ขอได้ผ่าน OAuth 2.0
+const (
+	ScopeOpenID  = "openid"  // ขอ ID token และเรียก userinfo ได้ (OpenID Connect)
+	ScopeProfile = "profile" // อ่านโปรไฟล์ของผู้ใช้ (GetProfile) และ claim name
+	ScopeEmail   = "email"   // claim email และ email_verified
+)
+
+// SupportedScopes คือ scope ทั้งหมดที่ authorization server รู้จัก
+var SupportedScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// OAuthClient คือแอปภายนอกที่ลงทะเบียนไว้เพื่อขอ token แทนผู้ใช้
+type OAuthClient struct {
+	ID           string    `bson:"_id"`
+	Name         string    `bson:"name"`
+	SecretHash   string    `bson:"secretHash,omitempty"` // SHA-256 (hex) ของ client secret; ว่าง = public client
+	RedirectURIs []string  `bson:"redirectURIs"`
+	Scopes       []string  `bson:"scopes"` // scope ที่ client นี้ขอได้
+	CreatedAt    time.Time `bson:"createdAt"`
+}
+
+// Confidential บอกว่า client ต้องยืนยันตัวด้วย secret ตอนแลก code หรือไม่
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+// AllowsRedirect เช็คว่า uri ตรงกับที่ลงทะเบียนไว้ทุกตัวอักษร
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return contains(c.RedirectURIs, uri)
+}
+
+// AllowsScopes เช็คว่าทุก scope อยู่ในรายการที่ client ขอได้
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, s := range scopes {
+		if !contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// AuthorizationCode คือ code อายุสั้นที่ออกหลังผู้ใช้อนุญาต ใช้แลก access token ได้ครั้งเดียว
+type AuthorizationCode struct {
+	CodeHash      string    `bson:"_id"` // SHA-256 (hex) ของ code
+	ClientID      string    `bson:"clientID"`
+	UserID        string    `bson:"userID"`
+	RedirectURI   string    `bson:"redirectURI"`
+	Scopes        []string  `bson:"scopes"`
+	CodeChallenge string    `bson:"codeChallenge"`   // PKCE S256
+	Nonce         string    `bson:"nonce,omitempty"` // nonce ของ OpenID Connect ใส่กลับใน ID token
+	ExpiresAt     time.Time `bson:"expiresAt"`
+}
+
+// Consent คือ scope ที่ผู้ใช้เคยอนุญาตให้ client (ครั้งต่อไปไม่ต้องถามซ้ำ)
+type Consent struct {
+	UserID    string    `bson:"userID"`
+	ClientID  string    `bson:"clientID"`
+	Scopes    []string  `bson:"scopes"`
+	GrantedAt time.Time `bson:"grantedAt"`
+}
+
+// Covers เช็คว่า consent ครอบคลุมทุก scope ที่ขอ
+func (c *Consent) Covers(scopes []string) bool {
+	for _, s := range scopes {
+		if !contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+// internal/domain/refresh_token.go
+package domain
+
+import "time"
+
+// RefreshToken คือ refresh token หนึ่งตัวใน family (เก็บเฉพาะ hash ไม่เก็บค่าจริง)
+type RefreshToken struct {
+	TokenHash string     `bson:"tokenHash"`
+	UserID    string     `bson:"userID"`
+	FamilyID  string     `bson:"familyID"`            // token ทุกตัวที่ rotate ต่อกันมาจาก login ครั้งเดียวกัน
+	CreatedAt time.Time  `bson:"createdAt"`
+	ExpiresAt time.Time  `bson:"expiresAt"`
+	RotatedAt *time.Time `bson:"rotatedAt,omitempty"` // ถูกใช้แลก token ใหม่ไปแล้ว
+	RevokedAt *time.Time `bson:"revokedAt,omitempty"` // ถูกเพิกถอนทั้ง family
+}
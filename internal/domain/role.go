@@ -0,0 +1,54 @@
+// internal/domain/role.go
+package domain
+
+// Role ที่กำหนดให้ผู้ใช้ได้
+const (
+	RoleUser    = "user"    // ผู้ใช้ทั่วไป (ค่าเริ่มต้นตอนสมัคร)
+	RoleSupport = "support" // ดูรายชื่อผู้ใช้ได้ แต่แก้ role ไม่ได้
+	RoleAdmin   = "admin"   // ทำได้ทุกอย่าง
+)
+
+// Permission ที่ service layer ใช้ตรวจสิทธิ์
+const (
+	PermUsersRead    = "users:read"        // ดูรายชื่อ/ข้อมูลผู้ใช้คนอื่น
+	PermRolesWrite   = "roles:write"       // กำหนดหรือถอน role ของผู้ใช้
+	PermUsersUnlock  = "users:unlock"      // ปลดล็อกบัญชีที่ถูกล็อกจากการ login ผิดซ้ำ
+	PermAuditRead    = "audit:read"        // ดู audit log
+	PermClientsWrite = "clients:write"     // ลงทะเบียน OAuth client
+	PermIntrospect   = "tokens:introspect" // ตรวจสถานะ token ของผู้อื่น (IntrospectToken)
+)
+
+// rolePermissions จับคู่ role กับ permission ที่ได้
+var rolePermissions = map[string][]string{
+	RoleUser:    {},
+	RoleSupport: {PermUsersRead, PermUsersUnlock},
+	RoleAdmin:   {PermUsersRead, PermRolesWrite, PermUsersUnlock, PermAuditRead, PermClientsWrite, PermIntrospect},
+}
+
+// ValidRole เช็คว่าเป็น role ที่ระบบรู้จักหรือไม่
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission เช็คว่า role ใดๆ ใน roles มี permission นี้หรือไม่
+func HasPermission(roles []string, perm string) bool {
+	for _, r := range roles {
+		for _, p := range rolePermissions[r] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasRole เช็คว่าผู้ใช้มี role นี้แล้วหรือไม่
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -11,4 +11,16 @@ type User struct {
 	CreatedAt    time.Time `bson:"created_at"` 
 	Name         string     `bson:"name,omitempty"`       
 	DeletedAt    *time.Time `bson:"deletedAt,omitempty"`  // สำหรับ soft delete
+
+	Roles []string `bson:"roles,omitempty"` // ดู role.go
+
+	EmailVerified   bool       `bson:"emailVerified"`
+	EmailVerifiedAt *time.Time `bson:"emailVerifiedAt,omitempty"`
+
+	// TOTP multi-factor authentication
+	MFAEnabled        bool     `bson:"mfaEnabled,omitempty"`
+	TOTPSecret        string   `bson:"totpSecret,omitempty"`
+	TOTPPendingSecret string   `bson:"totpPendingSecret,omitempty"` // รอยืนยันด้วยรหัสแรก
+	TOTPLastStep      int64    `bson:"totpLastStep,omitempty"`      // step ล่าสุดที่ใช้ไปแล้ว (กันใช้รหัสซ้ำ)
+	RecoveryCodes     []string `bson:"recoveryCodes,omitempty"`     // SHA-256 ของ recovery code ที่ยังไม่ถูกใช้
 }
\ No newline at end of file
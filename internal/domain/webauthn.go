@@ -0,0 +1,20 @@
+// internal/domain/webauthn.go
+package domain
+
+import "time"
+
+// WebAuthnCredential คือ passkey / security key ที่ผู้ใช้ลงทะเบียนไว้
+type WebAuthnCredential struct {
+	ID              string     `bson:"_id"` // credential ID (base64url)
+	UserID          string     `bson:"userID"`
+	Name            string     `bson:"name,omitempty"`
+	PublicKey       []byte     `bson:"publicKey"` // COSE public key
+	AttestationType string     `bson:"attestationType,omitempty"`
+	Transports      []string   `bson:"transports,omitempty"`
+	AAGUID          []byte     `bson:"aaguid,omitempty"`
+	SignCount       uint32     `bson:"signCount"`
+	BackupEligible  bool       `bson:"backupEligible"`
+	BackupState     bool       `bson:"backupState"`
+	CreatedAt       time.Time  `bson:"createdAt"`
+	LastUsedAt      *time.Time `bson:"lastUsedAt,omitempty"`
+}
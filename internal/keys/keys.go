@@ -0,0 +1,365 @@
+// Package keys จัดการ key สำหรับเซ็นและตรวจสอบ JWT (HS256, RS256, ES256, EdDSA)
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// อัลกอริทึมที่รองรับ
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Key คือ key หนึ่งตัวพร้อม kid และอัลกอริทึมที่ใช้
+type Key struct {
+	ID        string
+	Algorithm string
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// Method คืน jwt.SigningMethod ที่ตรงกับ Algorithm
+func (k *Key) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// SigningKey คืน private key (หรือ secret) สำหรับเซ็น token
+func (k *Key) SigningKey() interface{} {
+	return k.signKey
+}
+
+// VerifyKey คืน public key (หรือ secret) สำหรับตรวจ token
+func (k *Key) VerifyKey() interface{} {
+	return k.verifyKey
+}
+
+// CanSign บอกว่า key นี้มี private part สำหรับเซ็นหรือไม่
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// Symmetric บอกว่าเป็น shared secret (ห้ามเผยแพร่ใน JWKS)
+func (k *Key) Symmetric() bool {
+	return k.Algorithm == HS256
+}
+
+// NewHMACKey สร้าง HS256 key จาก shared secret
+// ถ้า id ว่างจะใช้ hash บางส่วนของ secret เป็น kid
+func NewHMACKey(id, secret string) (*Key, error) {
+	if secret == "" {
+		return nil, errors.New("empty HMAC secret")
+	}
+	if id == "" {
+		sum := sha256.Sum256([]byte(secret))
+		id = "hs256-" + hex.EncodeToString(sum[:4])
+	}
+	b := []byte(secret)
+	return &Key{ID: id, Algorithm: HS256, signKey: b, verifyKey: b}, nil
+}
+
+// NewPrivateKey ห่อ private key ที่ parse แล้วให้เป็น Key
+// ถ้า id ว่างจะใช้ JWK thumbprint (RFC 7638) ของ public key
+func NewPrivateKey(id, alg string, priv crypto.Signer) (*Key, error) {
+	if err := checkKeyType(alg, priv.Public()); err != nil {
+		return nil, err
+	}
+	k := &Key{ID: id, Algorithm: alg, signKey: priv, verifyKey: priv.Public()}
+	if k.ID == "" {
+		tp, err := k.thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		k.ID = tp
+	}
+	return k, nil
+}
+
+// NewPublicKey สร้าง Key ที่ใช้ตรวจสอบได้อย่างเดียว
+func NewPublicKey(id, alg string, pub crypto.PublicKey) (*Key, error) {
+	if err := checkKeyType(alg, pub); err != nil {
+		return nil, err
+	}
+	k := &Key{ID: id, Algorithm: alg, verifyKey: pub}
+	if k.ID == "" {
+		tp, err := k.thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		k.ID = tp
+	}
+	return k, nil
+}
+
+// ParsePrivateKeyPEM อ่าน private key จาก PEM (PKCS#8, PKCS#1 หรือ SEC1)
+func ParsePrivateKeyPEM(id, alg string, data []byte) (*Key, error) {
+	signer, err := parsePrivatePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(id, alg, signer)
+}
+
+// ParsePublicKeyPEM อ่าน key สำหรับตรวจสอบอย่างเดียวจาก PEM
+// รับทั้ง public key (PKIX) และ private key; อัลกอริทึมอนุมานจากชนิดของ key
+func ParsePublicKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	var pub crypto.PublicKey
+	if block.Type == "PUBLIC KEY" {
+		p, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key: %w", err)
+		}
+		pub = p
+	} else {
+		signer, err := parsePrivatePEM(data)
+		if err != nil {
+			return nil, err
+		}
+		pub = signer.Public()
+	}
+	return NewPublicKey(id, algorithmFor(pub), pub)
+}
+
+// parsePrivatePEM decode PEM แล้ว parse private key ตามชนิดของ block
+func parsePrivatePEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	var (
+		priv interface{}
+		err  error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		priv, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	return signer, nil
+}
+
+// algorithmFor เดาอัลกอริทึมจากชนิดของ public key
+func algorithmFor(pub crypto.PublicKey) string {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return RS256
+	case *ecdsa.PublicKey:
+		return ES256
+	case ed25519.PublicKey:
+		return EdDSA
+	}
+	return ""
+}
+
+// Generate สร้าง key pair ใหม่ตามอัลกอริทึม
+func Generate(alg string) (*Key, error) {
+	var (
+		priv crypto.Signer
+		err  error
+	)
+	switch alg {
+	case HS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return NewHMACKey("", base64.RawURLEncoding.EncodeToString(secret))
+	case RS256:
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case ES256:
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("cannot generate key for algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey("", alg, priv)
+}
+
+// checkKeyType ตรวจว่าชนิดของ key ตรงกับอัลกอริทึม
+func checkKeyType(alg string, pub crypto.PublicKey) error {
+	switch alg {
+	case RS256:
+		if _, ok := pub.(*rsa.PublicKey); ok {
+			return nil
+		}
+	case ES256:
+		if k, ok := pub.(*ecdsa.PublicKey); ok && k.Curve == elliptic.P256() {
+			return nil
+		}
+	case EdDSA:
+		if _, ok := pub.(ed25519.PublicKey); ok {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	return fmt.Errorf("key type %T does not match algorithm %s", pub, alg)
+}
+
+// JWK คือ public key ในรูป JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS คือชุด JWK สำหรับ /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK แปลง public key เป็น JWK (ใช้ไม่ได้กับ HS256)
+func (k *Key) JWK() (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = b64(pub.N.Bytes())
+		j.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		j.Kty = "EC"
+		j.Crv = pub.Curve.Params().Name
+		j.X = b64(pub.X.FillBytes(make([]byte, size)))
+		j.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.X = b64(pub)
+	default:
+		return JWK{}, errors.New("key has no public JWK representation")
+	}
+	return j, nil
+}
+
+// ParseJWK แปลง JWK กลับเป็น Key สำหรับตรวจสอบอย่างเดียว (เช่น key ที่ได้จาก jwks_uri ของ issuer อื่น)
+func ParseJWK(j JWK) (*Key, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	var pub crypto.PublicKey
+	switch j.Kty {
+	case "RSA":
+		n, err := b64(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := b64(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, errX := b64(j.X)
+		y, errY := b64(j.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		ec := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(ec.X, ec.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		pub = ec
+	case "OKP":
+		x, err := b64(j.X)
+		if j.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		pub = ed25519.PublicKey(x)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+	alg := j.Alg
+	if alg == "" {
+		alg = algorithmFor(pub)
+	}
+	return NewPublicKey(j.Kid, alg, pub)
+}
+
+// thumbprint คำนวณ JWK thumbprint ตาม RFC 7638 (SHA-256, base64url)
+func (k *Key) thumbprint() (string, error) {
+	j, err := k.JWK()
+	if err != nil {
+		return "", err
+	}
+	var canonical string
+	switch j.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, j.E, j.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, j.Crv, j.X, j.Y)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, j.Crv, j.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Load สร้าง signing key ตามค่า config
+// HS256 ใช้ secret, อัลกอริทึมอื่นอ่าน private key จากไฟล์ PEM
+func Load(alg, keyID, privateKeyFile, secret string) (*Key, error) {
+	if alg == "" || alg == HS256 {
+		return NewHMACKey(keyID, secret)
+	}
+	if privateKeyFile == "" {
+		return nil, fmt.Errorf("%s requires a private key file", alg)
+	}
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read private key: %w", err)
+	}
+	return ParsePrivateKeyPEM(keyID, alg, data)
+}
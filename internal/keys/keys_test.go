@@ -0,0 +1,256 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signAndVerify เซ็น token ด้วย signer แล้วตรวจด้วย verifier
+func signAndVerify(t *testing.T, signer, verifier *Key) error {
+	t.Helper()
+	tok := jwt.NewWithClaims(signer.Method(), jwt.RegisteredClaims{Subject: "user-1"})
+	raw, err := tok.SignedString(signer.SigningKey())
+	if err != nil {
+		t.Fatalf("SignedString(%s): %v", signer.Algorithm, err)
+	}
+	_, err = jwt.Parse(raw, func(*jwt.Token) (interface{}, error) { return verifier.VerifyKey(), nil },
+		jwt.WithValidMethods([]string{verifier.Algorithm}))
+	return err
+}
+
+// pemFile เขียน PEM block ลงไฟล์ชั่วคราวแล้วคืน path
+func pemFile(t *testing.T, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestGenerateSignsAndVerifies(t *testing.T) {
+	for _, alg := range []string{HS256, RS256, ES256, EdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			k, err := Generate(alg)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if k.Algorithm != alg || k.ID == "" || !k.CanSign() || k.Symmetric() != (alg == HS256) {
+				t.Fatalf("key = %+v", k)
+			}
+			if k.Method().Alg() != alg {
+				t.Fatalf("Method = %s, want %s", k.Method().Alg(), alg)
+			}
+			if err := signAndVerify(t, k, k); err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			other, _ := Generate(alg)
+			if err := signAndVerify(t, other, k); err == nil {
+				t.Fatal("a token signed by another key verified")
+			}
+		})
+	}
+	if _, err := Generate("none"); err == nil {
+		t.Fatal("Generate(none) succeeded")
+	}
+}
+
+func TestLoadPEM(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	pkcs8 := func(k crypto.Signer) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+		}
+		return der
+	}
+	sec1, _ := x509.MarshalECPrivateKey(ecKey)
+
+	for _, tc := range []struct {
+		name, alg, typ string
+		der            []byte
+		pub            crypto.PublicKey
+	}{
+		{"RS256 PKCS#1", RS256, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), rsaKey.Public()},
+		{"RS256 PKCS#8", RS256, "PRIVATE KEY", pkcs8(rsaKey), rsaKey.Public()},
+		{"ES256 SEC1", ES256, "EC PRIVATE KEY", sec1, ecKey.Public()},
+		{"ES256 PKCS#8", ES256, "PRIVATE KEY", pkcs8(ecKey), ecKey.Public()},
+		{"EdDSA PKCS#8", EdDSA, "PRIVATE KEY", pkcs8(edKey), edKey.Public()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := Load(tc.alg, "", pemFile(t, tc.typ, tc.der), "")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if k.Algorithm != tc.alg || !k.CanSign() {
+				t.Fatalf("key = %+v", k)
+			}
+			// kid ว่างจะได้ thumbprint ซึ่งเหมือนกันทุกครั้งที่โหลด key เดิม
+			again, _ := Load(tc.alg, "", pemFile(t, tc.typ, tc.der), "")
+			if k.ID != again.ID {
+				t.Fatalf("kid changed between loads: %s != %s", k.ID, again.ID)
+			}
+			if named, _ := Load(tc.alg, "main", pemFile(t, tc.typ, tc.der), ""); named.ID != "main" {
+				t.Fatalf("configured kid = %q, want main", named.ID)
+			}
+
+			// public key อย่างเดียว (PKIX) ตรวจ token ของ private key ได้ และอนุมานอัลกอริทึมได้เอง
+			pkix, err := x509.MarshalPKIXPublicKey(tc.pub)
+			if err != nil {
+				t.Fatalf("MarshalPKIXPublicKey: %v", err)
+			}
+			pub, err := ParsePublicKeyPEM("", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+			if err != nil {
+				t.Fatalf("ParsePublicKeyPEM: %v", err)
+			}
+			if pub.Algorithm != tc.alg || pub.CanSign() || pub.ID != k.ID {
+				t.Fatalf("public key = %+v, want verify-only %s with kid %s", pub, tc.alg, k.ID)
+			}
+			if err := signAndVerify(t, k, pub); err != nil {
+				t.Fatalf("verify with the public key: %v", err)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := Load(RS256, "", "", ""); err == nil {
+			t.Error("Load(RS256) without a key file succeeded")
+		}
+		if _, err := Load(RS256, "", filepath.Join(t.TempDir(), "missing.pem"), ""); err == nil {
+			t.Error("Load of a missing file succeeded")
+		}
+		if _, err := Load(ES256, "", pemFile(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), ""); err == nil {
+			t.Error("Load(ES256) of an RSA key succeeded")
+		}
+		if _, err := ParsePrivateKeyPEM("", RS256, []byte("not pem")); err == nil {
+			t.Error("ParsePrivateKeyPEM(not pem) succeeded")
+		}
+		p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		if _, err := NewPrivateKey("", ES256, p384); err == nil {
+			t.Error("NewPrivateKey(ES256) accepted a P-384 key")
+		}
+		if _, err := NewPrivateKey("", HS256, ecKey); err == nil {
+			t.Error("NewPrivateKey(HS256) accepted an EC key")
+		}
+	})
+
+	t.Run("HS256", func(t *testing.T) {
+		k, err := Load("", "", "", "top-secret")
+		if err != nil || k.Algorithm != HS256 || !strings.HasPrefix(k.ID, "hs256-") {
+			t.Fatalf("Load(HS256) = %+v, %v", k, err)
+		}
+		if _, err := Load(HS256, "", "", ""); err == nil {
+			t.Fatal("Load(HS256) with an empty secret succeeded")
+		}
+	})
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		alg, kty, crv string
+	}{
+		{RS256, "RSA", ""},
+		{ES256, "EC", "P-256"},
+		{EdDSA, "OKP", "Ed25519"},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			k, _ := Generate(tc.alg)
+			j, err := k.JWK()
+			if err != nil {
+				t.Fatalf("JWK: %v", err)
+			}
+			if j.Kty != tc.kty || j.Crv != tc.crv || j.Alg != tc.alg || j.Kid != k.ID || j.Use != "sig" {
+				t.Fatalf("JWK = %+v", j)
+			}
+			raw, _ := json.Marshal(j)
+			if strings.Contains(string(raw), `"d"`) {
+				t.Fatalf("JWK %s exposes a private part", raw)
+			}
+			var decoded JWK
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			pub, err := ParseJWK(decoded)
+			if err != nil {
+				t.Fatalf("ParseJWK: %v", err)
+			}
+			if pub.ID != k.ID || pub.Algorithm != tc.alg || pub.CanSign() {
+				t.Fatalf("parsed key = %+v", pub)
+			}
+			if err := signAndVerify(t, k, pub); err != nil {
+				t.Fatalf("verify with the parsed JWK: %v", err)
+			}
+			// ไม่มี alg ใน JWK ก็อนุมานจากชนิดของ key ได้
+			decoded.Alg = ""
+			if pub, err := ParseJWK(decoded); err != nil || pub.Algorithm != tc.alg {
+				t.Fatalf("ParseJWK without alg = %+v, %v", pub, err)
+			}
+		})
+	}
+
+	hmac, _ := NewHMACKey("", "top-secret")
+	if _, err := hmac.JWK(); err == nil {
+		t.Fatal("JWK of an HS256 key succeeded")
+	}
+}
+
+func TestParseJWKRejectsInvalid(t *testing.T) {
+	ec, _ := Generate(ES256)
+	valid, _ := ec.JWK()
+	offCurve := valid
+	offCurve.Y = valid.X
+	ed, _ := Generate(EdDSA)
+	edJWK, _ := ed.JWK()
+	shortEd := edJWK
+	shortEd.X = edJWK.X[:10]
+	rsaKey, _ := Generate(RS256)
+	rsaJWK, _ := rsaKey.JWK()
+	bigExponent := rsaJWK
+	bigExponent.E = "AQABAQAB"
+	wrongAlg := valid
+	wrongAlg.Alg = RS256
+
+	for name, j := range map[string]JWK{
+		"unknown kty":          {Kty: "oct", Kid: "k"},
+		"unsupported curve":    {Kty: "EC", Crv: "P-192", X: valid.X, Y: valid.Y},
+		"point not on curve":   offCurve,
+		"short Ed25519 key":    shortEd,
+		"wrong OKP curve":      {Kty: "OKP", Crv: "X25519", X: edJWK.X},
+		"RSA exponent too big": bigExponent,
+		"alg does not match":   wrongAlg,
+	} {
+		if _, err := ParseJWK(j); err == nil {
+			t.Errorf("%s: ParseJWK succeeded", name)
+		}
+	}
+}
+
+func TestThumbprintMatchesRFC7638(t *testing.T) {
+	// ตัวอย่างใน RFC 7638 section 3.1
+	k, err := ParseJWK(JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	})
+	if err != nil {
+		t.Fatalf("ParseJWK: %v", err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; k.ID != want {
+		t.Fatalf("thumbprint kid = %s, want %s", k.ID, want)
+	}
+}
@@ -0,0 +1,202 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// State คือสถานะของ key ใน Ring
+type State int
+
+const (
+	// Active ใช้เซ็น token ใหม่ (มีได้ตัวเดียว)
+	Active State = iota
+	// VerifyOnly ใช้ตรวจ token ที่ออกไปแล้วเท่านั้น
+	VerifyOnly
+	// Retired ไม่ยอมรับ token ที่เซ็นด้วย key นี้อีก
+	Retired
+)
+
+func (s State) String() string {
+	switch s {
+	case Active:
+		return "active"
+	case VerifyOnly:
+		return "verify-only"
+	case Retired:
+		return "retired"
+	}
+	return "unknown"
+}
+
+// ErrKeyRetired ถูกคืนเมื่อ token อ้างถึง kid ที่ถูก retire ไปแล้ว
+var ErrKeyRetired = errors.New("signing key retired")
+
+// ErrUnknownKey ถูกคืนเมื่อไม่พบ kid ใน Ring
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type ringEntry struct {
+	key      *Key
+	state    State
+	retireAt time.Time // zero = verify-only ไปจนกว่าจะ retire เอง
+}
+
+// Ring เก็บ key หลายตัวพร้อมสถานะ เพื่อให้ rotate key ได้โดย token เดิมยังใช้ได้
+// จนหมดช่วง overlap
+type Ring struct {
+	mu      sync.RWMutex
+	entries map[string]*ringEntry
+	active  string
+	overlap time.Duration
+}
+
+// NewRing สร้าง Ring โดยมี active เป็น key สำหรับเซ็น
+// overlap คือระยะเวลาที่ key เดิมยังตรวจสอบได้หลัง rotate (ควร >= อายุ access token)
+func NewRing(active *Key, overlap time.Duration) (*Ring, error) {
+	if active == nil || !active.CanSign() {
+		return nil, errors.New("active key must be able to sign")
+	}
+	return &Ring{
+		entries: map[string]*ringEntry{active.ID: {key: active, state: Active}},
+		active:  active.ID,
+		overlap: overlap,
+	}, nil
+}
+
+// AddVerifyOnly เพิ่ม key ที่ใช้ตรวจสอบอย่างเดียว (เช่น key/secret ก่อนหน้า)
+func (r *Ring) AddVerifyOnly(k *Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if k.ID == r.active {
+		return
+	}
+	r.entries[k.ID] = &ringEntry{key: k, state: VerifyOnly}
+}
+
+// Active คืน key ที่ใช้เซ็น token ในขณะนี้
+func (r *Ring) Active() *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.entries[r.active].key
+}
+
+// Lookup หา key สำหรับตรวจสอบจาก kid (ไม่คืน key ที่ retired)
+func (r *Ring) Lookup(kid string) (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if e.state == Retired || (!e.retireAt.IsZero() && time.Now().After(e.retireAt)) {
+		return nil, ErrKeyRetired
+	}
+	return e.key, nil
+}
+
+// Rotate ตั้ง next เป็น active key ตัวใหม่ แล้วลด key เดิมเป็น verify-only
+// จนครบช่วง overlap
+func (r *Ring) Rotate(next *Key) error {
+	if next == nil || !next.CanSign() {
+		return errors.New("active key must be able to sign")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if prev, ok := r.entries[r.active]; ok && prev.key.ID != next.ID {
+		prev.state = VerifyOnly
+		prev.retireAt = time.Now().Add(r.overlap)
+	}
+	r.entries[next.ID] = &ringEntry{key: next, state: Active}
+	r.active = next.ID
+	return nil
+}
+
+// Retire เลิกยอมรับ key ทันที (เช่น key หลุด); retire active key ไม่ได้
+func (r *Ring) Retire(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if kid == r.active {
+		return errors.New("cannot retire the active key; rotate first")
+	}
+	e, ok := r.entries[kid]
+	if !ok {
+		return ErrUnknownKey
+	}
+	e.state = Retired
+	e.retireAt = time.Now()
+	return nil
+}
+
+// Prune เปลี่ยนสถานะ key ที่หมด overlap เป็น retired
+// และลบ key ที่ retired นานเกินช่วง overlap ออกจาก Ring
+func (r *Ring) Prune(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for kid, e := range r.entries {
+		if e.state == VerifyOnly && !e.retireAt.IsZero() && now.After(e.retireAt) {
+			e.state = Retired
+		}
+		if e.state == Retired && now.After(e.retireAt.Add(r.overlap)) {
+			delete(r.entries, kid)
+		}
+	}
+}
+
+// State คืนสถานะของ kid
+func (r *Ring) State(kid string) (State, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[kid]
+	if !ok {
+		return 0, false
+	}
+	return e.state, true
+}
+
+// VerificationKeys คืน key ที่ยังใช้ตรวจสอบได้ (active + verify-only)
+func (r *Ring) VerificationKeys() []*Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	out := []*Key{r.entries[r.active].key}
+	for kid, e := range r.entries {
+		if kid == r.active || e.state == Retired {
+			continue
+		}
+		if !e.retireAt.IsZero() && now.After(e.retireAt) {
+			continue
+		}
+		out = append(out, e.key)
+	}
+	return out
+}
+
+// StartRotation สร้าง key ใหม่ด้วย generate และ rotate ทุก interval จนกว่า ctx จะถูกยกเลิก
+// key ที่สร้างอยู่ในหน่วยความจำของ process เท่านั้น จึงเหมาะกับการรัน instance เดียว
+func (r *Ring) StartRotation(ctx context.Context, interval time.Duration, generate func() (*Key, error)) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				r.Prune(now)
+				k, err := generate()
+				if err != nil {
+					log.Printf("key rotation failed: %v", err)
+					continue
+				}
+				if err := r.Rotate(k); err != nil {
+					log.Printf("key rotation failed: %v", err)
+					continue
+				}
+				log.Printf("rotated JWT signing key; new kid=%s", k.ID)
+			}
+		}
+	}()
+}
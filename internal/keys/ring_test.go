@@ -0,0 +1,158 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustGenerate(t *testing.T, alg string) *Key {
+	t.Helper()
+	k, err := Generate(alg)
+	if err != nil {
+		t.Fatalf("Generate(%s): %v", alg, err)
+	}
+	return k
+}
+
+// kids คืน kid ของ key ทั้งหมด
+func kids(keys []*Key) map[string]bool {
+	out := make(map[string]bool)
+	for _, k := range keys {
+		out[k.ID] = true
+	}
+	return out
+}
+
+func TestNewRingRequiresSigningKey(t *testing.T) {
+	k := mustGenerate(t, ES256)
+	pub, _ := NewPublicKey("", ES256, k.VerifyKey())
+	if _, err := NewRing(pub, time.Hour); err == nil {
+		t.Fatal("NewRing accepted a verify-only key")
+	}
+	if _, err := NewRing(nil, time.Hour); err == nil {
+		t.Fatal("NewRing accepted a nil key")
+	}
+}
+
+func TestRingRotationOverlap(t *testing.T) {
+	const overlap = time.Hour
+	prev := mustGenerate(t, ES256)
+	r, err := NewRing(prev, overlap)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	next := mustGenerate(t, ES256)
+	if err := r.Rotate(next); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// ช่วง overlap: token ของ key เดิมยังตรวจได้ และ key เดิมยังอยู่ใน JWKS
+	if r.Active().ID != next.ID {
+		t.Fatalf("active = %s, want %s", r.Active().ID, next.ID)
+	}
+	k, err := r.Lookup(prev.ID)
+	if err != nil {
+		t.Fatalf("Lookup(previous) during overlap: %v", err)
+	}
+	if err := signAndVerify(t, prev, k); err != nil {
+		t.Fatalf("token of the previous key during overlap: %v", err)
+	}
+	if st, _ := r.State(prev.ID); st != VerifyOnly {
+		t.Fatalf("previous key state = %s, want verify-only", st)
+	}
+	if got := kids(r.VerificationKeys()); !got[prev.ID] || !got[next.ID] {
+		t.Fatalf("verification keys = %v, want both", got)
+	}
+	// Prune ก่อนหมด overlap ไม่กระทบ key เดิม
+	r.Prune(time.Now().Add(overlap / 2))
+	if _, err := r.Lookup(prev.ID); err != nil {
+		t.Fatalf("Lookup(previous) after an early Prune: %v", err)
+	}
+
+	// หมด overlap: retire แล้วตรวจไม่ได้ และไม่อยู่ใน JWKS
+	r.Prune(time.Now().Add(overlap + time.Minute))
+	if _, err := r.Lookup(prev.ID); !errors.Is(err, ErrKeyRetired) {
+		t.Fatalf("Lookup(previous) after overlap = %v, want ErrKeyRetired", err)
+	}
+	if st, _ := r.State(prev.ID); st != Retired {
+		t.Fatalf("previous key state = %s, want retired", st)
+	}
+	if got := kids(r.VerificationKeys()); got[prev.ID] || !got[next.ID] {
+		t.Fatalf("verification keys = %v, want only the active key", got)
+	}
+
+	// retire นานเกิน overlap อีกรอบ: ลบออกจาก Ring
+	r.Prune(time.Now().Add(3 * overlap))
+	if _, err := r.Lookup(prev.ID); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Lookup(previous) after removal = %v, want ErrUnknownKey", err)
+	}
+	if _, ok := r.State(prev.ID); ok {
+		t.Fatal("pruned key still has a state")
+	}
+	if _, err := r.Lookup(next.ID); err != nil {
+		t.Fatalf("Lookup(active) after Prune: %v", err)
+	}
+}
+
+func TestRingLookupUnknownKid(t *testing.T) {
+	r, _ := NewRing(mustGenerate(t, EdDSA), time.Hour)
+	for _, kid := range []string{"", "nope", mustGenerate(t, EdDSA).ID} {
+		if _, err := r.Lookup(kid); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Lookup(%q) = %v, want ErrUnknownKey", kid, err)
+		}
+	}
+}
+
+func TestRingAddVerifyOnlyAndRetire(t *testing.T) {
+	active := mustGenerate(t, RS256)
+	r, _ := NewRing(active, time.Hour)
+	old, _ := NewHMACKey("old", "previous-secret")
+	r.AddVerifyOnly(old)
+	if k, err := r.Lookup("old"); err != nil || k != old {
+		t.Fatalf("Lookup(verify-only) = %v, %v", k, err)
+	}
+	// key ที่เพิ่มเป็น verify-only โดยไม่มีกำหนด retire ไม่หมดอายุเอง
+	r.Prune(time.Now().Add(24 * time.Hour))
+	if _, err := r.Lookup("old"); err != nil {
+		t.Fatalf("Lookup(verify-only) after Prune: %v", err)
+	}
+
+	if err := r.Retire(active.ID); err == nil {
+		t.Fatal("Retire(active) succeeded")
+	}
+	if err := r.Retire("nope"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Retire(unknown) = %v, want ErrUnknownKey", err)
+	}
+	if err := r.Retire("old"); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	if _, err := r.Lookup("old"); !errors.Is(err, ErrKeyRetired) {
+		t.Fatalf("Lookup(retired) = %v, want ErrKeyRetired", err)
+	}
+	// AddVerifyOnly ด้วย kid ของ active key ต้องไม่ลดสถานะ active key
+	r.AddVerifyOnly(active)
+	if st, _ := r.State(active.ID); st != Active {
+		t.Fatalf("active key state = %s after AddVerifyOnly", st)
+	}
+}
+
+func TestStartRotation(t *testing.T) {
+	r, _ := NewRing(mustGenerate(t, ES256), time.Hour)
+	first := r.Active().ID
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.StartRotation(ctx, 10*time.Millisecond, func() (*Key, error) { return Generate(ES256) })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Active().ID == first && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if r.Active().ID == first {
+		t.Fatal("StartRotation never rotated the active key")
+	}
+	if _, err := r.Lookup(first); err != nil {
+		t.Fatalf("Lookup(first key) during overlap: %v", err)
+	}
+}
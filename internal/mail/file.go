@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer เขียนอีเมลเป็นไฟล์ .eml ลงโฟลเดอร์ (สำหรับ dev เปิดดูด้วย mail client ได้)
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// NewFileMailer สร้าง FileMailer และสร้างโฟลเดอร์ถ้ายังไม่มี
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileMailer{Dir: dir, From: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := msg.Bytes(m.From)
+	if err != nil {
+		return err
+	}
+	to := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), to)
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o600)
+}
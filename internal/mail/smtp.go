@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer ส่งอีเมลผ่าน SMTP server และลองใหม่เมื่อเจอข้อผิดพลาดชั่วคราว
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+
+	// Retries คือจำนวนครั้งที่ลองใหม่หลังครั้งแรก
+	Retries int
+	// Backoff คือเวลารอก่อนลองใหม่ครั้งแรก (เพิ่มเป็นเท่าตัวในครั้งถัดไป)
+	Backoff time.Duration
+}
+
+// NewSMTPMailer สร้าง SMTPMailer พร้อมค่า retry เริ่มต้น (3 ครั้ง, เริ่มที่ 500ms)
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+		Retries:  3,
+		Backoff:  500 * time.Millisecond,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := msg.Bytes(m.From)
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	wait := m.Backoff
+	for attempt := 0; ; attempt++ {
+		err = smtp.SendMail(addr, auth, m.From, []string{msg.To}, raw)
+		if err == nil || !isTransient(err) || attempt >= m.Retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// isTransient บอกว่าควรลองส่งใหม่หรือไม่:
+// SMTP reply 4xx (เช่น 421, 450, 451) และปัญหาเครือข่าย ถือว่าชั่วคราว
+func isTransient(err error) bool {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code >= 400 && tpErr.Code < 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,219 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpStandIn คือ SMTP server จำลองบน localhost
+// reply คืนคำตอบของ connection ที่ n (เริ่มที่ 1) ต่อคำสั่ง cmd ("" = greeting)
+// คำตอบว่างหมายถึงตอบตามปกติ
+type smtpStandIn struct {
+	ln    net.Listener
+	reply func(n int, cmd string) string
+
+	mu    sync.Mutex
+	conns int
+	data  []string
+}
+
+func newSMTPStandIn(t *testing.T, reply func(n int, cmd string) string) *smtpStandIn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpStandIn{ln: ln, reply: reply}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		n := s.conns
+		s.mu.Unlock()
+		go s.handle(conn, n)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn, n int) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	respond := func(cmd, normal string) bool {
+		r := s.reply(n, cmd)
+		if r == "" {
+			r = normal
+		}
+		tp.PrintfLine("%s", r)
+		return r == normal
+	}
+	if !respond("", "220 stand-in ready") {
+		return
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " ")[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			respond(cmd, "250 stand-in")
+		case "DATA":
+			if !respond(cmd, "354 go ahead") {
+				continue
+			}
+			body, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = append(s.data, strings.Join(body, "\n"))
+			s.mu.Unlock()
+			respond("BODY", "250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			respond(cmd, "250 ok")
+		}
+	}
+}
+
+func (s *smtpStandIn) mailer() *SMTPMailer {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	m := NewSMTPMailer("127.0.0.1", addr.Port, "", "", "noreply@example.com")
+	m.Backoff = time.Millisecond
+	return m
+}
+
+func (s *smtpStandIn) stats() (conns int, delivered []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns, append([]string(nil), s.data...)
+}
+
+var testMessage = Message{To: "alice@example.com", Subject: "Hello", Text: "hi", HTML: "<p>hi</p>"}
+
+func TestSMTPMailerRetriesTransientErrors(t *testing.T) {
+	srv := newSMTPStandIn(t, func(n int, cmd string) string {
+		switch {
+		case n == 1 && cmd == "":
+			return "421 too busy"
+		case n == 2 && cmd == "RCPT":
+			return "451 try again later"
+		}
+		return ""
+	})
+	if err := srv.mailer().Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	conns, delivered := srv.stats()
+	if conns != 3 || len(delivered) != 1 {
+		t.Fatalf("connections = %d, delivered = %d; want 3 and 1", conns, len(delivered))
+	}
+	if !strings.Contains(delivered[0], "To: alice@example.com") {
+		t.Fatalf("delivered message lacks the recipient:\n%s", delivered[0])
+	}
+}
+
+func TestSMTPMailerDoesNotRetryPermanentErrors(t *testing.T) {
+	srv := newSMTPStandIn(t, func(n int, cmd string) string {
+		if cmd == "RCPT" {
+			return "550 no such user"
+		}
+		return ""
+	})
+	err := srv.mailer().Send(context.Background(), testMessage)
+	var tpErr *textproto.Error
+	if !errors.As(err, &tpErr) || tpErr.Code != 550 {
+		t.Fatalf("Send = %v, want the 550 reply", err)
+	}
+	if conns, _ := srv.stats(); conns != 1 {
+		t.Fatalf("connections = %d, want 1 (no retry)", conns)
+	}
+}
+
+func TestSMTPMailerGivesUpAfterRetries(t *testing.T) {
+	srv := newSMTPStandIn(t, func(n int, cmd string) string {
+		if cmd == "BODY" {
+			return "452 mailbox full"
+		}
+		return ""
+	})
+	m := srv.mailer()
+	m.Retries = 2
+	err := m.Send(context.Background(), testMessage)
+	var tpErr *textproto.Error
+	if !errors.As(err, &tpErr) || tpErr.Code != 452 {
+		t.Fatalf("Send = %v, want the 452 reply", err)
+	}
+	if conns, _ := srv.stats(); conns != 3 {
+		t.Fatalf("connections = %d, want 3 (first try + 2 retries)", conns)
+	}
+}
+
+func TestSMTPMailerRetriesConnectionErrors(t *testing.T) {
+	// server ที่ตัด connection ทันที: ได้ EOF ซึ่งถือว่าชั่วคราว
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	var mu sync.Mutex
+	conns := 0
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns++
+			mu.Unlock()
+			c.Close()
+		}
+	}()
+	m := NewSMTPMailer("127.0.0.1", ln.Addr().(*net.TCPAddr).Port, "", "", "noreply@example.com")
+	m.Retries, m.Backoff = 1, time.Millisecond
+	if err := m.Send(context.Background(), testMessage); err == nil {
+		t.Fatal("Send to a server that hangs up succeeded")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 2 {
+		t.Fatalf("connections = %d, want 2", conns)
+	}
+}
+
+func TestSMTPMailerStopsWhenContextIsDone(t *testing.T) {
+	srv := newSMTPStandIn(t, func(n int, cmd string) string {
+		if cmd == "" {
+			return "421 too busy"
+		}
+		return ""
+	})
+	m := srv.mailer()
+	m.Backoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.Send(ctx, testMessage); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send = %v, want context.DeadlineExceeded", err)
+	}
+	if conns, _ := srv.stats(); conns != 1 {
+		t.Fatalf("connections = %d, want 1", conns)
+	}
+}
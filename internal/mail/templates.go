@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+)
+
+// ชื่อ template ที่มีให้ใช้
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+// Render สร้าง Message จาก template <name>.txt (ต้องมี block "subject") และ <name>.html
+func Render(name, to string, data interface{}) (Message, error) {
+	var subject, text, html bytes.Buffer
+	txt := textTemplates.Lookup(name + ".txt")
+	if txt == nil {
+		return Message{}, errUnknownTemplate(name)
+	}
+	if err := txt.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := textTemplates.ExecuteTemplate(&subject, name+"_subject", data); err != nil {
+		return Message{}, err
+	}
+	if h := htmlTemplates.Lookup(name + ".html"); h != nil {
+		if err := h.Execute(&html, data); err != nil {
+			return Message{}, err
+		}
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+type errUnknownTemplate string
+
+func (e errUnknownTemplate) Error() string {
+	return "unknown mail template: " + string(e)
+}
@@ -0,0 +1,128 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachChecker บอกว่ารหัสผ่านเคยอยู่ในข้อมูลที่รั่วหรือไม่
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// prefixLen คือความยาว prefix ของ SHA-1 (hex) ที่ใช้แบ่งช่วงแบบ k-anonymity ของ Pwned Passwords
+const prefixLen = 5
+
+// RangeDir อ่านชุดรหัสที่รั่วจากโฟลเดอร์ในรูปแบบ range ของ Pwned Passwords:
+// ไฟล์ชื่อ prefix 5 ตัวของ SHA-1 (เช่น 21BD1 หรือ 21BD1.txt) แต่ละบรรทัดคือ "SUFFIX:COUNT"
+// เปิดเฉพาะไฟล์ของ prefix ที่ต้องการ จึงใช้กับชุดข้อมูลขนาดใหญ่ได้โดยไม่ต้องโหลดเข้าหน่วยความจำ
+type RangeDir struct {
+	Dir string
+}
+
+// NewRangeDir สร้าง RangeDir จากโฟลเดอร์ dir
+func NewRangeDir(dir string) *RangeDir {
+	return &RangeDir{Dir: dir}
+}
+
+func (r *RangeDir) Breached(ctx context.Context, password string) (bool, error) {
+	prefix, suffix := sha1Range(password)
+	f, err := os.Open(filepath.Join(r.Dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(filepath.Join(r.Dir, prefix+".txt"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	found := false
+	err = scanHashes(f, func(hash string) bool {
+		found = hash == suffix || hash == prefix+suffix
+		return !found
+	})
+	return found, err
+}
+
+// HashList เก็บ SHA-1 ของรหัสที่รั่วในหน่วยความจำ แบ่งตาม prefix แบบเดียวกับ RangeDir
+type HashList struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadHashList โหลดไฟล์ที่แต่ละบรรทัดคือ SHA-1 (hex 40 ตัว) ตามด้วย ":COUNT" หรือไม่ก็ได้
+// เช่นไฟล์ pwned-passwords-sha1 ที่ตัดเหลือเฉพาะรหัสที่พบบ่อย
+func LoadHashList(path string) (*HashList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l := &HashList{ranges: make(map[string]map[string]struct{})}
+	err = scanHashes(f, func(hash string) bool {
+		if len(hash) != 2*sha1.Size {
+			return true
+		}
+		prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+		if l.ranges[prefix] == nil {
+			l.ranges[prefix] = make(map[string]struct{})
+		}
+		l.ranges[prefix][suffix] = struct{}{}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *HashList) Breached(ctx context.Context, password string) (bool, error) {
+	prefix, suffix := sha1Range(password)
+	_, ok := l.ranges[prefix][suffix]
+	return ok, nil
+}
+
+// LoadBreachChecker เปิด path เป็น RangeDir ถ้าเป็นโฟลเดอร์ หรือ HashList ถ้าเป็นไฟล์
+func LoadBreachChecker(path string) (BreachChecker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return NewRangeDir(path), nil
+	}
+	return LoadHashList(path)
+}
+
+// sha1Range คืน prefix 5 ตัวและส่วนที่เหลือของ SHA-1 (hex ตัวใหญ่) ของรหัสผ่าน
+func sha1Range(password string) (prefix, suffix string) {
+	sum := sha1.Sum([]byte(password))
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return h[:prefixLen], h[prefixLen:]
+}
+
+// scanHashes เรียก fn กับ hash (ตัวใหญ่, ตัด ":COUNT" ออก) ของทุกบรรทัดจนกว่า fn คืน false
+func scanHashes(r io.Reader, fn func(hash string) bool) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		if !fn(strings.ToUpper(hash)) {
+			break
+		}
+	}
+	return sc.Err()
+}
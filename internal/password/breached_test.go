@@ -0,0 +1,64 @@
+package password
+
+import (
+	"context"
+	"testing"
+)
+
+// testdata/ranges มีไฟล์ range ของ prefix 5BAA6 ("password") และ 7C4A8.txt ("123456", suffix ตัวเล็ก)
+// testdata/pwned-sha1.txt มี SHA-1 เต็มของ "letmein" และ "qwerty"
+func TestBreachCheckers(t *testing.T) {
+	rangeDir, err := LoadBreachChecker("testdata/ranges")
+	if err != nil {
+		t.Fatalf("LoadBreachChecker(dir): %v", err)
+	}
+	if _, ok := rangeDir.(*RangeDir); !ok {
+		t.Fatalf("LoadBreachChecker(dir) = %T, want *RangeDir", rangeDir)
+	}
+	hashList, err := LoadBreachChecker("testdata/pwned-sha1.txt")
+	if err != nil {
+		t.Fatalf("LoadBreachChecker(file): %v", err)
+	}
+	if _, ok := hashList.(*HashList); !ok {
+		t.Fatalf("LoadBreachChecker(file) = %T, want *HashList", hashList)
+	}
+
+	for _, tc := range []struct {
+		checker  BreachChecker
+		password string
+		want     bool
+	}{
+		{rangeDir, "password", true},
+		{rangeDir, "123456", true},    // ไฟล์ .txt และ suffix ตัวเล็ก
+		{rangeDir, "Password", false}, // prefix ไม่มีไฟล์
+		{rangeDir, "letmein", false},
+		{hashList, "letmein", true},
+		{hashList, "qwerty", true}, // hash ตัวเล็ก ไม่มี :COUNT
+		{hashList, "password", false},
+		{hashList, "correct-horse-battery", false},
+	} {
+		got, err := tc.checker.Breached(context.Background(), tc.password)
+		if err != nil {
+			t.Errorf("%T.Breached(%q): %v", tc.checker, tc.password, err)
+		} else if got != tc.want {
+			t.Errorf("%T.Breached(%q) = %v, want %v", tc.checker, tc.password, got, tc.want)
+		}
+	}
+}
+
+func TestRangeDirKAnonymity(t *testing.T) {
+	// เปิดเฉพาะไฟล์ของ prefix 5 ตัวแรก ส่วนที่เหลือเทียบภายในไฟล์ ไม่พบไฟล์ = ไม่รั่ว
+	prefix, suffix := sha1Range("password")
+	if prefix != "5BAA6" || suffix != "1E4C9B93F3F0682250B6CF8331B7EE68FD8" {
+		t.Fatalf("sha1Range(password) = %s, %s", prefix, suffix)
+	}
+	if ok, err := NewRangeDir("testdata/missing").Breached(context.Background(), "password"); ok || err != nil {
+		t.Fatalf("missing dir: Breached = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestLoadBreachCheckerMissingPath(t *testing.T) {
+	if _, err := LoadBreachChecker("testdata/does-not-exist"); err == nil {
+		t.Fatal("LoadBreachChecker on a missing path succeeded")
+	}
+}
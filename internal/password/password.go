@@ -0,0 +1,42 @@
+// Package password hash และตรวจรหัสผ่านผ่าน Hasher ที่เปลี่ยน algorithm ได้ (argon2id หรือ bcrypt)
+//
+// hash ที่เก็บเป็น string ที่บอก algorithm และ parameter ในตัว:
+// argon2id ใช้ PHC string format ($argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>)
+// bcrypt ใช้รูปแบบมาตรฐานของมันเอง ($2a$10$...) ซึ่งเป็นรูปแบบเดียวกับที่บัญชีเก่าเก็บไว้
+// Verify จึงตรวจ hash ของ algorithm ใดก็ได้ ส่วน NeedsRehash บอกว่าควร hash ใหม่ด้วยค่าปัจจุบันหรือไม่
+package password
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrUnknownAlgorithm คือ hash ที่ไม่ได้ขึ้นต้นด้วย prefix ของ algorithm ที่รองรับ
+	ErrUnknownAlgorithm = errors.New("password: unknown hash algorithm")
+	// ErrMalformedHash คือ hash ที่ขึ้นต้นถูกแต่ parse ไม่ได้
+	ErrMalformedHash = errors.New("password: malformed hash")
+)
+
+// Hasher สร้าง hash ด้วย algorithm และ parameter ของตัวเอง
+type Hasher interface {
+	// Hash คืน encoded hash ของรหัสผ่าน (มี salt สุ่มใหม่ทุกครั้ง)
+	Hash(password string) (string, error)
+	// Verify ตรวจรหัสผ่านกับ encoded hash ของ algorithm ใดก็ได้ที่รองรับ
+	// รหัสไม่ตรงคืน false, nil ส่วน error หมายถึง hash ใช้ไม่ได้
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash บอกว่า encoded ไม่ได้สร้างด้วย algorithm หรือ parameter ปัจจุบัน
+	NeedsRehash(encoded string) bool
+}
+
+// Verify ตรวจรหัสผ่านกับ encoded hash โดยดู algorithm จาก prefix
+func Verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return verifyArgon2id(password, encoded)
+	case isBcrypt(encoded):
+		return verifyBcrypt(password, encoded)
+	default:
+		return false, ErrUnknownAlgorithm
+	}
+}
@@ -0,0 +1,138 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fastArgon2id ใช้ parameter ต่ำเพื่อให้ test เร็ว
+func fastArgon2id() *Argon2id {
+	return &Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestArgon2idPHCFormat(t *testing.T) {
+	a := fastArgon2id()
+	encoded, err := a.Hash("s3cret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("hash %q is not a PHC argon2id string with the configured parameters", encoded)
+	}
+	p, err := parseArgon2id(encoded)
+	if err != nil {
+		t.Fatalf("parseArgon2id: %v", err)
+	}
+	if p.memory != 1024 || p.iterations != 1 || p.parallelism != 1 || len(p.salt) != 16 || len(p.key) != 32 {
+		t.Fatalf("parsed params = %+v", p)
+	}
+	if again, _ := a.Hash("s3cret"); again == encoded {
+		t.Fatal("two hashes of the same password share a salt")
+	}
+}
+
+func TestParseArgon2idRejectsMalformed(t *testing.T) {
+	valid, _ := fastArgon2id().Hash("s3cret")
+	parts := strings.Split(valid, "$")
+	with := func(i int, v string) string {
+		p := append([]string(nil), parts...)
+		p[i] = v
+		return strings.Join(p, "$")
+	}
+	for name, encoded := range map[string]string{
+		"too few fields":   "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"too many fields":  valid + "$extra",
+		"wrong algorithm":  with(1, "argon2i"),
+		"wrong version":    with(2, "v=16"),
+		"missing version":  with(2, "19"),
+		"bad params":       with(3, "m=1024,t=1"),
+		"zero iterations":  with(3, "m=1024,t=0,p=1"),
+		"zero parallelism": with(3, "m=1024,t=1,p=0"),
+		"salt not base64":  with(4, "!!!"),
+		"key not base64":   with(5, "!!!"),
+		"empty key":        with(5, ""),
+	} {
+		if _, err := parseArgon2id(encoded); !errors.Is(err, ErrMalformedHash) {
+			t.Errorf("%s: parseArgon2id(%q) = %v, want ErrMalformedHash", name, encoded, err)
+		}
+		if ok, err := Verify("s3cret", encoded); ok || err == nil {
+			t.Errorf("%s: Verify = %v, %v; want an error", name, ok, err)
+		}
+	}
+}
+
+func TestVerifyAcceptsEitherAlgorithm(t *testing.T) {
+	for _, h := range []Hasher{fastArgon2id(), NewBcrypt(4)} {
+		encoded, err := h.Hash("s3cret")
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		// hasher ตัวใดก็ตรวจ hash ของอีกตัวได้
+		for _, verifier := range []Hasher{fastArgon2id(), NewBcrypt(4)} {
+			if ok, err := verifier.Verify("s3cret", encoded); !ok || err != nil {
+				t.Errorf("Verify(correct, %q) = %v, %v", encoded, ok, err)
+			}
+			if ok, err := verifier.Verify("wrong", encoded); ok || err != nil {
+				t.Errorf("Verify(wrong, %q) = %v, %v; want false, nil", encoded, ok, err)
+			}
+		}
+	}
+	if _, err := Verify("s3cret", "plaintext"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Fatalf("Verify(unknown format) = %v, want ErrUnknownAlgorithm", err)
+	}
+	if _, err := Verify("s3cret", "$2a$04$short"); !errors.Is(err, ErrMalformedHash) {
+		t.Fatalf("Verify(truncated bcrypt) = %v, want ErrMalformedHash", err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current := fastArgon2id()
+	argonHash, _ := current.Hash("s3cret")
+	bcryptHash, _ := NewBcrypt(4).Hash("s3cret")
+
+	stronger := fastArgon2id()
+	stronger.Iterations = 2
+	longerKey := fastArgon2id()
+	longerKey.KeyLength = 64
+
+	for _, tc := range []struct {
+		name    string
+		hasher  Hasher
+		encoded string
+		want    bool
+	}{
+		{"argon2id, same parameters", current, argonHash, false},
+		{"argon2id, more iterations", stronger, argonHash, true},
+		{"argon2id, longer key", longerKey, argonHash, true},
+		{"bcrypt hash, argon2id hasher", current, bcryptHash, true},
+		{"malformed hash", current, "$argon2id$garbage", true},
+		{"bcrypt, same cost", NewBcrypt(4), bcryptHash, false},
+		{"bcrypt, higher cost", NewBcrypt(5), bcryptHash, true},
+		{"argon2id hash, bcrypt hasher", NewBcrypt(4), argonHash, true},
+	} {
+		if got := tc.hasher.NeedsRehash(tc.encoded); got != tc.want {
+			t.Errorf("%s: NeedsRehash = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// เส้นทาง upgrade: hash ใหม่ด้วยค่าปัจจุบันแล้วไม่ต้อง rehash อีก และรหัสผ่านเดิมยังตรวจผ่าน
+	upgraded, err := stronger.Hash("s3cret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if stronger.NeedsRehash(upgraded) {
+		t.Fatal("a freshly upgraded hash still needs a rehash")
+	}
+	if ok, _ := stronger.Verify("s3cret", upgraded); !ok {
+		t.Fatal("upgraded hash does not verify")
+	}
+}
+
+func TestNewBcryptClampsCost(t *testing.T) {
+	for _, cost := range []int{0, 3, 32} {
+		if b := NewBcrypt(cost); b.Cost != 10 {
+			t.Errorf("NewBcrypt(%d).Cost = %d, want the default 10", cost, b.Cost)
+		}
+	}
+}
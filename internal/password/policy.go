@@ -0,0 +1,121 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// rule ที่ Policy ตรวจ ใช้เป็น reason ของ violation
+const (
+	RuleTooShort         = "TOO_SHORT"
+	RuleTooLong          = "TOO_LONG"
+	RuleMissingLowercase = "MISSING_LOWERCASE"
+	RuleMissingUppercase = "MISSING_UPPERCASE"
+	RuleMissingDigit     = "MISSING_DIGIT"
+	RuleMissingSymbol    = "MISSING_SYMBOL"
+	RuleContainsEmail    = "CONTAINS_EMAIL"
+	RuleBreached         = "BREACHED"
+)
+
+// Violation คือ rule หนึ่งข้อที่รหัสผ่านไม่ผ่าน
+type Violation struct {
+	Rule        string
+	Description string
+}
+
+// Policy กำหนดเงื่อนไขของรหัสผ่านใหม่ (ตอนสมัครหรือ reset) ไม่ใช้ตอน login
+type Policy struct {
+	MinLength int // นับเป็นตัวอักษร (rune)
+	MaxBytes  int // bcrypt ใช้แค่ 72 byte แรก รหัสที่ยาวกว่านั้นจึงถูกปฏิเสธ; 0 = ไม่จำกัด
+
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// DisallowEmail ห้ามรหัสผ่านที่มีอีเมลหรือชื่อหน้า @ ของผู้ใช้อยู่ (ไม่สนตัวพิมพ์เล็กใหญ่)
+	DisallowEmail bool
+
+	// Breached ตรวจกับชุดรหัสผ่านที่เคยรั่ว nil = ไม่ตรวจ
+	Breached BreachChecker
+}
+
+// DefaultPolicy คืน policy เริ่มต้น: อย่างน้อย 8 ตัว, ไม่เกิน 72 byte, ห้ามใช้อีเมล
+// ไม่บังคับชนิดตัวอักษร (ตามแนวทาง NIST SP 800-63B ที่เน้นความยาวและรายการรหัสที่รั่ว)
+func DefaultPolicy() *Policy {
+	return &Policy{MinLength: 8, MaxBytes: 72, DisallowEmail: true}
+}
+
+// Validate คืนทุก rule ที่ password ไม่ผ่าน (ว่าง = ผ่าน)
+// email ว่างจะข้าม rule CONTAINS_EMAIL; error หมายถึงตรวจ Breached ไม่สำเร็จ
+func (p *Policy) Validate(ctx context.Context, password, email string) ([]Violation, error) {
+	var vs []Violation
+	add := func(rule, format string, args ...interface{}) {
+		vs = append(vs, Violation{Rule: rule, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		add(RuleTooShort, "must be at least %d characters", p.MinLength)
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		add(RuleTooLong, "must be at most %d bytes", p.MaxBytes)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireLowercase && !lower {
+		add(RuleMissingLowercase, "must contain a lowercase letter")
+	}
+	if p.RequireUppercase && !upper {
+		add(RuleMissingUppercase, "must contain an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		add(RuleMissingDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		add(RuleMissingSymbol, "must contain a symbol")
+	}
+
+	if p.DisallowEmail && containsEmail(password, email) {
+		add(RuleContainsEmail, "must not contain your email address")
+	}
+
+	if p.Breached != nil && password != "" {
+		breached, err := p.Breached.Breached(ctx, password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			add(RuleBreached, "has appeared in a known data breach")
+		}
+	}
+	return vs, nil
+}
+
+// containsEmail เช็คว่า password มีอีเมลหรือชื่อหน้า @ (ถ้ายาวพอจะมีความหมาย) อยู่หรือไม่
+func containsEmail(password, email string) bool {
+	if email == "" {
+		return false
+	}
+	pw := strings.ToLower(password)
+	email = strings.ToLower(email)
+	if strings.Contains(pw, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= 3 && strings.Contains(pw, local)
+}
@@ -0,0 +1,102 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// staticChecker คืนผลตายตัวและจำรหัสที่ถูกถาม
+type staticChecker struct {
+	breached bool
+	err      error
+	asked    []string
+}
+
+func (c *staticChecker) Breached(ctx context.Context, password string) (bool, error) {
+	c.asked = append(c.asked, password)
+	return c.breached, c.err
+}
+
+func TestPolicyValidate(t *testing.T) {
+	strict := &Policy{MinLength: 8, RequireLowercase: true, RequireUppercase: true, RequireDigit: true, RequireSymbol: true}
+
+	for _, tc := range []struct {
+		name     string
+		policy   *Policy
+		password string
+		email    string
+		want     []string
+	}{
+		{"default, ok", DefaultPolicy(), "correct-horse-battery", "alice@example.com", nil},
+		{"default, too short", DefaultPolicy(), "abc1234", "", []string{RuleTooShort}},
+		{"default, exactly min length", DefaultPolicy(), "abcd1234", "", nil},
+		{"length counts runes, not bytes", DefaultPolicy(), "ñññññññ", "", []string{RuleTooShort}},
+		{"eight multibyte runes", DefaultPolicy(), "ññññññññ", "", nil},
+		{"exactly max bytes", DefaultPolicy(), strings.Repeat("a", 72), "", nil},
+		{"over max bytes", DefaultPolicy(), strings.Repeat("a", 73), "", []string{RuleTooLong}},
+		{"max bytes counts bytes", DefaultPolicy(), strings.Repeat("ñ", 37), "", []string{RuleTooLong}},
+		{"no max bytes", &Policy{MinLength: 8}, strings.Repeat("a", 200), "", nil},
+		{"empty password", DefaultPolicy(), "", "alice@example.com", []string{RuleTooShort}},
+		{"contains email", DefaultPolicy(), "xxALICE@Example.comxx", "alice@example.com", []string{RuleContainsEmail}},
+		{"contains local part", DefaultPolicy(), "my-alice-password", "alice@example.com", []string{RuleContainsEmail}},
+		{"short local part ignored", DefaultPolicy(), "al-is-my-password", "al@example.com", nil},
+		{"no email given", DefaultPolicy(), "my-alice-password", "", nil},
+		{"email allowed", &Policy{MinLength: 8}, "my-alice-password", "alice@example.com", nil},
+		{"strict, all classes", strict, "Abcdefg1!", "", nil},
+		{"strict, space is a symbol", strict, "Abcdef 1", "", nil},
+		{"strict, lowercase only", strict, "abcdefgh", "", []string{RuleMissingUppercase, RuleMissingDigit, RuleMissingSymbol}},
+		{"strict, uppercase only", strict, "ABCDEFGH", "", []string{RuleMissingLowercase, RuleMissingDigit, RuleMissingSymbol}},
+		{"strict, every rule", strict, "", "", []string{RuleTooShort, RuleMissingLowercase, RuleMissingUppercase, RuleMissingDigit, RuleMissingSymbol}},
+		{"non-ASCII letters count", strict, "Ñandú-2024", "", nil},
+	} {
+		vs, err := tc.policy.Validate(context.Background(), tc.password, tc.email)
+		if err != nil {
+			t.Errorf("%s: Validate: %v", tc.name, err)
+			continue
+		}
+		var got []string
+		for _, v := range vs {
+			if v.Description == "" {
+				t.Errorf("%s: rule %s has no description", tc.name, v.Rule)
+			}
+			got = append(got, v.Rule)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Validate(%q) rules = %v, want %v", tc.name, tc.password, got, tc.want)
+		}
+	}
+}
+
+func TestPolicyValidateBreached(t *testing.T) {
+	ctx := context.Background()
+
+	c := &staticChecker{breached: true}
+	p := &Policy{MinLength: 8, Breached: c}
+	vs, err := p.Validate(ctx, "short", "")
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(vs) != 2 || vs[0].Rule != RuleTooShort || vs[1].Rule != RuleBreached {
+		t.Fatalf("violations = %+v, want TOO_SHORT and BREACHED", vs)
+	}
+
+	// รหัสว่างไม่ต้องถาม checker
+	c.asked = nil
+	if _, err := p.Validate(ctx, "", ""); err != nil || len(c.asked) != 0 {
+		t.Fatalf("empty password: err = %v, checker asked %v", err, c.asked)
+	}
+
+	c.breached = false
+	if vs, _ := p.Validate(ctx, "long-enough-password", ""); len(vs) != 0 {
+		t.Fatalf("violations = %+v, want none", vs)
+	}
+
+	failure := errors.New("breach list unavailable")
+	p.Breached = &staticChecker{err: failure}
+	if vs, err := p.Validate(ctx, "long-enough-password", ""); !errors.Is(err, failure) || vs != nil {
+		t.Fatalf("Validate = %v, %v; want the checker's error", vs, err)
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter เก็บสถานะในหน่วยความจำ ใช้ได้กับ instance เดียวและหายเมื่อ restart
+type MemoryLimiter struct {
+	mu     sync.Mutex
+	states map[string]State
+	sweep  time.Time
+}
+
+// NewMemoryLimiter สร้าง MemoryLimiter เปล่า
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{states: make(map[string]State)}
+}
+
+func (m *MemoryLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.states[key].wait(time.Now()), nil
+}
+
+func (m *MemoryLimiter) Fail(ctx context.Context, key string, p Policy) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.sweepExpired(now)
+	st := p.fail(m.states[key], now)
+	m.states[key] = st
+	return st.wait(now), nil
+}
+
+func (m *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, key)
+	return nil
+}
+
+// sweepExpired ลบสถานะที่หมดอายุ อย่างมากนาทีละครั้ง เพื่อไม่ให้ map โตไม่หยุด
+func (m *MemoryLimiter) sweepExpired(now time.Time) {
+	if now.Before(m.sweep) {
+		return
+	}
+	m.sweep = now.Add(time.Minute)
+	for k, st := range m.states {
+		if !now.Before(st.ExpiresAt) {
+			delete(m.states, k)
+		}
+	}
+}
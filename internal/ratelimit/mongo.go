@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLimiter เก็บสถานะใน collection เดียว (document ละ key) ใช้ร่วมกันได้ทุก replica
+type MongoLimiter struct {
+	col *mongo.Collection
+}
+
+type mongoState struct {
+	Key     string `bson:"_id"`
+	Version int64  `bson:"version"`
+	State   `bson:",inline"`
+}
+
+// NewMongoLimiter สร้าง instance และตั้ง TTL index บน expiresAt
+func NewMongoLimiter(col *mongo.Collection) *MongoLimiter {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return &MongoLimiter{col: col}
+}
+
+func (m *MongoLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	doc, err := m.load(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return doc.wait(time.Now()), nil
+}
+
+// Fail อ่านสถานะ คำนวณใหม่ แล้วเขียนกลับแบบ compare-and-swap บน version
+func (m *MongoLimiter) Fail(ctx context.Context, key string, p Policy) (time.Duration, error) {
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			if err := backoff(ctx, i); err != nil {
+				return 0, err
+			}
+		}
+		doc, err := m.load(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		now := time.Now()
+		next := mongoState{Key: key, Version: doc.Version + 1, State: p.fail(doc.State, now)}
+
+		if doc.Version == 0 {
+			_, err = m.col.InsertOne(ctx, next)
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			return next.wait(now), nil
+		}
+		res, err := m.col.ReplaceOne(ctx, bson.M{"_id": key, "version": doc.Version}, next)
+		if err != nil {
+			return 0, err
+		}
+		if res.MatchedCount == 1 {
+			return next.wait(now), nil
+		}
+	}
+	return p.contended(), nil
+}
+
+func (m *MongoLimiter) Reset(ctx context.Context, key string) error {
+	_, err := m.col.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// load คืนสถานะของ key หรือสถานะว่าง (version 0) ถ้ายังไม่มี
+func (m *MongoLimiter) load(ctx context.Context, key string) (mongoState, error) {
+	var doc mongoState
+	err := m.col.FindOne(ctx, bson.M{"_id": key}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return mongoState{Key: key}, nil
+	}
+	return doc, err
+}
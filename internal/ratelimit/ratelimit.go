@@ -0,0 +1,165 @@
+// Package ratelimit นับความล้มเหลว (เช่น login ผิด) ต่อ key และล็อก key ที่ผิดเกิน threshold
+// ผ่าน RateLimiter ที่เปลี่ยน backend ได้ (in-memory สำหรับ instance เดียว,
+// Mongo หรือ Redis สำหรับหลาย replica และให้สถานะรอด restart)
+//
+// ทุก backend ใช้ Policy.fail คำนวณสถานะชุดเดียวกัน ต่างกันแค่วิธีเก็บและ update แบบ atomic
+package ratelimit
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// maxRetries คือจำนวนครั้งที่ลอง update ใหม่เมื่อมี request อื่นแก้ key เดียวกันพร้อมกัน
+const maxRetries = 10
+
+// retryBase และ maxBackoff กำหนดเวลารอระหว่างการลองใหม่ (ทวีคูณจาก retryBase จนถึง maxBackoff)
+const (
+	retryBase  = 2 * time.Millisecond
+	maxBackoff = 100 * time.Millisecond
+)
+
+// RateLimiter เก็บสถานะความล้มเหลวของแต่ละ key (เช่น "login:alice@example.com", "ip:203.0.113.7")
+type RateLimiter interface {
+	// Check คืนเวลาที่ต้องรอถ้า key ถูกล็อกอยู่ หรือ 0 ถ้ายังลองได้
+	Check(ctx context.Context, key string) (time.Duration, error)
+	// Fail บันทึกความล้มเหลวหนึ่งครั้งตาม policy
+	// คืนเวลาที่ถูกล็อกถ้าครั้งนี้ทำให้ครบ threshold หรือ 0
+	// ถ้า request อื่นแก้ key เดียวกันจน update ไม่สำเร็จ คืนเวลารอเท่า Lockout แทน error
+	Fail(ctx context.Context, key string, p Policy) (time.Duration, error)
+	// Reset ล้างสถานะของ key ทั้งหมด รวมถึงจำนวนครั้งที่เคยถูกล็อก
+	Reset(ctx context.Context, key string) error
+}
+
+// EmailKey คืน key ของบัญชีตามอีเมล เช่น EmailKey("login", " Alice@Example.com") = "login:alice@example.com"
+// อีเมลถูกตัดช่องว่างและแปลงเป็นตัวพิมพ์เล็ก เพื่อไม่ให้เลี่ยงการล็อกด้วยการเปลี่ยนตัวพิมพ์
+func EmailKey(prefix, email string) string {
+	return prefix + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// Policy กำหนด threshold และการล็อกแบบทวีคูณ:
+// ผิดครบ MaxAttempts ครั้งภายใน Window ถูกล็อก Lockout, ครั้งต่อไปล็อกนานขึ้นเท่าตัว
+// จนถึง MaxLockout ถ้าเงียบไป ResetAfter หลังพ้นล็อก ระดับการล็อกกลับไปเริ่มใหม่
+type Policy struct {
+	MaxAttempts int
+	Window      time.Duration
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+	ResetAfter  time.Duration
+}
+
+// DefaultPolicy ตรงกับกติกาเดิมของ Login: ผิด 5 ครั้งใน 1 นาทีรอ 1 นาที
+var DefaultPolicy = Policy{
+	MaxAttempts: 5,
+	Window:      time.Minute,
+	Lockout:     time.Minute,
+	MaxLockout:  time.Hour,
+	ResetAfter:  24 * time.Hour,
+}
+
+// State คือสถานะของ key หนึ่งตัวตามที่ backend เก็บไว้
+type State struct {
+	Count       int       `bson:"count" json:"count"`             // จำนวนครั้งที่ผิดใน window ปัจจุบัน
+	WindowStart time.Time `bson:"windowStart" json:"windowStart"` // เริ่ม window ปัจจุบัน
+	LockedUntil time.Time `bson:"lockedUntil" json:"lockedUntil"`
+	Lockouts    int       `bson:"lockouts" json:"lockouts"`   // จำนวนครั้งที่ถูกล็อกติดกัน
+	ExpiresAt   time.Time `bson:"expiresAt" json:"expiresAt"` // หลังเวลานี้ทิ้งสถานะได้ทั้งหมด
+}
+
+// wait คืนเวลาที่เหลือของการล็อก ณ now
+func (st State) wait(now time.Time) time.Duration {
+	if !st.ExpiresAt.IsZero() && !now.Before(st.ExpiresAt) {
+		return 0
+	}
+	if d := st.LockedUntil.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// fail คำนวณสถานะใหม่หลังผิดหนึ่งครั้ง ณ now
+func (p Policy) fail(st State, now time.Time) State {
+	p = p.withDefaults()
+	if !st.ExpiresAt.IsZero() && !now.Before(st.ExpiresAt) {
+		st = State{}
+	}
+	if st.wait(now) > 0 {
+		// ยังล็อกอยู่ (ผู้เรียกไม่ได้ Check ก่อน) ไม่นับเพิ่ม
+		return st
+	}
+	if st.WindowStart.IsZero() || !now.Before(st.WindowStart.Add(p.Window)) {
+		st.Count = 0
+		st.WindowStart = now
+	}
+	st.Count++
+	if st.Count >= p.MaxAttempts {
+		st.Lockouts++
+		st.LockedUntil = now.Add(p.lockoutFor(st.Lockouts))
+		st.Count = 0
+		st.WindowStart = time.Time{}
+	}
+	end := st.WindowStart.Add(p.Window)
+	if st.LockedUntil.After(end) {
+		end = st.LockedUntil
+	}
+	st.ExpiresAt = end.Add(p.ResetAfter)
+	return st
+}
+
+// lockoutFor คืนระยะล็อกของการล็อกครั้งที่ n (เริ่มที่ 1)
+func (p Policy) lockoutFor(n int) time.Duration {
+	d := p.Lockout
+	for i := 1; i < n && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > p.MaxLockout {
+		d = p.MaxLockout
+	}
+	return d
+}
+
+// withDefaults เติมค่าที่ไม่ได้ตั้งด้วยค่าจาก DefaultPolicy
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultPolicy.MaxAttempts
+	}
+	if p.Window <= 0 {
+		p.Window = DefaultPolicy.Window
+	}
+	if p.Lockout <= 0 {
+		p.Lockout = DefaultPolicy.Lockout
+	}
+	if p.MaxLockout < p.Lockout {
+		p.MaxLockout = p.Lockout
+	}
+	if p.ResetAfter <= 0 {
+		p.ResetAfter = DefaultPolicy.ResetAfter
+	}
+	return p
+}
+
+// contended คือเวลารอที่ Fail คืนเมื่อชนกับ request อื่นจนครบ maxRetries
+// key ที่ล้มพร้อมกันถี่ขนาดนั้นกำลังถูกเดารหัส จึงให้ผู้เรียกรอเหมือนถูกล็อกแทนการคืน error (ซึ่งจะกลายเป็น Internal)
+func (p Policy) contended() time.Duration {
+	return p.withDefaults().Lockout
+}
+
+// backoff รอก่อนลองครั้งที่ attempt (เริ่มที่ 1) แบบทวีคูณพร้อม jitter
+// เพื่อไม่ให้ request ที่ชนกันกลับมาชนกันอีกในจังหวะเดิม
+func backoff(ctx context.Context, attempt int) error {
+	d := retryBase << (attempt - 1)
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	d = d/2 + rand.N(d/2+1)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
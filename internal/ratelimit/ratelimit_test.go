@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	MaxAttempts: 3,
+	Window:      time.Minute,
+	Lockout:     time.Minute,
+	MaxLockout:  5 * time.Minute,
+	ResetAfter:  time.Hour,
+}
+
+// failTimes เรียก fail n ครั้งที่เวลา now แล้วคืนสถานะสุดท้าย
+func failTimes(p Policy, st State, now time.Time, n int) State {
+	for i := 0; i < n; i++ {
+		st = p.fail(st, now)
+	}
+	return st
+}
+
+func TestPolicyLockoutEscalates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var st State
+	// ล็อกครั้งที่ 1..5: 1m, 2m, 4m แล้วตันที่ MaxLockout 5m
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		st = failTimes(testPolicy, st, now, testPolicy.MaxAttempts-1)
+		if d := st.wait(now); d != 0 {
+			t.Fatalf("lockout %d: locked after %d failures (%v)", i+1, testPolicy.MaxAttempts-1, d)
+		}
+		st = testPolicy.fail(st, now)
+		if d := st.wait(now); d != want {
+			t.Fatalf("lockout %d lasts %v, want %v", i+1, d, want)
+		}
+		if st.Lockouts != i+1 {
+			t.Fatalf("lockout %d: Lockouts = %d", i+1, st.Lockouts)
+		}
+		// ระหว่างล็อก ความล้มเหลวไม่ถูกนับเพิ่มและไม่ยืดเวลาล็อก
+		if again := testPolicy.fail(st, now.Add(time.Second)); again != st {
+			t.Fatalf("lockout %d: fail while locked changed state %+v -> %+v", i+1, st, again)
+		}
+		now = now.Add(want)
+	}
+}
+
+func TestPolicyEscalationResetsAfterQuietPeriod(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := failTimes(testPolicy, State{}, now, testPolicy.MaxAttempts)
+	now = now.Add(testPolicy.Lockout)
+	st = failTimes(testPolicy, st, now, testPolicy.MaxAttempts)
+	if st.Lockouts != 2 {
+		t.Fatalf("Lockouts = %d, want 2", st.Lockouts)
+	}
+
+	// เงียบไปครบ ResetAfter หลังพ้นล็อก: กลับไปเริ่มที่ Lockout
+	now = st.LockedUntil.Add(testPolicy.ResetAfter)
+	st = failTimes(testPolicy, st, now, testPolicy.MaxAttempts)
+	if st.Lockouts != 1 || st.wait(now) != testPolicy.Lockout {
+		t.Fatalf("after a quiet period: Lockouts = %d, wait = %v; want 1 and %v", st.Lockouts, st.wait(now), testPolicy.Lockout)
+	}
+}
+
+func TestPolicyWindowExpires(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := failTimes(testPolicy, State{}, now, testPolicy.MaxAttempts-1)
+	// ความล้มเหลวที่เกิน window ไปแล้วเริ่มนับใหม่
+	now = now.Add(testPolicy.Window)
+	st = failTimes(testPolicy, st, now, testPolicy.MaxAttempts-1)
+	if d := st.wait(now); d != 0 || st.Count != testPolicy.MaxAttempts-1 {
+		t.Fatalf("count = %d, wait = %v; want %d failures in the new window and no lockout", st.Count, d, testPolicy.MaxAttempts-1)
+	}
+}
+
+func TestPolicyDefaults(t *testing.T) {
+	now := time.Now()
+	st := failTimes(Policy{}, State{}, now, DefaultPolicy.MaxAttempts)
+	if d := st.wait(now); d != DefaultPolicy.Lockout {
+		t.Fatalf("zero Policy locks for %v, want DefaultPolicy.Lockout %v", d, DefaultPolicy.Lockout)
+	}
+}
+
+func TestMemoryLimiterEscalates(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryLimiter()
+	p := Policy{MaxAttempts: 2, Window: time.Minute, Lockout: 20 * time.Millisecond, MaxLockout: time.Minute, ResetAfter: time.Hour}
+
+	var last time.Duration
+	for i := 0; i < 3; i++ {
+		if d, _ := m.Fail(ctx, "k", p); d != 0 {
+			t.Fatalf("round %d: locked after one failure", i)
+		}
+		d, err := m.Fail(ctx, "k", p)
+		if err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+		if d <= last {
+			t.Fatalf("round %d: lockout %v did not grow from %v", i, d, last)
+		}
+		if w, _ := m.Check(ctx, "k"); w <= 0 {
+			t.Fatalf("round %d: Check = %v while locked", i, w)
+		}
+		if w, _ := m.Check(ctx, "other"); w != 0 {
+			t.Fatalf("unrelated key locked: %v", w)
+		}
+		last = d
+		time.Sleep(d)
+	}
+
+	if err := m.Reset(ctx, "k"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	m.Fail(ctx, "k", p)
+	if d, _ := m.Fail(ctx, "k", p); d != p.Lockout {
+		t.Fatalf("lockout after Reset = %v, want %v", d, p.Lockout)
+	}
+}
+
+func TestEmailKey(t *testing.T) {
+	for _, email := range []string{"alice@example.com", "Alice@Example.COM", "  alice@example.com\t"} {
+		if got := EmailKey("login", email); got != "login:alice@example.com" {
+			t.Errorf("EmailKey(%q) = %q", email, got)
+		}
+	}
+}
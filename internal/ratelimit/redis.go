@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLimiter เก็บสถานะเป็น JSON ใน key ของ Redis (หรือ server ที่พูด Redis protocol เช่น Valkey, KeyDB)
+// ใช้ร่วมกันได้ทุก replica และ Redis ลบ key ที่หมดอายุให้เอง
+type RedisLimiter struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisLimiter สร้าง RedisLimiter ทุก key จะขึ้นต้นด้วย prefix (เช่น "auth:ratelimit:")
+func NewRedisLimiter(client redis.UniversalClient, prefix string) *RedisLimiter {
+	return &RedisLimiter{client: client, prefix: prefix}
+}
+
+func (r *RedisLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	st, err := r.load(ctx, r.client, r.prefix+key)
+	if err != nil {
+		return 0, err
+	}
+	return st.wait(time.Now()), nil
+}
+
+// Fail ใช้ WATCH/MULTI: ถ้ามี request อื่นแก้ key ระหว่างนั้น transaction จะล้มและลองใหม่หลัง backoff
+func (r *RedisLimiter) Fail(ctx context.Context, key string, p Policy) (time.Duration, error) {
+	k := r.prefix + key
+	var wait time.Duration
+	txf := func(tx *redis.Tx) error {
+		st, err := r.load(ctx, tx, k)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		st = p.fail(st, now)
+		data, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, k, data, st.ExpiresAt.Sub(now))
+			return nil
+		})
+		wait = st.wait(now)
+		return err
+	}
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			if err := backoff(ctx, i); err != nil {
+				return 0, err
+			}
+		}
+		err := r.client.Watch(ctx, txf, k)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return wait, err
+	}
+	return p.contended(), nil
+}
+
+func (r *RedisLimiter) Reset(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key).Err()
+}
+
+func (r *RedisLimiter) load(ctx context.Context, c redis.Cmdable, key string) (State, error) {
+	var st State
+	data, err := c.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
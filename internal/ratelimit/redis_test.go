@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedisLimiter เปิด RedisLimiter บน miniredis ที่ปิดเองตอนจบ test
+func newRedisLimiter(t *testing.T) (*RedisLimiter, *miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisLimiter(client, "test:"), mr, client
+}
+
+func TestRedisLimiterConcurrentFail(t *testing.T) {
+	ctx := context.Background()
+	r, _, _ := newRedisLimiter(t)
+	const n = 20
+	p := Policy{MaxAttempts: 1000, Window: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour, ResetAfter: time.Hour}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		contended int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, err := r.Fail(ctx, "k", p)
+			if err != nil {
+				t.Errorf("Fail: %v", err)
+				return
+			}
+			if d > 0 {
+				mu.Lock()
+				contended++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// ทุกครั้งถูกนับ ยกเว้นครั้งที่ชนจนครบ maxRetries (ซึ่งได้เวลารอแทน error)
+	st, err := r.load(ctx, r.client, "test:k")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if st.Count+contended != n {
+		t.Fatalf("count %d + contended %d, want %d failures in total", st.Count, contended, n)
+	}
+}
+
+// touchBeforeExec แก้ key ที่ WATCH ไว้ก่อน EXEC ทุกครั้ง จำลอง request อื่นที่ชนตลอด
+type touchBeforeExec struct {
+	mr  *miniredis.Miniredis
+	key string
+}
+
+func (h touchBeforeExec) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h touchBeforeExec) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+func (h touchBeforeExec) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.mr.Set(h.key, "{}")
+		return next(ctx, cmds)
+	}
+}
+
+func TestRedisLimiterContentionIsRateLimited(t *testing.T) {
+	r, mr, client := newRedisLimiter(t)
+	client.AddHook(touchBeforeExec{mr: mr, key: "test:k"})
+	p := Policy{MaxAttempts: 5, Window: time.Minute, Lockout: 2 * time.Minute, MaxLockout: time.Hour, ResetAfter: time.Hour}
+
+	d, err := r.Fail(context.Background(), "k", p)
+	if err != nil || d != p.Lockout {
+		t.Fatalf("Fail under constant contention = %v, %v; want %v, nil", d, err, p.Lockout)
+	}
+
+	// ctx ที่ถูกยกเลิกระหว่าง backoff หยุดรอทันที
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := r.Fail(ctx, "k", p); err == nil {
+		t.Fatal("Fail with an expired context succeeded")
+	}
+}
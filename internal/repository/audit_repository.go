@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository เก็บ audit event แบบเขียนต่อท้ายอย่างเดียว (ไม่มี update หรือ delete)
+type AuditRepository interface {
+	// Append ตั้ง ID และ CreatedAt ให้ event แล้วบันทึก
+	Append(ctx context.Context, e *domain.AuditEvent) error
+	// List คืน event ที่ตรง filter เรียงจากใหม่ไปเก่า พร้อมจำนวนทั้งหมด (size <= 0 = ไม่จำกัด)
+	List(ctx context.Context, f domain.AuditFilter, page, size int) ([]*domain.AuditEvent, int64, error)
+}
+
+type mongoAuditRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoAuditRepository สร้าง instance พร้อม index สำหรับเรียงตามเวลาและกรองตามผู้ใช้
+func NewMongoAuditRepository(col *mongo.Collection) AuditRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "actorID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "subjectID", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	return &mongoAuditRepo{col: col}
+}
+
+func (r *mongoAuditRepo) Append(ctx context.Context, e *domain.AuditEvent) error {
+	e.ID = uuid.NewString()
+	e.CreatedAt = time.Now()
+	_, err := r.col.InsertOne(ctx, e)
+	return err
+}
+
+func (r *mongoAuditRepo) List(ctx context.Context, f domain.AuditFilter, page, size int) ([]*domain.AuditEvent, int64, error) {
+	filter := bson.M{}
+	for field, v := range map[string]string{"type": f.Type, "outcome": f.Outcome, "actorID": f.ActorID, "subjectID": f.SubjectID} {
+		if v != "" {
+			filter[field] = v
+		}
+	}
+	created := bson.M{}
+	if !f.Since.IsZero() {
+		created["$gte"] = f.Since
+	}
+	if !f.Until.IsZero() {
+		created["$lt"] = f.Until
+	}
+	if len(created) > 0 {
+		filter["createdAt"] = created
+	}
+
+	total, err := r.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	if size > 0 {
+		opts.SetSkip(int64((page - 1) * size)).SetLimit(int64(size))
+	}
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	var events []*domain.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
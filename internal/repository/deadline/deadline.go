@@ -0,0 +1,426 @@
+// Package deadline ครอบ repository ให้ทุก operation มี timeout ของตัวเอง
+// ต่อจาก deadline ของ request (อันไหนถึงก่อนก็ยกเลิก query ก่อน)
+// ทำให้ฐานข้อมูลที่ช้าผิดปกติไม่กิน request ไปจนหมดเวลาของ client
+//
+//	users := deadline.Users(postgres.NewUserRepository(pool), cfg.DBTimeout)
+//
+// timeout <= 0 คืน repository เดิมโดยไม่ครอบ
+package deadline
+
+import (
+	"context"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+)
+
+type users struct {
+	next    repo.UserRepository
+	timeout time.Duration
+}
+
+// Users ครอบ UserRepository ด้วย timeout ต่อ operation
+func Users(r repo.UserRepository, timeout time.Duration) repo.UserRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &users{next: r, timeout: timeout}
+}
+
+func (r *users) Create(ctx context.Context, u *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, u)
+}
+
+func (r *users) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByEmail(ctx, email)
+}
+
+func (r *users) FindAll(ctx context.Context, filterName, filterEmail string, page, size int) ([]*domain.User, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindAll(ctx, filterName, filterEmail, page, size)
+}
+
+func (r *users) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *users) Update(ctx context.Context, u *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Update(ctx, u)
+}
+
+func (r *users) SoftDelete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.SoftDelete(ctx, id)
+}
+
+func (r *users) SetMFA(ctx context.Context, id, secret string, lastStep int64, recoveryCodes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.SetMFA(ctx, id, secret, lastStep, recoveryCodes)
+}
+
+func (r *users) ConsumeTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.ConsumeTOTPStep(ctx, id, step)
+}
+
+func (r *users) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.ConsumeRecoveryCode(ctx, id, codeHash)
+}
+
+type tokens struct {
+	next    repo.TokenRepository
+	timeout time.Duration
+}
+
+// Tokens ครอบ TokenRepository ด้วย timeout ต่อ operation
+func Tokens(r repo.TokenRepository, timeout time.Duration) repo.TokenRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &tokens{next: r, timeout: timeout}
+}
+
+func (r *tokens) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Blacklist(ctx, jti, expiresAt)
+}
+
+func (r *tokens) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.IsBlacklisted(ctx, jti)
+}
+
+func (r *tokens) Claim(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Claim(ctx, jti, expiresAt)
+}
+
+func (r *tokens) RevokeIssuedBefore(ctx context.Context, subject string, before, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.RevokeIssuedBefore(ctx, subject, before, expiresAt)
+}
+
+func (r *tokens) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.RevokedBefore(ctx, subject)
+}
+
+type passwordResets struct {
+	next    pr.PasswordResetRepository
+	timeout time.Duration
+}
+
+// PasswordResets ครอบ PasswordResetRepository ด้วย timeout ต่อ operation
+func PasswordResets(r pr.PasswordResetRepository, timeout time.Duration) pr.PasswordResetRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &passwordResets{next: r, timeout: timeout}
+}
+
+func (r *passwordResets) Create(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, token, userID, expiresAt)
+}
+
+func (r *passwordResets) Consume(ctx context.Context, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Consume(ctx, token)
+}
+
+func (r *passwordResets) DeleteByUser(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.DeleteByUser(ctx, userID)
+}
+
+type emailVerifications struct {
+	next    ev.EmailVerificationRepository
+	timeout time.Duration
+}
+
+// EmailVerifications ครอบ EmailVerificationRepository ด้วย timeout ต่อ operation
+func EmailVerifications(r ev.EmailVerificationRepository, timeout time.Duration) ev.EmailVerificationRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &emailVerifications{next: r, timeout: timeout}
+}
+
+func (r *emailVerifications) Create(ctx context.Context, token, userID, email string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, token, userID, email, expiresAt)
+}
+
+func (r *emailVerifications) Verify(ctx context.Context, token string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Verify(ctx, token)
+}
+
+func (r *emailVerifications) Delete(ctx context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Delete(ctx, token)
+}
+
+type refreshTokens struct {
+	next    repo.RefreshTokenRepository
+	timeout time.Duration
+}
+
+// RefreshTokens ครอบ RefreshTokenRepository ด้วย timeout ต่อ operation
+func RefreshTokens(r repo.RefreshTokenRepository, timeout time.Duration) repo.RefreshTokenRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &refreshTokens{next: r, timeout: timeout}
+}
+
+func (r *refreshTokens) Create(ctx context.Context, t *domain.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, t)
+}
+
+func (r *refreshTokens) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByHash(ctx, hash)
+}
+
+func (r *refreshTokens) MarkRotated(ctx context.Context, hash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.MarkRotated(ctx, hash)
+}
+
+func (r *refreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.RevokeFamily(ctx, familyID)
+}
+
+type webAuthnCredentials struct {
+	next    repo.WebAuthnCredentialRepository
+	timeout time.Duration
+}
+
+// WebAuthnCredentials ครอบ WebAuthnCredentialRepository ด้วย timeout ต่อ operation
+func WebAuthnCredentials(r repo.WebAuthnCredentialRepository, timeout time.Duration) repo.WebAuthnCredentialRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &webAuthnCredentials{next: r, timeout: timeout}
+}
+
+func (r *webAuthnCredentials) Create(ctx context.Context, c *domain.WebAuthnCredential) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, c)
+}
+
+func (r *webAuthnCredentials) FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByUserID(ctx, userID)
+}
+
+func (r *webAuthnCredentials) FindByID(ctx context.Context, id string) (*domain.WebAuthnCredential, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *webAuthnCredentials) UpdateSignCount(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.UpdateSignCount(ctx, id, signCount, backupState, usedAt)
+}
+
+type webAuthnSessions struct {
+	next    repo.WebAuthnSessionRepository
+	timeout time.Duration
+}
+
+// WebAuthnSessions ครอบ WebAuthnSessionRepository ด้วย timeout ต่อ operation
+func WebAuthnSessions(r repo.WebAuthnSessionRepository, timeout time.Duration) repo.WebAuthnSessionRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &webAuthnSessions{next: r, timeout: timeout}
+}
+
+func (r *webAuthnSessions) Create(ctx context.Context, id string, data []byte, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, id, data, expiresAt)
+}
+
+func (r *webAuthnSessions) Consume(ctx context.Context, id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Consume(ctx, id)
+}
+
+type audit struct {
+	next    repo.AuditRepository
+	timeout time.Duration
+}
+
+// Audit ครอบ AuditRepository ด้วย timeout ต่อ operation
+func Audit(r repo.AuditRepository, timeout time.Duration) repo.AuditRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &audit{next: r, timeout: timeout}
+}
+
+func (r *audit) Append(ctx context.Context, e *domain.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Append(ctx, e)
+}
+
+func (r *audit) List(ctx context.Context, f domain.AuditFilter, page, size int) ([]*domain.AuditEvent, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.List(ctx, f, page, size)
+}
+
+type oauthClients struct {
+	next    repo.OAuthClientRepository
+	timeout time.Duration
+}
+
+// OAuthClients ครอบ OAuthClientRepository ด้วย timeout ต่อ operation
+func OAuthClients(r repo.OAuthClientRepository, timeout time.Duration) repo.OAuthClientRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &oauthClients{next: r, timeout: timeout}
+}
+
+func (r *oauthClients) Create(ctx context.Context, c *domain.OAuthClient) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, c)
+}
+
+func (r *oauthClients) FindByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByID(ctx, id)
+}
+
+type authorizationCodes struct {
+	next    repo.AuthorizationCodeRepository
+	timeout time.Duration
+}
+
+// AuthorizationCodes ครอบ AuthorizationCodeRepository ด้วย timeout ต่อ operation
+func AuthorizationCodes(r repo.AuthorizationCodeRepository, timeout time.Duration) repo.AuthorizationCodeRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &authorizationCodes{next: r, timeout: timeout}
+}
+
+func (r *authorizationCodes) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, c)
+}
+
+func (r *authorizationCodes) Consume(ctx context.Context, codeHash, clientID string) (*domain.AuthorizationCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Consume(ctx, codeHash, clientID)
+}
+
+type consents struct {
+	next    repo.ConsentRepository
+	timeout time.Duration
+}
+
+// Consents ครอบ ConsentRepository ด้วย timeout ต่อ operation
+func Consents(r repo.ConsentRepository, timeout time.Duration) repo.ConsentRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &consents{next: r, timeout: timeout}
+}
+
+func (r *consents) Grant(ctx context.Context, userID, clientID string, scopes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Grant(ctx, userID, clientID, scopes)
+}
+
+func (r *consents) Find(ctx context.Context, userID, clientID string) (*domain.Consent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Find(ctx, userID, clientID)
+}
+
+type machineClients struct {
+	next    repo.MachineClientRepository
+	timeout time.Duration
+}
+
+// MachineClients ครอบ MachineClientRepository ด้วย timeout ต่อ operation
+func MachineClients(r repo.MachineClientRepository, timeout time.Duration) repo.MachineClientRepository {
+	if timeout <= 0 {
+		return r
+	}
+	return &machineClients{next: r, timeout: timeout}
+}
+
+func (r *machineClients) Create(ctx context.Context, c *domain.MachineClient) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Create(ctx, c)
+}
+
+func (r *machineClients) FindByID(ctx context.Context, id string) (*domain.MachineClient, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *machineClients) UpdateSecret(ctx context.Context, id, secretHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.UpdateSecret(ctx, id, secretHash)
+}
+
+func (r *machineClients) Disable(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.next.Disable(ctx, id)
+}
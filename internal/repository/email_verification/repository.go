@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EmailVerificationRepository จัดการสร้าง-ตรวจสอบ-ลบ token ยืนยันอีเมล
+// token ผูกกับอีเมลที่ส่งไป ถ้าผู้ใช้เปลี่ยนอีเมลภายหลัง token เดิมจะใช้ยืนยันไม่ได้
+// token ถูกเก็บเป็น SHA-256 digest เหมือน reset token
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token, userID, email string, expiresAt time.Time) error
+	Verify(ctx context.Context, token string) (userID, email string, err error)
+	Delete(ctx context.Context, token string) error
+}
+
+type mongoVerificationRepo struct {
+	col *mongo.Collection
+}
+
+func NewMongoVerificationRepo(col *mongo.Collection) EmailVerificationRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// index เดิมบน token (raw) ใช้ไม่ได้แล้ว: document ใหม่ไม่มี field นี้ จะชนกันที่ค่า null
+	// document เดิมค้นไม่เจออีก และ TTL index ลบทิ้งเมื่อหมดอายุ
+	col.Indexes().DropOne(ctx, "token_1")
+
+	//index บน tokenHash และ TTL บน expiresAt
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"tokenHash": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return &mongoVerificationRepo{col: col}
+}
+
+func (r *mongoVerificationRepo) Create(ctx context.Context, token, userID, email string, expiresAt time.Time) error {
+	_, err := r.col.InsertOne(ctx, bson.M{
+		"tokenHash": HashToken(token),
+		"userID":    userID,
+		"email":     email,
+		"expiresAt": expiresAt,
+	})
+	return err
+}
+
+func (r *mongoVerificationRepo) Verify(ctx context.Context, token string) (string, string, error) {
+	var doc struct {
+		UserID    string    `bson:"userID"`
+		Email     string    `bson:"email"`
+		ExpiresAt time.Time `bson:"expiresAt"`
+	}
+	err := r.col.FindOne(ctx, bson.M{
+		"tokenHash": HashToken(token),
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&doc)
+	return doc.UserID, doc.Email, err
+}
+
+func (r *mongoVerificationRepo) Delete(ctx context.Context, token string) error {
+	_, err := r.col.DeleteOne(ctx, bson.M{"tokenHash": HashToken(token)})
+	return err
+}
+
+// HashToken คืน SHA-256 (hex) ของ token ยืนยันอีเมลตามที่เก็บในฐานข้อมูล
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
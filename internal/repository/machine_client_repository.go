@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// error ที่ MachineClientRepository ทุก implementation ต้องคืนให้ตรงกัน
+var (
+	ErrMachineClientNotFound  = errors.New("machine client not found")
+	ErrDuplicateMachineClient = errors.New("machine client already exists")
+)
+
+// MachineClientRepository เก็บ client ของ client credentials grant
+type MachineClientRepository interface {
+	// Create ตั้ง CreatedAt แล้วบันทึก (ID ซ้ำคืน ErrDuplicateMachineClient)
+	Create(ctx context.Context, c *domain.MachineClient) error
+	FindByID(ctx context.Context, id string) (*domain.MachineClient, error)
+	// UpdateSecret แทน secret เดิมด้วย hash ใหม่และตั้ง RotatedAt (secret เดิมใช้ไม่ได้ทันที)
+	UpdateSecret(ctx context.Context, id, secretHash string) error
+	// Disable ตั้ง DisabledAt ถ้ายังไม่เคยปิด (เรียกซ้ำได้ เวลาเดิมไม่เปลี่ยน)
+	Disable(ctx context.Context, id string) error
+}
+
+type mongoMachineClientRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoMachineClientRepository สร้าง instance (ค้นด้วย _id อย่างเดียว ไม่ต้องมี index เพิ่ม)
+func NewMongoMachineClientRepository(col *mongo.Collection) MachineClientRepository {
+	return &mongoMachineClientRepo{col: col}
+}
+
+func (r *mongoMachineClientRepo) Create(ctx context.Context, c *domain.MachineClient) error {
+	c.CreatedAt = time.Now()
+	_, err := r.col.InsertOne(ctx, c)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateMachineClient
+	}
+	return err
+}
+
+func (r *mongoMachineClientRepo) FindByID(ctx context.Context, id string) (*domain.MachineClient, error) {
+	var c domain.MachineClient
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrMachineClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *mongoMachineClientRepo) UpdateSecret(ctx context.Context, id, secretHash string) error {
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"secretHash": secretHash, "rotatedAt": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrMachineClientNotFound
+	}
+	return nil
+}
+
+func (r *mongoMachineClientRepo) Disable(ctx context.Context, id string) error {
+	// update แบบ pipeline: $ifNull เก็บเวลาที่ปิดครั้งแรกไว้ในคำสั่งเดียว
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"disabledAt": bson.M{"$ifNull": bson.A{"$disabledAt", time.Now()}}}}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrMachineClientNotFound
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+)
+
+type auditRepo struct {
+	mu     sync.RWMutex
+	events []domain.AuditEvent
+}
+
+// NewAuditRepository สร้าง AuditRepository ในหน่วยความจำ
+func NewAuditRepository() repo.AuditRepository {
+	return &auditRepo{}
+}
+
+func (r *auditRepo) Append(ctx context.Context, e *domain.AuditEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = uuid.NewString()
+	e.CreatedAt = time.Now()
+	r.events = append(r.events, *e)
+	return nil
+}
+
+func (r *auditRepo) List(ctx context.Context, f domain.AuditFilter, page, size int) ([]*domain.AuditEvent, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	r.mu.RLock()
+	var matched []*domain.AuditEvent
+	for _, e := range r.events {
+		if auditMatches(&e, f) {
+			c := e
+			matched = append(matched, &c)
+		}
+	}
+	r.mu.RUnlock()
+
+	// ใหม่ไปเก่า เหมือน sort createdAt, _id จากมากไปน้อยของ Mongo
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	total := int64(len(matched))
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 {
+		return matched, total, nil
+	}
+	start := (page - 1) * size
+	if start >= len(matched) {
+		return nil, total, nil
+	}
+	end := start + size
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], total, nil
+}
+
+func auditMatches(e *domain.AuditEvent, f domain.AuditFilter) bool {
+	switch {
+	case f.Type != "" && e.Type != f.Type,
+		f.Outcome != "" && e.Outcome != f.Outcome,
+		f.ActorID != "" && e.ActorID != f.ActorID,
+		f.SubjectID != "" && e.SubjectID != f.SubjectID,
+		!f.Since.IsZero() && e.CreatedAt.Before(f.Since),
+		!f.Until.IsZero() && !e.CreatedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
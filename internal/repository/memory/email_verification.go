@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+)
+
+type verificationEntry struct {
+	userID    string
+	email     string
+	expiresAt time.Time
+}
+
+type emailVerificationRepo struct {
+	mu     sync.Mutex
+	tokens map[string]verificationEntry // key = SHA-256 ของ token
+}
+
+// NewEmailVerificationRepository สร้าง EmailVerificationRepository ในหน่วยความจำ
+func NewEmailVerificationRepository() ev.EmailVerificationRepository {
+	return &emailVerificationRepo{tokens: make(map[string]verificationEntry)}
+}
+
+func (r *emailVerificationRepo) Create(ctx context.Context, token, userID, email string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for h, e := range r.tokens {
+		if !now.Before(e.expiresAt) {
+			delete(r.tokens, h)
+		}
+	}
+	h := ev.HashToken(token)
+	if _, ok := r.tokens[h]; ok {
+		return errors.New("verification token already exists")
+	}
+	r.tokens[h] = verificationEntry{userID: userID, email: email, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *emailVerificationRepo) Verify(ctx context.Context, token string) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.tokens[ev.HashToken(token)]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return "", "", errors.New("verification token not found")
+	}
+	return e.userID, e.email, nil
+}
+
+func (r *emailVerificationRepo) Delete(ctx context.Context, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tokens, ev.HashToken(token))
+	return nil
+}
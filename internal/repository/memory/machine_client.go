@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type machineClientRepo struct {
+	mu      sync.RWMutex
+	clients map[string]*domain.MachineClient
+}
+
+// NewMachineClientRepository สร้าง MachineClientRepository ในหน่วยความจำ
+func NewMachineClientRepository() repo.MachineClientRepository {
+	return &machineClientRepo{clients: make(map[string]*domain.MachineClient)}
+}
+
+func (r *machineClientRepo) Create(ctx context.Context, c *domain.MachineClient) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clients[c.ID]; ok {
+		return repo.ErrDuplicateMachineClient
+	}
+	c.CreatedAt = time.Now()
+	r.clients[c.ID] = cloneMachineClient(c)
+	return nil
+}
+
+func (r *machineClientRepo) FindByID(ctx context.Context, id string) (*domain.MachineClient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.clients[id]
+	if !ok {
+		return nil, repo.ErrMachineClientNotFound
+	}
+	return cloneMachineClient(c), nil
+}
+
+func (r *machineClientRepo) UpdateSecret(ctx context.Context, id, secretHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.clients[id]
+	if !ok {
+		return repo.ErrMachineClientNotFound
+	}
+	now := time.Now()
+	c.SecretHash, c.RotatedAt = secretHash, &now
+	return nil
+}
+
+func (r *machineClientRepo) Disable(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.clients[id]
+	if !ok {
+		return repo.ErrMachineClientNotFound
+	}
+	if c.DisabledAt == nil {
+		now := time.Now()
+		c.DisabledAt = &now
+	}
+	return nil
+}
+
+func cloneMachineClient(c *domain.MachineClient) *domain.MachineClient {
+	cp := *c
+	cp.Scopes = append([]string(nil), c.Scopes...)
+	if c.RotatedAt != nil {
+		t := *c.RotatedAt
+		cp.RotatedAt = &t
+	}
+	if c.DisabledAt != nil {
+		t := *c.DisabledAt
+		cp.DisabledAt = &t
+	}
+	return &cp
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+	"github.com/LengLKR/auth-microservice/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, repotest.Backend{
+		Users:               NewUserRepository,
+		Tokens:              NewTokenRepository,
+		PasswordResets:      NewPasswordResetRepository,
+		EmailVerifications:  NewEmailVerificationRepository,
+		RefreshTokens:       NewRefreshTokenRepository,
+		WebAuthnCredentials: NewWebAuthnCredentialRepository,
+		WebAuthnSessions:    NewWebAuthnSessionRepository,
+		Audit:               NewAuditRepository,
+		OAuthClients:        NewOAuthClientRepository,
+		AuthorizationCodes:  NewAuthorizationCodeRepository,
+		Consents:            NewConsentRepository,
+		MachineClients:      NewMachineClientRepository,
+	})
+}
+
+// TestTokensAreStoredHashed ตรวจว่า map ของ reset token และ token ยืนยันอีเมลใช้ SHA-256 เป็น key ไม่ใช่ token ดิบ
+func TestTokensAreStoredHashed(t *testing.T) {
+	ctx := context.Background()
+	const raw = "raw-secret-token"
+	exp := time.Now().Add(time.Hour)
+
+	resets := NewPasswordResetRepository().(*passwordResetRepo)
+	if err := resets.Create(ctx, raw, "user-1", exp); err != nil {
+		t.Fatalf("Create reset: %v", err)
+	}
+	if _, ok := resets.tokens[pr.HashToken(raw)]; !ok || len(resets.tokens) != 1 {
+		t.Fatalf("reset tokens = %v, want only the digest of the token", resets.tokens)
+	}
+
+	verifications := NewEmailVerificationRepository().(*emailVerificationRepo)
+	if err := verifications.Create(ctx, raw, "user-1", "a@example.com", exp); err != nil {
+		t.Fatalf("Create verification: %v", err)
+	}
+	if _, ok := verifications.tokens[ev.HashToken(raw)]; !ok || len(verifications.tokens) != 1 {
+		t.Fatalf("verification tokens = %v, want only the digest of the token", verifications.tokens)
+	}
+}
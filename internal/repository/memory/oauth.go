@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type oauthClientRepo struct {
+	mu      sync.RWMutex
+	clients map[string]*domain.OAuthClient
+}
+
+// NewOAuthClientRepository สร้าง OAuthClientRepository ในหน่วยความจำ
+func NewOAuthClientRepository() repo.OAuthClientRepository {
+	return &oauthClientRepo{clients: make(map[string]*domain.OAuthClient)}
+}
+
+func (r *oauthClientRepo) Create(ctx context.Context, c *domain.OAuthClient) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clients[c.ID]; ok {
+		return repo.ErrDuplicateOAuthClient
+	}
+	c.CreatedAt = time.Now()
+	r.clients[c.ID] = cloneOAuthClient(c)
+	return nil
+}
+
+func (r *oauthClientRepo) FindByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.clients[id]
+	if !ok {
+		return nil, repo.ErrOAuthClientNotFound
+	}
+	return cloneOAuthClient(c), nil
+}
+
+func cloneOAuthClient(c *domain.OAuthClient) *domain.OAuthClient {
+	cp := *c
+	cp.RedirectURIs = append([]string(nil), c.RedirectURIs...)
+	cp.Scopes = append([]string(nil), c.Scopes...)
+	return &cp
+}
+
+type authorizationCodeRepo struct {
+	mu    sync.Mutex
+	codes map[string]*domain.AuthorizationCode // key = code hash
+}
+
+// NewAuthorizationCodeRepository สร้าง AuthorizationCodeRepository ในหน่วยความจำ
+func NewAuthorizationCodeRepository() repo.AuthorizationCodeRepository {
+	return &authorizationCodeRepo{codes: make(map[string]*domain.AuthorizationCode)}
+}
+
+func (r *authorizationCodeRepo) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, code := range r.codes {
+		if !now.Before(code.ExpiresAt) {
+			delete(r.codes, k)
+		}
+	}
+	cp := *c
+	cp.Scopes = append([]string(nil), c.Scopes...)
+	r.codes[c.CodeHash] = &cp
+	return nil
+}
+
+func (r *authorizationCodeRepo) Consume(ctx context.Context, codeHash, clientID string) (*domain.AuthorizationCode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.codes[codeHash]
+	if !ok || c.ClientID != clientID {
+		return nil, repo.ErrAuthorizationCodeNotFound
+	}
+	delete(r.codes, codeHash)
+	if !time.Now().Before(c.ExpiresAt) {
+		return nil, repo.ErrAuthorizationCodeNotFound
+	}
+	return c, nil
+}
+
+type consentKey struct {
+	userID, clientID string
+}
+
+type consentRepo struct {
+	mu       sync.RWMutex
+	consents map[consentKey]*domain.Consent
+}
+
+// NewConsentRepository สร้าง ConsentRepository ในหน่วยความจำ
+func NewConsentRepository() repo.ConsentRepository {
+	return &consentRepo{consents: make(map[consentKey]*domain.Consent)}
+}
+
+func (r *consentRepo) Grant(ctx context.Context, userID, clientID string, scopes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := consentKey{userID, clientID}
+	c, ok := r.consents[k]
+	if !ok {
+		c = &domain.Consent{UserID: userID, ClientID: clientID, Scopes: []string{}}
+		r.consents[k] = c
+	}
+	for _, s := range scopes {
+		if !c.Covers([]string{s}) {
+			c.Scopes = append(c.Scopes, s)
+		}
+	}
+	c.GrantedAt = time.Now()
+	return nil
+}
+
+func (r *consentRepo) Find(ctx context.Context, userID, clientID string) (*domain.Consent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.consents[consentKey{userID, clientID}]
+	if !ok {
+		return nil, repo.ErrConsentNotFound
+	}
+	cp := *c
+	cp.Scopes = append([]string(nil), c.Scopes...)
+	return &cp, nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+)
+
+type resetEntry struct {
+	userID    string
+	expiresAt time.Time
+}
+
+type passwordResetRepo struct {
+	mu     sync.Mutex
+	tokens map[string]resetEntry // key = SHA-256 ของ token
+}
+
+// NewPasswordResetRepository สร้าง PasswordResetRepository ในหน่วยความจำ
+func NewPasswordResetRepository() pr.PasswordResetRepository {
+	return &passwordResetRepo{tokens: make(map[string]resetEntry)}
+}
+
+func (r *passwordResetRepo) Create(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for h, e := range r.tokens {
+		if !now.Before(e.expiresAt) {
+			delete(r.tokens, h)
+		}
+	}
+	h := pr.HashToken(token)
+	if _, ok := r.tokens[h]; ok {
+		return errors.New("reset token already exists")
+	}
+	r.tokens[h] = resetEntry{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *passwordResetRepo) Consume(ctx context.Context, token string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := pr.HashToken(token)
+	e, ok := r.tokens[h]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return "", errors.New("reset token not found")
+	}
+	delete(r.tokens, h)
+	return e.userID, nil
+}
+
+func (r *passwordResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for h, e := range r.tokens {
+		if e.userID == userID {
+			delete(r.tokens, h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type refreshTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*domain.RefreshToken // key = TokenHash
+}
+
+// NewRefreshTokenRepository สร้าง RefreshTokenRepository ในหน่วยความจำ
+func NewRefreshTokenRepository() repo.RefreshTokenRepository {
+	return &refreshTokenRepo{tokens: make(map[string]*domain.RefreshToken)}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for h, existing := range r.tokens {
+		if !now.Before(existing.ExpiresAt) {
+			delete(r.tokens, h)
+		}
+	}
+	if _, ok := r.tokens[t.TokenHash]; ok {
+		return errors.New("refresh token already exists")
+	}
+	t.CreatedAt = now
+	r.tokens[t.TokenHash] = cloneRefreshToken(t)
+	return nil
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[hash]
+	if !ok {
+		return nil, errors.New("refresh token not found")
+	}
+	return cloneRefreshToken(t), nil
+}
+
+func (r *refreshTokenRepo) MarkRotated(ctx context.Context, hash string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[hash]
+	if !ok || t.RotatedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.RotatedAt = &now
+	return true, nil
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			revokedAt := now
+			t.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func cloneRefreshToken(t *domain.RefreshToken) *domain.RefreshToken {
+	c := *t
+	c.RotatedAt = cloneTime(t.RotatedAt)
+	c.RevokedAt = cloneTime(t.RevokedAt)
+	return &c
+}
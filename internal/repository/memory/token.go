@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type watermark struct {
+	before, expiresAt time.Time
+}
+
+type tokenRepo struct {
+	mu         sync.Mutex
+	tokens     map[string]time.Time // jti -> expiresAt
+	watermarks map[string]watermark // subject -> watermark
+}
+
+// NewTokenRepository สร้าง TokenRepository (blacklist) ในหน่วยความจำ
+func NewTokenRepository() repo.TokenRepository {
+	return &tokenRepo{tokens: make(map[string]time.Time), watermarks: make(map[string]watermark)}
+}
+
+func (r *tokenRepo) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(time.Now())
+	if expiresAt.After(r.tokens[jti]) {
+		r.tokens[jti] = expiresAt
+	}
+	return nil
+}
+
+func (r *tokenRepo) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exp, ok := r.tokens[jti]
+	return ok && time.Now().Before(exp), nil
+}
+
+func (r *tokenRepo) Claim(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(time.Now())
+	if _, ok := r.tokens[jti]; ok {
+		return false, nil
+	}
+	r.tokens[jti] = expiresAt
+	return true, nil
+}
+
+func (r *tokenRepo) RevokeIssuedBefore(ctx context.Context, subject string, before, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(time.Now())
+	w := r.watermarks[subject]
+	if before.After(w.before) {
+		w.before = before
+	}
+	if expiresAt.After(w.expiresAt) {
+		w.expiresAt = expiresAt
+	}
+	r.watermarks[subject] = w
+	return nil
+}
+
+func (r *tokenRepo) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.watermarks[subject]
+	if !ok || !time.Now().Before(w.expiresAt) {
+		return time.Time{}, nil
+	}
+	return w.before, nil
+}
+
+// sweep ลบ jti และ watermark ที่หมดอายุแล้ว (แทน TTL index) ต้องถือ lock อยู่
+func (r *tokenRepo) sweep(now time.Time) {
+	for jti, exp := range r.tokens {
+		if !now.Before(exp) {
+			delete(r.tokens, jti)
+		}
+	}
+	for subject, w := range r.watermarks {
+		if !now.Before(w.expiresAt) {
+			delete(r.watermarks, subject)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package memory มี repository ทุกตัวแบบเก็บในหน่วยความจำ (thread-safe)
+// สำหรับ test และ local dev ความหมายเหมือน implementation ของ Mongo
+// (email ไม่ซ้ำ, กรองผู้ใช้ที่ soft delete, token หมดอายุถือว่าไม่มีอยู่)
+// ทุก method คืน ctx.Err() ทันทีถ้า context ถูกยกเลิกแล้ว เหมือน driver ของฐานข้อมูลจริง
+package memory
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+)
+
+type userRepo struct {
+	mu    sync.RWMutex
+	users map[string]*domain.User // key = ID
+}
+
+// NewUserRepository สร้าง UserRepository ในหน่วยความจำ
+func NewUserRepository() repo.UserRepository {
+	return &userRepo{users: make(map[string]*domain.User)}
+}
+
+func (r *userRepo) Create(ctx context.Context, u *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// unique index ของ Mongo ครอบคลุมบัญชีที่ถูก soft delete ด้วย
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return repo.ErrDuplicateEmail
+		}
+	}
+	u.ID = uuid.NewString()
+	u.CreatedAt = time.Now()
+	r.users[u.ID] = cloneUser(u)
+	return nil
+}
+
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.Email == email && u.DeletedAt == nil {
+			return cloneUser(u), nil
+		}
+	}
+	return nil, repo.ErrUserNotFound
+}
+
+func (r *userRepo) FindAll(ctx context.Context, filterName, filterEmail string, page, size int) ([]*domain.User, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	nameRe, err := compileFilter(filterName)
+	if err != nil {
+		return nil, 0, err
+	}
+	emailRe, err := compileFilter(filterEmail)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	var matched []*domain.User
+	for _, u := range r.users {
+		if u.DeletedAt != nil {
+			continue
+		}
+		if nameRe != nil && !nameRe.MatchString(u.Name) {
+			continue
+		}
+		if emailRe != nil && !emailRe.MatchString(u.Email) {
+			continue
+		}
+		matched = append(matched, cloneUser(u))
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	total := int64(len(matched))
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 {
+		return matched, total, nil
+	}
+	start := (page - 1) * size
+	if start >= len(matched) {
+		return nil, total, nil
+	}
+	end := start + size
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], total, nil
+}
+
+func (r *userRepo) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt != nil {
+		return nil, repo.ErrUserNotFound
+	}
+	return cloneUser(u), nil
+}
+
+func (r *userRepo) Update(ctx context.Context, u *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.users[u.ID]
+	if !ok || existing.DeletedAt != nil {
+		return repo.ErrUserNotFound
+	}
+	for id, other := range r.users {
+		if id != u.ID && other.Email == u.Email {
+			return repo.ErrDuplicateEmail
+		}
+	}
+	// เหมือน $set ของ Mongo: ไม่แตะ ID, CreatedAt, DeletedAt และสถานะ MFA
+	updated := cloneUser(u)
+	updated.CreatedAt = existing.CreatedAt
+	updated.DeletedAt = existing.DeletedAt
+	updated.MFAEnabled = existing.MFAEnabled
+	updated.TOTPSecret = existing.TOTPSecret
+	updated.TOTPLastStep = existing.TOTPLastStep
+	updated.RecoveryCodes = existing.RecoveryCodes
+	r.users[u.ID] = updated
+	return nil
+}
+
+func (r *userRepo) SetMFA(ctx context.Context, id, secret string, lastStep int64, recoveryCodes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt != nil {
+		return repo.ErrUserNotFound
+	}
+	u.MFAEnabled = secret != ""
+	u.TOTPSecret = secret
+	u.TOTPPendingSecret = ""
+	u.TOTPLastStep = lastStep
+	u.RecoveryCodes = append([]string(nil), recoveryCodes...)
+	return nil
+}
+
+func (r *userRepo) ConsumeTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt != nil || step <= u.TOTPLastStep {
+		return false, nil
+	}
+	u.TOTPLastStep = step
+	return true, nil
+}
+
+func (r *userRepo) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt != nil {
+		return false, nil
+	}
+	for i, stored := range u.RecoveryCodes {
+		if stored == codeHash {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *userRepo) SoftDelete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt != nil {
+		return repo.ErrUserNotFound
+	}
+	now := time.Now()
+	u.DeletedAt = &now
+	return nil
+}
+
+// compileFilter แปลง filter แบบ regex (ไม่สนตัวพิมพ์เล็กใหญ่ เหมือน $options: "i")
+func compileFilter(filter string) (*regexp.Regexp, error) {
+	if filter == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + filter)
+}
+
+// cloneUser คัดลอก User ทั้งก้อน เพื่อไม่ให้ผู้เรียกแก้ข้อมูลใน store ได้โดยตรง
+func cloneUser(u *domain.User) *domain.User {
+	c := *u
+	c.Roles = append([]string(nil), u.Roles...)
+	c.RecoveryCodes = append([]string(nil), u.RecoveryCodes...)
+	c.DeletedAt = cloneTime(u.DeletedAt)
+	c.EmailVerifiedAt = cloneTime(u.EmailVerifiedAt)
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
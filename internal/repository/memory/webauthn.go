@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type webAuthnCredentialRepo struct {
+	mu    sync.RWMutex
+	creds map[string]*domain.WebAuthnCredential // key = credential ID
+}
+
+// NewWebAuthnCredentialRepository สร้าง WebAuthnCredentialRepository ในหน่วยความจำ
+func NewWebAuthnCredentialRepository() repo.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepo{creds: make(map[string]*domain.WebAuthnCredential)}
+}
+
+func (r *webAuthnCredentialRepo) Create(ctx context.Context, c *domain.WebAuthnCredential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.creds[c.ID]; ok {
+		return errors.New("credential already exists")
+	}
+	r.creds[c.ID] = cloneCredential(c)
+	return nil
+}
+
+func (r *webAuthnCredentialRepo) FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.WebAuthnCredential
+	for _, c := range r.creds {
+		if c.UserID == userID {
+			out = append(out, cloneCredential(c))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *webAuthnCredentialRepo) FindByID(ctx context.Context, id string) (*domain.WebAuthnCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.creds[id]
+	if !ok {
+		return nil, errors.New("credential not found")
+	}
+	return cloneCredential(c), nil
+}
+
+func (r *webAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.creds[id]
+	if !ok {
+		return nil
+	}
+	c.SignCount = signCount
+	c.BackupState = backupState
+	c.LastUsedAt = &usedAt
+	return nil
+}
+
+func cloneCredential(c *domain.WebAuthnCredential) *domain.WebAuthnCredential {
+	cp := *c
+	cp.PublicKey = append([]byte(nil), c.PublicKey...)
+	cp.AAGUID = append([]byte(nil), c.AAGUID...)
+	cp.Transports = append([]string(nil), c.Transports...)
+	cp.LastUsedAt = cloneTime(c.LastUsedAt)
+	return &cp
+}
+
+type sessionEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+type webAuthnSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[string]sessionEntry
+}
+
+// NewWebAuthnSessionRepository สร้าง WebAuthnSessionRepository ในหน่วยความจำ
+func NewWebAuthnSessionRepository() repo.WebAuthnSessionRepository {
+	return &webAuthnSessionRepo{sessions: make(map[string]sessionEntry)}
+}
+
+func (r *webAuthnSessionRepo) Create(ctx context.Context, id string, data []byte, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, s := range r.sessions {
+		if !now.Before(s.expiresAt) {
+			delete(r.sessions, k)
+		}
+	}
+	if _, ok := r.sessions[id]; ok {
+		return errors.New("session already exists")
+	}
+	r.sessions[id] = sessionEntry{data: append([]byte(nil), data...), expiresAt: expiresAt}
+	return nil
+}
+
+func (r *webAuthnSessionRepo) Consume(ctx context.Context, id string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	delete(r.sessions, id)
+	if !time.Now().Before(s.expiresAt) {
+		return nil, errors.New("session expired")
+	}
+	return s.data, nil
+}
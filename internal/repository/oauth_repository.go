@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// error ที่ repository ของ OAuth ทุก implementation ต้องคืนให้ตรงกัน
+var (
+	ErrOAuthClientNotFound       = errors.New("oauth client not found")
+	ErrDuplicateOAuthClient      = errors.New("oauth client already exists")
+	ErrAuthorizationCodeNotFound = errors.New("authorization code not found")
+	ErrConsentNotFound           = errors.New("consent not found")
+)
+
+// OAuthClientRepository เก็บ client ที่ลงทะเบียนกับ authorization server
+type OAuthClientRepository interface {
+	// Create ตั้ง CreatedAt แล้วบันทึก (ID ซ้ำคืน ErrDuplicateOAuthClient)
+	Create(ctx context.Context, c *domain.OAuthClient) error
+	FindByID(ctx context.Context, id string) (*domain.OAuthClient, error)
+}
+
+// AuthorizationCodeRepository เก็บ authorization code ระหว่าง /authorize กับ /token
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, c *domain.AuthorizationCode) error
+	// Consume คืน code และลบทิ้งในคำสั่งเดียว (atomic) code ที่หมดอายุถือว่าไม่พบ
+	// request ที่แลก code เดียวกันพร้อมกันจะสำเร็จได้เพียงอันเดียว
+	// code ที่ออกให้ client อื่นถือว่าไม่พบและไม่ถูกลบ (client อื่นทำให้ code เสียไม่ได้)
+	Consume(ctx context.Context, codeHash, clientID string) (*domain.AuthorizationCode, error)
+}
+
+// ConsentRepository เก็บ scope ที่ผู้ใช้อนุญาตให้แต่ละ client
+type ConsentRepository interface {
+	// Grant เพิ่ม scope ให้ consent ของคู่ user/client (รวมกับที่เคยอนุญาตไว้)
+	Grant(ctx context.Context, userID, clientID string, scopes []string) error
+	Find(ctx context.Context, userID, clientID string) (*domain.Consent, error)
+}
+
+type mongoOAuthClientRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoOAuthClientRepository สร้าง instance (ค้นด้วย _id อย่างเดียว ไม่ต้องมี index เพิ่ม)
+func NewMongoOAuthClientRepository(col *mongo.Collection) OAuthClientRepository {
+	return &mongoOAuthClientRepo{col: col}
+}
+
+func (r *mongoOAuthClientRepo) Create(ctx context.Context, c *domain.OAuthClient) error {
+	c.CreatedAt = time.Now()
+	_, err := r.col.InsertOne(ctx, c)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateOAuthClient
+	}
+	return err
+}
+
+func (r *mongoOAuthClientRepo) FindByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	var c domain.OAuthClient
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type mongoAuthorizationCodeRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoAuthorizationCodeRepository สร้าง instance และตั้ง TTL index บน expiresAt
+func NewMongoAuthorizationCodeRepository(col *mongo.Collection) AuthorizationCodeRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return &mongoAuthorizationCodeRepo{col: col}
+}
+
+func (r *mongoAuthorizationCodeRepo) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	_, err := r.col.InsertOne(ctx, c)
+	return err
+}
+
+func (r *mongoAuthorizationCodeRepo) Consume(ctx context.Context, codeHash, clientID string) (*domain.AuthorizationCode, error) {
+	var c domain.AuthorizationCode
+	// TTL monitor ลบเป็นรอบ ๆ จึงต้องกรอง code ที่หมดอายุเองด้วย
+	err := r.col.FindOneAndDelete(ctx, bson.M{
+		"_id":       codeHash,
+		"clientID":  clientID,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAuthorizationCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type mongoConsentRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoConsentRepository สร้าง instance พร้อม unique index บน userID + clientID
+func NewMongoConsentRepository(col *mongo.Collection) ConsentRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userID", Value: 1}, {Key: "clientID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &mongoConsentRepo{col: col}
+}
+
+func (r *mongoConsentRepo) Grant(ctx context.Context, userID, clientID string, scopes []string) error {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	_, err := r.col.UpdateOne(
+		ctx,
+		bson.M{"userID": userID, "clientID": clientID},
+		bson.M{
+			"$addToSet": bson.M{"scopes": bson.M{"$each": scopes}},
+			"$set":      bson.M{"grantedAt": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *mongoConsentRepo) Find(ctx context.Context, userID, clientID string) (*domain.Consent, error) {
+	var c domain.Consent
+	err := r.col.FindOne(ctx, bson.M{"userID": userID, "clientID": clientID}).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConsentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,11 +12,15 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-//PasswordResetRepository  จัดการสร้าง-ตรวจสอบ-ลบ reset token
+//PasswordResetRepository  จัดการสร้าง-ใช้-ลบ reset token
+// token ถูกเก็บเป็น SHA-256 digest ฐานข้อมูลหลุดก็เอาไปใช้ไม่ได้
 type PasswordResetRepository interface {
-	Create(token, userID string, expiresAt time.Time) error
-	Verify(token string) (string, error)
-	Delete(token string) error
+	Create(ctx context.Context, token, userID string, expiresAt time.Time) error
+	// Consume ตรวจและลบ token ในคำสั่งเดียว (atomic) คืน userID
+	// request ที่มาพร้อมกันด้วย token เดียวกันจะสำเร็จได้เพียงอันเดียว
+	Consume(ctx context.Context, token string) (string, error)
+	// DeleteByUser ลบ reset token ทั้งหมดที่ค้างอยู่ของผู้ใช้
+	DeleteByUser(ctx context.Context, userID string) error
 }
 
 type mongoResetRepo struct {
@@ -24,11 +31,17 @@ func NewMongoResetRepo(col *mongo.Collection) PasswordResetRepository {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	//index บน token และ TTL บน expiresAT
+	// index เดิมบน token (raw) ใช้ไม่ได้แล้ว: document ใหม่ไม่มี field นี้ จะชนกันที่ค่า null
+	col.Indexes().DropOne(ctx, "token_1")
+
+	//index บน tokenHash, userID และ TTL บน expiresAT
 	col.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:	bson.M{"token": 1},
+		Keys:	bson.M{"tokenHash": 1},
 		Options: options.Index().SetUnique(true),
 	})
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"userID": 1},
+	})
 	col.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.M{"expiresAT": 1},
 		Options: options.Index().SetExpireAfterSeconds(0),
@@ -36,22 +49,35 @@ func NewMongoResetRepo(col *mongo.Collection) PasswordResetRepository {
 	return &mongoResetRepo{col: col}
 }
 
-func (r *mongoResetRepo) Create(token, userID string, expiresAt time.Time) error{
-	_, err := r.col.InsertOne(context.Background(), bson.M{
-		"token":	 token,
+func (r *mongoResetRepo) Create(ctx context.Context, token, userID string, expiresAt time.Time) error{
+	_, err := r.col.InsertOne(ctx, bson.M{
+		"tokenHash": HashToken(token),
 		"userID":	 userID,
 		"expiresAT": expiresAt,
 	})
 	return err
 }
 
-func (r *mongoResetRepo) Verify(token string) (string, error){
+func (r *mongoResetRepo) Consume(ctx context.Context, token string) (string, error){
 	var doc struct{ UserID string}
-	err := r.col.FindOne(context.Background(), bson.M{"token": token}).Decode(&doc)
+	// TTL monitor ลบเป็นรอบ ๆ จึงต้องกรอง token ที่หมดอายุเองด้วย
+	err := r.col.FindOneAndDelete(ctx, bson.M{
+		"tokenHash": HashToken(token),
+		"expiresAT": bson.M{"$gt": time.Now()},
+	}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return "", errors.New("reset token not found")
+	}
 	return doc.UserID, err
 }
 
-func (r *mongoResetRepo) Delete(token string) error {
-	_, err := r.col.DeleteOne(context.Background(), bson.M{"token": token})
+func (r *mongoResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.col.DeleteMany(ctx, bson.M{"userID": userID})
 	return err
-}
\ No newline at end of file
+}
+
+// HashToken คืน SHA-256 (hex) ของ reset token ตามที่เก็บในฐานข้อมูล
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const auditColumns = `id, type, outcome, actor_id, subject_id, email, ip, user_agent, reason, created_at`
+
+type auditRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuditRepository สร้าง AuditRepository บน Postgres
+func NewAuditRepository(pool *pgxpool.Pool) repo.AuditRepository {
+	return &auditRepo{pool: pool}
+}
+
+func (r *auditRepo) Append(ctx context.Context, e *domain.AuditEvent) error {
+	id, now := uuid.NewString(), time.Now()
+	_, err := r.pool.Exec(ctx, `INSERT INTO audit_events (`+auditColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		id, e.Type, e.Outcome, e.ActorID, e.SubjectID, e.Email, e.IP, e.UserAgent, e.Reason, now)
+	if err != nil {
+		return err
+	}
+	e.ID, e.CreatedAt = id, now
+	return nil
+}
+
+func (r *auditRepo) List(ctx context.Context, f domain.AuditFilter, page, size int) ([]*domain.AuditEvent, int64, error) {
+	const where = ` FROM audit_events WHERE ($1 = '' OR type = $1)
+		AND ($2 = '' OR outcome = $2)
+		AND ($3 = '' OR actor_id = $3)
+		AND ($4 = '' OR subject_id = $4)
+		AND ($5::timestamptz IS NULL OR created_at >= $5)
+		AND ($6::timestamptz IS NULL OR created_at < $6)`
+	args := []interface{}{f.Type, f.Outcome, f.ActorID, f.SubjectID, optionalTime(f.Since), optionalTime(f.Until)}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*)`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	var limit *int64
+	offset := int64(0)
+	if size > 0 {
+		l := int64(size)
+		limit = &l
+		offset = int64(page-1) * l
+	}
+	rows, err := r.pool.Query(ctx, `SELECT `+auditColumns+where+`
+		ORDER BY created_at DESC, id DESC LIMIT $7 OFFSET $8`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.AuditEvent, error) {
+		var e domain.AuditEvent
+		err := row.Scan(&e.ID, &e.Type, &e.Outcome, &e.ActorID, &e.SubjectID, &e.Email, &e.IP, &e.UserAgent, &e.Reason, &e.CreatedAt)
+		return &e, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// optionalTime แปลง zero time เป็น NULL (ไม่กรอง)
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type emailVerificationRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewEmailVerificationRepository สร้าง EmailVerificationRepository บน Postgres
+func NewEmailVerificationRepository(pool *pgxpool.Pool) ev.EmailVerificationRepository {
+	return &emailVerificationRepo{pool: pool}
+}
+
+func (r *emailVerificationRepo) Create(ctx context.Context, token, userID, email string, expiresAt time.Time) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM email_verifications WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO email_verifications (token_hash, user_id, email, expires_at) VALUES ($1, $2, $3, $4)`,
+		ev.HashToken(token), userID, email, expiresAt)
+	if isUniqueViolation(err) {
+		return errors.New("verification token already exists")
+	}
+	return err
+}
+
+func (r *emailVerificationRepo) Verify(ctx context.Context, token string) (string, string, error) {
+	var userID, email string
+	err := r.pool.QueryRow(ctx,
+		`SELECT user_id, email FROM email_verifications WHERE token_hash = $1 AND expires_at > $2`,
+		ev.HashToken(token), time.Now()).Scan(&userID, &email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", errors.New("verification token not found")
+	}
+	return userID, email, err
+}
+
+func (r *emailVerificationRepo) Delete(ctx context.Context, token string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM email_verifications WHERE token_hash = $1`, ev.HashToken(token))
+	return err
+}
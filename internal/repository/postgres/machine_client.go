@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type machineClientRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewMachineClientRepository สร้าง MachineClientRepository บน Postgres
+func NewMachineClientRepository(pool *pgxpool.Pool) repo.MachineClientRepository {
+	return &machineClientRepo{pool: pool}
+}
+
+func (r *machineClientRepo) Create(ctx context.Context, c *domain.MachineClient) error {
+	now := time.Now()
+	_, err := r.pool.Exec(ctx, `INSERT INTO machine_clients
+		(id, name, secret_hash, scopes, created_at, rotated_at, disabled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		c.ID, c.Name, c.SecretHash, textArray(c.Scopes), now, c.RotatedAt, c.DisabledAt)
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateMachineClient
+	}
+	if err != nil {
+		return err
+	}
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *machineClientRepo) FindByID(ctx context.Context, id string) (*domain.MachineClient, error) {
+	var c domain.MachineClient
+	err := r.pool.QueryRow(ctx, `SELECT id, name, secret_hash, scopes, created_at, rotated_at, disabled_at
+		FROM machine_clients WHERE id = $1`, id).
+		Scan(&c.ID, &c.Name, &c.SecretHash, &c.Scopes, &c.CreatedAt, &c.RotatedAt, &c.DisabledAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repo.ErrMachineClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *machineClientRepo) UpdateSecret(ctx context.Context, id, secretHash string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE machine_clients SET secret_hash = $2, rotated_at = $3 WHERE id = $1`,
+		id, secretHash, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.ErrMachineClientNotFound
+	}
+	return nil
+}
+
+func (r *machineClientRepo) Disable(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE machine_clients SET disabled_at = COALESCE(disabled_at, $2) WHERE id = $1`,
+		id, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.ErrMachineClientNotFound
+	}
+	return nil
+}
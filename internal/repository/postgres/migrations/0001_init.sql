@@ -0,0 +1,76 @@
+-- schema เริ่มต้น: ตารางเดียวกับ collection ของ Mongo
+
+CREATE TABLE users (
+    id                  TEXT PRIMARY KEY,
+    email               TEXT NOT NULL UNIQUE, -- รวมบัญชีที่ถูก soft delete ด้วย
+    password_hash       TEXT NOT NULL DEFAULT '',
+    name                TEXT NOT NULL DEFAULT '',
+    roles               TEXT[] NOT NULL DEFAULT '{}',
+    email_verified      BOOLEAN NOT NULL DEFAULT FALSE,
+    email_verified_at   TIMESTAMPTZ,
+    mfa_enabled         BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_secret         TEXT NOT NULL DEFAULT '',
+    totp_pending_secret TEXT NOT NULL DEFAULT '',
+    totp_last_step      BIGINT NOT NULL DEFAULT 0,
+    recovery_codes      TEXT[] NOT NULL DEFAULT '{}',
+    created_at          TIMESTAMPTZ NOT NULL,
+    deleted_at          TIMESTAMPTZ
+);
+CREATE INDEX users_created_at_idx ON users (created_at, id) WHERE deleted_at IS NULL;
+
+CREATE TABLE invalidated_tokens (
+    token      TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX invalidated_tokens_expires_at_idx ON invalidated_tokens (expires_at);
+
+CREATE TABLE password_resets (
+    token_hash TEXT PRIMARY KEY, -- SHA-256 (hex) ของ token
+    user_id    TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
+CREATE INDEX password_resets_expires_at_idx ON password_resets (expires_at);
+
+CREATE TABLE email_verifications (
+    token      TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX email_verifications_expires_at_idx ON email_verifications (expires_at);
+
+CREATE TABLE refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    family_id  TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
+
+CREATE TABLE webauthn_credentials (
+    id               TEXT PRIMARY KEY, -- credential ID (base64url)
+    user_id          TEXT NOT NULL,
+    name             TEXT NOT NULL DEFAULT '',
+    public_key       BYTEA NOT NULL,
+    attestation_type TEXT NOT NULL DEFAULT '',
+    transports       TEXT[] NOT NULL DEFAULT '{}',
+    aaguid           BYTEA,
+    sign_count       BIGINT NOT NULL DEFAULT 0,
+    backup_eligible  BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at       TIMESTAMPTZ NOT NULL,
+    last_used_at     TIMESTAMPTZ
+);
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE webauthn_sessions (
+    id         TEXT PRIMARY KEY,
+    data       BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX webauthn_sessions_expires_at_idx ON webauthn_sessions (expires_at);
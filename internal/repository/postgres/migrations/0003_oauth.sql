@@ -0,0 +1,29 @@
+-- OAuth 2.0 authorization server: client, authorization code และ consent
+
+CREATE TABLE oauth_clients (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    secret_hash   TEXT NOT NULL DEFAULT '', -- ว่าง = public client
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    scopes        TEXT[] NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE oauth_authorization_codes (
+    code_hash      TEXT PRIMARY KEY, -- SHA-256 (hex) ของ code
+    client_id      TEXT NOT NULL,
+    user_id        TEXT NOT NULL,
+    redirect_uri   TEXT NOT NULL,
+    scopes         TEXT[] NOT NULL DEFAULT '{}',
+    code_challenge TEXT NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX oauth_authorization_codes_expires_at_idx ON oauth_authorization_codes (expires_at);
+
+CREATE TABLE oauth_consents (
+    user_id    TEXT NOT NULL,
+    client_id  TEXT NOT NULL,
+    scopes     TEXT[] NOT NULL DEFAULT '{}',
+    granted_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, client_id)
+);
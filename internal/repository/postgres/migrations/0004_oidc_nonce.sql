@@ -0,0 +1,3 @@
+-- OpenID Connect: nonce จาก /authorize ต้องส่งต่อไปถึง ID token
+
+ALTER TABLE oauth_authorization_codes ADD COLUMN nonce TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- client ของ client credentials grant (service-to-service)
+
+CREATE TABLE machine_clients (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    secret_hash TEXT NOT NULL, -- SHA-256 (hex) ของ client secret
+    scopes      TEXT[] NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL,
+    rotated_at  TIMESTAMPTZ,
+    disabled_at TIMESTAMPTZ
+);
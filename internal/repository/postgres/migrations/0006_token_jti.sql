@@ -0,0 +1,13 @@
+-- blacklist เก็บ jti แทน token ดิบ (token ดิบใน DB dump คือ bearer token ที่ยังใช้ได้)
+-- แถวเดิมลบทิ้งได้: token ที่ไม่มี jti ถูกปฏิเสธอยู่แล้ว
+
+DELETE FROM invalidated_tokens;
+ALTER TABLE invalidated_tokens RENAME COLUMN token TO jti;
+
+-- watermark ของ logout ทุกเครื่อง: token ของ subject ที่ออกก่อน revoked_before ใช้ไม่ได้
+CREATE TABLE token_watermarks (
+    subject        TEXT PRIMARY KEY,
+    revoked_before TIMESTAMPTZ NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX token_watermarks_expires_at_idx ON token_watermarks (expires_at);
@@ -0,0 +1,5 @@
+-- token ยืนยันอีเมลเก็บเป็น SHA-256 (hex) แบบเดียวกับ reset token
+-- แปลงแถวเดิมในที่ ลิงก์ที่ส่งไปแล้วจึงยังใช้ได้
+
+ALTER TABLE email_verifications RENAME COLUMN token TO token_hash;
+UPDATE email_verifications SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
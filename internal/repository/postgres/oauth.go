@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type oauthClientRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewOAuthClientRepository สร้าง OAuthClientRepository บน Postgres
+func NewOAuthClientRepository(pool *pgxpool.Pool) repo.OAuthClientRepository {
+	return &oauthClientRepo{pool: pool}
+}
+
+func (r *oauthClientRepo) Create(ctx context.Context, c *domain.OAuthClient) error {
+	now := time.Now()
+	_, err := r.pool.Exec(ctx, `INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		c.ID, c.Name, c.SecretHash, textArray(c.RedirectURIs), textArray(c.Scopes), now)
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateOAuthClient
+	}
+	if err != nil {
+		return err
+	}
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *oauthClientRepo) FindByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	var c domain.OAuthClient
+	err := r.pool.QueryRow(ctx, `SELECT id, name, secret_hash, redirect_uris, scopes, created_at
+		FROM oauth_clients WHERE id = $1`, id).
+		Scan(&c.ID, &c.Name, &c.SecretHash, &c.RedirectURIs, &c.Scopes, &c.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repo.ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type authorizationCodeRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuthorizationCodeRepository สร้าง AuthorizationCodeRepository บน Postgres
+func NewAuthorizationCodeRepository(pool *pgxpool.Pool) repo.AuthorizationCodeRepository {
+	return &authorizationCodeRepo{pool: pool}
+}
+
+func (r *authorizationCodeRepo) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM oauth_authorization_codes WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO oauth_authorization_codes
+		(code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		c.CodeHash, c.ClientID, c.UserID, c.RedirectURI, textArray(c.Scopes), c.CodeChallenge, c.Nonce, c.ExpiresAt)
+	return err
+}
+
+func (r *authorizationCodeRepo) Consume(ctx context.Context, codeHash, clientID string) (*domain.AuthorizationCode, error) {
+	// DELETE ... RETURNING ทำให้ตรวจและลบในคำสั่งเดียว
+	var c domain.AuthorizationCode
+	err := r.pool.QueryRow(ctx, `DELETE FROM oauth_authorization_codes
+		WHERE code_hash = $1 AND client_id = $2 AND expires_at > $3
+		RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at`,
+		codeHash, clientID, time.Now()).
+		Scan(&c.CodeHash, &c.ClientID, &c.UserID, &c.RedirectURI, &c.Scopes, &c.CodeChallenge, &c.Nonce, &c.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repo.ErrAuthorizationCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type consentRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewConsentRepository สร้าง ConsentRepository บน Postgres
+func NewConsentRepository(pool *pgxpool.Pool) repo.ConsentRepository {
+	return &consentRepo{pool: pool}
+}
+
+func (r *consentRepo) Grant(ctx context.Context, userID, clientID string, scopes []string) error {
+	// รวม scope เดิมกับใหม่ในคำสั่งเดียว request ที่มาพร้อมกันจึงไม่ทับกัน
+	_, err := r.pool.Exec(ctx, `INSERT INTO oauth_consents (user_id, client_id, scopes, granted_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET
+			scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+			granted_at = EXCLUDED.granted_at`,
+		userID, clientID, textArray(scopes), time.Now())
+	return err
+}
+
+func (r *consentRepo) Find(ctx context.Context, userID, clientID string) (*domain.Consent, error) {
+	var c domain.Consent
+	err := r.pool.QueryRow(ctx, `SELECT user_id, client_id, scopes, granted_at
+		FROM oauth_consents WHERE user_id = $1 AND client_id = $2`, userID, clientID).
+		Scan(&c.UserID, &c.ClientID, &c.Scopes, &c.GrantedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repo.ErrConsentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
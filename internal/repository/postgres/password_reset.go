@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type passwordResetRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewPasswordResetRepository สร้าง PasswordResetRepository บน Postgres
+func NewPasswordResetRepository(pool *pgxpool.Pool) pr.PasswordResetRepository {
+	return &passwordResetRepo{pool: pool}
+}
+
+func (r *passwordResetRepo) Create(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM password_resets WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`,
+		pr.HashToken(token), userID, expiresAt)
+	if isUniqueViolation(err) {
+		return errors.New("reset token already exists")
+	}
+	return err
+}
+
+func (r *passwordResetRepo) Consume(ctx context.Context, token string) (string, error) {
+	// DELETE ... RETURNING ทำให้ตรวจและลบในคำสั่งเดียว
+	var userID string
+	err := r.pool.QueryRow(ctx,
+		`DELETE FROM password_resets WHERE token_hash = $1 AND expires_at > $2 RETURNING user_id`,
+		pr.HashToken(token), time.Now()).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errors.New("reset token not found")
+	}
+	return userID, err
+}
+
+func (r *passwordResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+	"github.com/LengLKR/auth-microservice/internal/repository/repotest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestConformance รัน repotest กับ Postgres จริงที่ POSTGRES_DSN (ข้ามถ้าไม่ได้ตั้งไว้)
+// ฐานข้อมูลนี้จะถูกล้างข้อมูลทุกตาราง อย่าชี้ไปที่ฐานข้อมูลที่ใช้งานจริง
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+	pool, err := Open(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	// fresh ล้างทุกตาราง (ยกเว้น schema_migrations) เพื่อให้ constructor แต่ละครั้งได้ storage ว่าง
+	fresh := func() *pgxpool.Pool {
+		t.Helper()
+		rows, err := pool.Query(ctx, `SELECT quote_ident(tablename) FROM pg_tables
+			WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'`)
+		if err != nil {
+			t.Fatalf("list tables: %v", err)
+		}
+		tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			t.Fatalf("list tables: %v", err)
+		}
+		if _, err := pool.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" CASCADE"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return pool
+	}
+
+	repotest.Run(t, repotest.Backend{
+		Users:               func() repo.UserRepository { return NewUserRepository(fresh()) },
+		Tokens:              func() repo.TokenRepository { return NewTokenRepository(fresh()) },
+		PasswordResets:      func() pr.PasswordResetRepository { return NewPasswordResetRepository(fresh()) },
+		EmailVerifications:  func() ev.EmailVerificationRepository { return NewEmailVerificationRepository(fresh()) },
+		RefreshTokens:       func() repo.RefreshTokenRepository { return NewRefreshTokenRepository(fresh()) },
+		WebAuthnCredentials: func() repo.WebAuthnCredentialRepository { return NewWebAuthnCredentialRepository(fresh()) },
+		WebAuthnSessions:    func() repo.WebAuthnSessionRepository { return NewWebAuthnSessionRepository(fresh()) },
+		Audit:               func() repo.AuditRepository { return NewAuditRepository(fresh()) },
+		OAuthClients:        func() repo.OAuthClientRepository { return NewOAuthClientRepository(fresh()) },
+		AuthorizationCodes:  func() repo.AuthorizationCodeRepository { return NewAuthorizationCodeRepository(fresh()) },
+		Consents:            func() repo.ConsentRepository { return NewConsentRepository(fresh()) },
+		MachineClients:      func() repo.MachineClientRepository { return NewMachineClientRepository(fresh()) },
+	})
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type refreshTokenRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewRefreshTokenRepository สร้าง RefreshTokenRepository บน Postgres
+func NewRefreshTokenRepository(pool *pgxpool.Pool) repo.RefreshTokenRepository {
+	return &refreshTokenRepo{pool: pool}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	now := time.Now()
+	if _, err := r.pool.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= $1`, now); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO refresh_tokens
+		(token_hash, user_id, family_id, created_at, expires_at, rotated_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		t.TokenHash, t.UserID, t.FamilyID, now, t.ExpiresAt, t.RotatedAt, t.RevokedAt)
+	if isUniqueViolation(err) {
+		return errors.New("refresh token already exists")
+	}
+	if err != nil {
+		return err
+	}
+	t.CreatedAt = now
+	return nil
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var t domain.RefreshToken
+	err := r.pool.QueryRow(ctx, `SELECT
+		token_hash, user_id, family_id, created_at, expires_at, rotated_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1`, hash).
+		Scan(&t.TokenHash, &t.UserID, &t.FamilyID, &t.CreatedAt, &t.ExpiresAt, &t.RotatedAt, &t.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("refresh token not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *refreshTokenRepo) MarkRotated(ctx context.Context, hash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE refresh_tokens SET rotated_at = $2
+		WHERE token_hash = $1 AND rotated_at IS NULL AND revoked_at IS NULL`, hash, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = $2
+		WHERE family_id = $1 AND revoked_at IS NULL`, familyID, time.Now())
+	return err
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type tokenRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewTokenRepository สร้าง TokenRepository (blacklist) บน Postgres
+func NewTokenRepository(pool *pgxpool.Pool) repo.TokenRepository {
+	return &tokenRepo{pool: pool}
+}
+
+func (r *tokenRepo) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM invalidated_tokens WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO invalidated_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO UPDATE SET expires_at = GREATEST(invalidated_tokens.expires_at, EXCLUDED.expires_at)`,
+		jti, expiresAt)
+	return err
+}
+
+func (r *tokenRepo) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	var found bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM invalidated_tokens WHERE jti = $1 AND expires_at > $2)`,
+		jti, time.Now()).Scan(&found)
+	return found, err
+}
+
+func (r *tokenRepo) Claim(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM invalidated_tokens WHERE expires_at <= $1`, time.Now()); err != nil {
+		return false, err
+	}
+	tag, err := r.pool.Exec(ctx, `INSERT INTO invalidated_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`, jti, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *tokenRepo) RevokeIssuedBefore(ctx context.Context, subject string, before, expiresAt time.Time) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM token_watermarks WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO token_watermarks (subject, revoked_before, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (subject) DO UPDATE SET
+			revoked_before = GREATEST(token_watermarks.revoked_before, EXCLUDED.revoked_before),
+			expires_at = GREATEST(token_watermarks.expires_at, EXCLUDED.expires_at)`,
+		subject, before, expiresAt)
+	return err
+}
+
+func (r *tokenRepo) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	var before time.Time
+	err := r.pool.QueryRow(ctx,
+		`SELECT revoked_before FROM token_watermarks WHERE subject = $1 AND expires_at > $2`,
+		subject, time.Now()).Scan(&before)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return before, err
+}
@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const userColumns = `id, email, password_hash, name, roles, email_verified, email_verified_at,
+	mfa_enabled, totp_secret, totp_pending_secret, totp_last_step, recovery_codes, created_at, deleted_at`
+
+type userRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserRepository สร้าง UserRepository บน Postgres
+func NewUserRepository(pool *pgxpool.Pool) repo.UserRepository {
+	return &userRepo{pool: pool}
+}
+
+func (r *userRepo) Create(ctx context.Context, u *domain.User) error {
+	id := uuid.NewString()
+	now := time.Now()
+	_, err := r.pool.Exec(ctx, `INSERT INTO users (`+userColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULL)`,
+		id, u.Email, u.PasswordHash, u.Name, textArray(u.Roles), u.EmailVerified, u.EmailVerifiedAt,
+		u.MFAEnabled, u.TOTPSecret, u.TOTPPendingSecret, u.TOTPLastStep, textArray(u.RecoveryCodes), now)
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateEmail
+	}
+	if err != nil {
+		return err
+	}
+	u.ID = id
+	u.CreatedAt = now
+	return nil
+}
+
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1 AND deleted_at IS NULL`, email)
+}
+
+func (r *userRepo) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1 AND deleted_at IS NULL`, id)
+}
+
+// FindAll ใช้ ~* (regex ไม่สนตัวพิมพ์เล็กใหญ่) ให้ตรงกับ $regex + $options "i" ของ Mongo
+func (r *userRepo) FindAll(ctx context.Context, filterName, filterEmail string, page, size int) ([]*domain.User, int64, error) {
+	const where = ` FROM users WHERE deleted_at IS NULL
+		AND ($1 = '' OR name ~* $1)
+		AND ($2 = '' OR email ~* $2)`
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*)`+where, filterName, filterEmail).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	// LIMIT NULL = ไม่จำกัด เหมือน SetLimit(0) ของ Mongo
+	var limit *int64
+	offset := int64(0)
+	if size > 0 {
+		l := int64(size)
+		limit = &l
+		offset = int64(page-1) * l
+	}
+	rows, err := r.pool.Query(ctx, `SELECT `+userColumns+where+`
+		ORDER BY created_at, id LIMIT $3 OFFSET $4`, filterName, filterEmail, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.User, error) {
+		return scanUser(row)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepo) Update(ctx context.Context, u *domain.User) error {
+	// เหมือน $set ของ Mongo: ไม่แตะ id, created_at, deleted_at และสถานะ MFA (เขียนผ่าน SetMFA)
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET
+		email = $2, password_hash = $3, name = $4, roles = $5,
+		email_verified = $6, email_verified_at = $7, totp_pending_secret = $8
+		WHERE id = $1 AND deleted_at IS NULL`,
+		u.ID, u.Email, u.PasswordHash, u.Name, textArray(u.Roles),
+		u.EmailVerified, u.EmailVerifiedAt, u.TOTPPendingSecret)
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateEmail
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) SetMFA(ctx context.Context, id, secret string, lastStep int64, recoveryCodes []string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET
+		mfa_enabled = $2 <> '', totp_secret = $2, totp_pending_secret = '', totp_last_step = $3, recovery_codes = $4
+		WHERE id = $1 AND deleted_at IS NULL`,
+		id, secret, lastStep, textArray(recoveryCodes))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) ConsumeTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET totp_last_step = $2
+		WHERE id = $1 AND deleted_at IS NULL AND totp_last_step < $2`, id, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *userRepo) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET recovery_codes = array_remove(recovery_codes, $2)
+		WHERE id = $1 AND deleted_at IS NULL AND $2 = ANY(recovery_codes)`, id, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *userRepo) SoftDelete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) findOne(ctx context.Context, query string, arg string) (*domain.User, error) {
+	u, err := scanUser(r.pool.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repo.ErrUserNotFound
+	}
+	return u, err
+}
+
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var u domain.User
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Name, &u.Roles, &u.EmailVerified, &u.EmailVerifiedAt,
+		&u.MFAEnabled, &u.TOTPSecret, &u.TOTPPendingSecret, &u.TOTPLastStep, &u.RecoveryCodes, &u.CreatedAt, &u.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// textArray แปลง nil slice เป็น slice ว่าง เพราะคอลัมน์ TEXT[] เป็น NOT NULL
+func textArray(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const credentialColumns = `id, user_id, name, public_key, attestation_type, transports, aaguid,
+	sign_count, backup_eligible, backup_state, created_at, last_used_at`
+
+type webAuthnCredentialRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebAuthnCredentialRepository สร้าง WebAuthnCredentialRepository บน Postgres
+func NewWebAuthnCredentialRepository(pool *pgxpool.Pool) repo.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepo{pool: pool}
+}
+
+func (r *webAuthnCredentialRepo) Create(ctx context.Context, c *domain.WebAuthnCredential) error {
+	now := time.Now()
+	_, err := r.pool.Exec(ctx, `INSERT INTO webauthn_credentials (`+credentialColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		c.ID, c.UserID, c.Name, c.PublicKey, c.AttestationType, textArray(c.Transports), c.AAGUID,
+		int64(c.SignCount), c.BackupEligible, c.BackupState, now, c.LastUsedAt)
+	if isUniqueViolation(err) {
+		return errors.New("credential already exists")
+	}
+	if err != nil {
+		return err
+	}
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *webAuthnCredentialRepo) FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+credentialColumns+`
+		FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.WebAuthnCredential, error) {
+		return scanCredential(row)
+	})
+}
+
+func (r *webAuthnCredentialRepo) FindByID(ctx context.Context, id string) (*domain.WebAuthnCredential, error) {
+	c, err := scanCredential(r.pool.QueryRow(ctx, `SELECT `+credentialColumns+`
+		FROM webauthn_credentials WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("credential not found")
+	}
+	return c, err
+}
+
+func (r *webAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE webauthn_credentials
+		SET sign_count = $2, backup_state = $3, last_used_at = $4 WHERE id = $1`,
+		id, int64(signCount), backupState, usedAt)
+	return err
+}
+
+func scanCredential(row pgx.Row) (*domain.WebAuthnCredential, error) {
+	var c domain.WebAuthnCredential
+	var signCount int64
+	err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.PublicKey, &c.AttestationType, &c.Transports, &c.AAGUID,
+		&signCount, &c.BackupEligible, &c.BackupState, &c.CreatedAt, &c.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.SignCount = uint32(signCount)
+	return &c, nil
+}
+
+type webAuthnSessionRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebAuthnSessionRepository สร้าง WebAuthnSessionRepository บน Postgres
+func NewWebAuthnSessionRepository(pool *pgxpool.Pool) repo.WebAuthnSessionRepository {
+	return &webAuthnSessionRepo{pool: pool}
+}
+
+func (r *webAuthnSessionRepo) Create(ctx context.Context, id string, data []byte, expiresAt time.Time) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM webauthn_sessions WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO webauthn_sessions (id, data, expires_at) VALUES ($1, $2, $3)`,
+		id, data, expiresAt)
+	return err
+}
+
+func (r *webAuthnSessionRepo) Consume(ctx context.Context, id string) ([]byte, error) {
+	var data []byte
+	err := r.pool.QueryRow(ctx,
+		`DELETE FROM webauthn_sessions WHERE id = $1 AND expires_at > $2 RETURNING data`,
+		id, time.Now()).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("session not found")
+	}
+	return data, err
+}
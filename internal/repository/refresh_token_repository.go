@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RefreshTokenRepository จัดเก็บ refresh token (เป็น hash) สำหรับ rotation และ reuse detection
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t *domain.RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error)
+	// MarkRotated ทำเครื่องหมายว่า token ถูกใช้แล้วแบบ atomic
+	// คืน false ถ้า token ถูก rotate หรือ revoke ไปก่อนหน้าแล้ว
+	MarkRotated(ctx context.Context, hash string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+type mongoRefreshTokenRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoRefreshTokenRepository สร้าง instance พร้อม index บน hash, family และ TTL
+func NewMongoRefreshTokenRepository(col *mongo.Collection) RefreshTokenRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"tokenHash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"familyID": 1},
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return &mongoRefreshTokenRepo{col: col}
+}
+
+func (r *mongoRefreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	t.CreatedAt = time.Now()
+	_, err := r.col.InsertOne(ctx, t)
+	return err
+}
+
+func (r *mongoRefreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var t domain.RefreshToken
+	err := r.col.FindOne(ctx, bson.M{"tokenHash": hash}).Decode(&t)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("refresh token not found")
+	}
+	return &t, err
+}
+
+func (r *mongoRefreshTokenRepo) MarkRotated(ctx context.Context, hash string) (bool, error) {
+	res, err := r.col.UpdateOne(
+		ctx,
+		bson.M{
+			"tokenHash": hash,
+			"rotatedAt": bson.M{"$exists": false},
+			"revokedAt": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"rotatedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *mongoRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.col.UpdateMany(
+		ctx,
+		bson.M{"familyID": familyID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	return err
+}
@@ -0,0 +1,109 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+// TestAudit ตรวจ audit log: Append ตั้ง ID/เวลา, List เรียงใหม่ไปเก่า, filter และ pagination
+func TestAudit(t *testing.T, newRepo func() repo.AuditRepository) {
+	ctx := context.Background()
+
+	t.Run("AppendAndList", func(t *testing.T) {
+		r := newRepo()
+		before := time.Now().Add(-time.Second)
+		e := &domain.AuditEvent{
+			Type: domain.AuditLogin, Outcome: domain.AuditFailure, SubjectID: "user-1",
+			Email: "a@example.com", IP: "203.0.113.7", UserAgent: "curl/8", Reason: "INVALID_CREDENTIALS",
+		}
+		if err := r.Append(ctx, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		if e.ID == "" || e.CreatedAt.Before(before) {
+			t.Fatalf("Append did not set ID/CreatedAt: %+v", e)
+		}
+		got, total, err := r.List(ctx, domain.AuditFilter{}, 1, 10)
+		if err != nil || total != 1 || len(got) != 1 {
+			t.Fatalf("List = %d events (total %d), %v", len(got), total, err)
+		}
+		g := got[0]
+		if g.ID != e.ID || g.Type != e.Type || g.Outcome != e.Outcome || g.SubjectID != "user-1" || g.Email != e.Email ||
+			g.IP != e.IP || g.UserAgent != e.UserAgent || g.Reason != e.Reason || !sameTime(g.CreatedAt, e.CreatedAt) {
+			t.Fatalf("stored event = %+v, want %+v", g, e)
+		}
+	})
+
+	t.Run("FilterAndPage", func(t *testing.T) {
+		r := newRepo()
+		var ids []string
+		var mid time.Time
+		for i, e := range []domain.AuditEvent{
+			{Type: domain.AuditRegister, Outcome: domain.AuditSuccess, ActorID: "alice", SubjectID: "alice"},
+			{Type: domain.AuditLogin, Outcome: domain.AuditFailure, SubjectID: "alice"},
+			{Type: domain.AuditLogin, Outcome: domain.AuditSuccess, ActorID: "alice", SubjectID: "alice"},
+			{Type: domain.AuditLogin, Outcome: domain.AuditSuccess, ActorID: "bob", SubjectID: "bob"},
+		} {
+			if i == 2 {
+				mid = time.Now()
+				time.Sleep(2 * time.Millisecond)
+			}
+			e := e
+			if err := r.Append(ctx, &e); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			ids = append(ids, e.ID)
+			time.Sleep(2 * time.Millisecond) // ให้ CreatedAt ต่างกันแม้ backend เก็บแค่ระดับ ms
+		}
+
+		page1, total, err := r.List(ctx, domain.AuditFilter{}, 1, 3)
+		if err != nil || total != 4 || len(page1) != 3 || page1[0].ID != ids[3] || page1[2].ID != ids[1] {
+			t.Fatalf("page 1 = %v (total %d), %v; want newest first", auditIDs(page1), total, err)
+		}
+		page2, _, _ := r.List(ctx, domain.AuditFilter{}, 2, 3)
+		if len(page2) != 1 || page2[0].ID != ids[0] {
+			t.Fatalf("page 2 = %v, want [%s]", auditIDs(page2), ids[0])
+		}
+
+		for name, c := range map[string]struct {
+			f    domain.AuditFilter
+			want []string
+		}{
+			"Type":      {domain.AuditFilter{Type: domain.AuditLogin}, []string{ids[3], ids[2], ids[1]}},
+			"Outcome":   {domain.AuditFilter{Outcome: domain.AuditFailure}, []string{ids[1]}},
+			"Actor":     {domain.AuditFilter{ActorID: "alice"}, []string{ids[2], ids[0]}},
+			"Subject":   {domain.AuditFilter{SubjectID: "alice", Type: domain.AuditLogin}, []string{ids[2], ids[1]}},
+			"Since":     {domain.AuditFilter{Since: mid}, []string{ids[3], ids[2]}},
+			"Until":     {domain.AuditFilter{Until: mid}, []string{ids[1], ids[0]}},
+			"NoneMatch": {domain.AuditFilter{ActorID: "carol"}, nil},
+		} {
+			got, total, err := r.List(ctx, c.f, 1, 10)
+			if err != nil || int(total) != len(c.want) || !equalIDs(auditIDs(got), c.want) {
+				t.Errorf("%s: List = %v (total %d), %v; want %v", name, auditIDs(got), total, err, c.want)
+			}
+		}
+	})
+}
+
+func auditIDs(events []*domain.AuditEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
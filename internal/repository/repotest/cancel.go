@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+)
+
+// TestCancellation ตรวจว่า repository ส่ง context ต่อให้ storage จริง
+// เรียกด้วย context ที่ถูกยกเลิกแล้วต้องได้ error ที่ห่อ context.Canceled ไม่ใช่ผลลัพธ์ปกติ
+func TestCancellation(t *testing.T, b Backend) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exp := time.Now().Add(time.Hour)
+
+	check := func(t *testing.T, op string, err error) {
+		t.Helper()
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s with cancelled context = %v, want context.Canceled", op, err)
+		}
+	}
+
+	if b.Users != nil {
+		t.Run("Users", func(t *testing.T) {
+			r := b.Users()
+			u := mustCreateUser(t, r, "cancel@example.com")
+			check(t, "Create", r.Create(ctx, &domain.User{Email: "other@example.com"}))
+			_, err := r.FindByEmail(ctx, u.Email)
+			check(t, "FindByEmail", err)
+			_, err = r.FindByID(ctx, u.ID)
+			check(t, "FindByID", err)
+			_, _, err = r.FindAll(ctx, "", "", 1, 10)
+			check(t, "FindAll", err)
+			check(t, "Update", r.Update(ctx, u))
+			check(t, "SetMFA", r.SetMFA(ctx, u.ID, "SECRET", 1, nil))
+			_, err = r.ConsumeTOTPStep(ctx, u.ID, 1)
+			check(t, "ConsumeTOTPStep", err)
+			_, err = r.ConsumeRecoveryCode(ctx, u.ID, "code")
+			check(t, "ConsumeRecoveryCode", err)
+			check(t, "SoftDelete", r.SoftDelete(ctx, u.ID))
+		})
+	}
+	if b.Tokens != nil {
+		t.Run("Tokens", func(t *testing.T) {
+			r := b.Tokens()
+			check(t, "Blacklist", r.Blacklist(ctx, "tok", exp))
+			_, err := r.Claim(ctx, "claim", exp)
+			check(t, "Claim", err)
+			_, err = r.IsBlacklisted(ctx, "tok")
+			check(t, "IsBlacklisted", err)
+			check(t, "RevokeIssuedBefore", r.RevokeIssuedBefore(ctx, "user-1", time.Now(), exp))
+			_, err = r.RevokedBefore(ctx, "user-1")
+			check(t, "RevokedBefore", err)
+		})
+	}
+	if b.PasswordResets != nil {
+		t.Run("PasswordResets", func(t *testing.T) {
+			r := b.PasswordResets()
+			check(t, "Create", r.Create(ctx, "tok", "user-1", exp))
+			_, err := r.Consume(ctx, "tok")
+			check(t, "Consume", err)
+			check(t, "DeleteByUser", r.DeleteByUser(ctx, "user-1"))
+		})
+	}
+	if b.EmailVerifications != nil {
+		t.Run("EmailVerifications", func(t *testing.T) {
+			r := b.EmailVerifications()
+			check(t, "Create", r.Create(ctx, "tok", "user-1", "a@example.com", exp))
+			_, _, err := r.Verify(ctx, "tok")
+			check(t, "Verify", err)
+			check(t, "Delete", r.Delete(ctx, "tok"))
+		})
+	}
+	if b.RefreshTokens != nil {
+		t.Run("RefreshTokens", func(t *testing.T) {
+			r := b.RefreshTokens()
+			check(t, "Create", r.Create(ctx, &domain.RefreshToken{TokenHash: "h", UserID: "user-1", FamilyID: "f", ExpiresAt: exp}))
+			_, err := r.FindByHash(ctx, "h")
+			check(t, "FindByHash", err)
+			_, err = r.MarkRotated(ctx, "h")
+			check(t, "MarkRotated", err)
+			check(t, "RevokeFamily", r.RevokeFamily(ctx, "f"))
+		})
+	}
+	if b.WebAuthnCredentials != nil {
+		t.Run("WebAuthnCredentials", func(t *testing.T) {
+			r := b.WebAuthnCredentials()
+			check(t, "Create", r.Create(ctx, &domain.WebAuthnCredential{ID: "cred", UserID: "user-1", PublicKey: []byte{1}}))
+			_, err := r.FindByID(ctx, "cred")
+			check(t, "FindByID", err)
+			_, err = r.FindByUserID(ctx, "user-1")
+			check(t, "FindByUserID", err)
+			check(t, "UpdateSignCount", r.UpdateSignCount(ctx, "cred", 1, false, time.Now()))
+		})
+	}
+	if b.WebAuthnSessions != nil {
+		t.Run("WebAuthnSessions", func(t *testing.T) {
+			r := b.WebAuthnSessions()
+			check(t, "Create", r.Create(ctx, "s", []byte("data"), exp))
+			_, err := r.Consume(ctx, "s")
+			check(t, "Consume", err)
+		})
+	}
+	if b.Audit != nil {
+		t.Run("Audit", func(t *testing.T) {
+			r := b.Audit()
+			check(t, "Append", r.Append(ctx, &domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditSuccess}))
+			_, _, err := r.List(ctx, domain.AuditFilter{}, 1, 10)
+			check(t, "List", err)
+		})
+	}
+	if b.OAuthClients != nil {
+		t.Run("OAuthClients", func(t *testing.T) {
+			r := b.OAuthClients()
+			check(t, "Create", r.Create(ctx, &domain.OAuthClient{ID: "client-1", Name: "Example"}))
+			_, err := r.FindByID(ctx, "client-1")
+			check(t, "FindByID", err)
+		})
+	}
+	if b.AuthorizationCodes != nil {
+		t.Run("AuthorizationCodes", func(t *testing.T) {
+			r := b.AuthorizationCodes()
+			check(t, "Create", r.Create(ctx, &domain.AuthorizationCode{CodeHash: "h", ClientID: "client-1", UserID: "user-1", ExpiresAt: exp}))
+			_, err := r.Consume(ctx, "h", "client-1")
+			check(t, "Consume", err)
+		})
+	}
+	if b.Consents != nil {
+		t.Run("Consents", func(t *testing.T) {
+			r := b.Consents()
+			check(t, "Grant", r.Grant(ctx, "user-1", "client-1", []string{domain.ScopeProfile}))
+			_, err := r.Find(ctx, "user-1", "client-1")
+			check(t, "Find", err)
+		})
+	}
+	if b.MachineClients != nil {
+		t.Run("MachineClients", func(t *testing.T) {
+			r := b.MachineClients()
+			check(t, "Create", r.Create(ctx, &domain.MachineClient{ID: "machine-1", Name: "Job", SecretHash: "abc"}))
+			_, err := r.FindByID(ctx, "machine-1")
+			check(t, "FindByID", err)
+			check(t, "UpdateSecret", r.UpdateSecret(ctx, "machine-1", "def"))
+			check(t, "Disable", r.Disable(ctx, "machine-1"))
+		})
+	}
+}
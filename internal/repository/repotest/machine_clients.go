@@ -0,0 +1,67 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+// TestMachineClients ตรวจการสร้าง ค้นหา เปลี่ยน secret และปิด machine client
+func TestMachineClients(t *testing.T, newRepo func() repo.MachineClientRepository) {
+	ctx := context.Background()
+	r := newRepo()
+	before := time.Now().Add(-time.Second)
+	c := &domain.MachineClient{ID: "machine-1", Name: "Nightly job", SecretHash: "abc", Scopes: []string{"reports:read", "reports:write"}}
+	if err := r.Create(ctx, c); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if c.CreatedAt.Before(before) {
+		t.Fatalf("Create did not set CreatedAt: %v", c.CreatedAt)
+	}
+	if err := r.Create(ctx, &domain.MachineClient{ID: "machine-1", Name: "Other", SecretHash: "x"}); !errors.Is(err, repo.ErrDuplicateMachineClient) {
+		t.Fatalf("duplicate Create = %v, want ErrDuplicateMachineClient", err)
+	}
+
+	got, err := r.FindByID(ctx, "machine-1")
+	if err != nil || got.Name != c.Name || got.SecretHash != "abc" || !sameTime(got.CreatedAt, c.CreatedAt) ||
+		!equalIDs(got.Scopes, c.Scopes) || got.RotatedAt != nil || got.Disabled() {
+		t.Fatalf("FindByID = %+v, %v", got, err)
+	}
+	if _, err := r.FindByID(ctx, "missing"); !errors.Is(err, repo.ErrMachineClientNotFound) {
+		t.Fatalf("FindByID of unknown client = %v, want ErrMachineClientNotFound", err)
+	}
+
+	if err := r.UpdateSecret(ctx, "machine-1", "def"); err != nil {
+		t.Fatalf("UpdateSecret: %v", err)
+	}
+	got, _ = r.FindByID(ctx, "machine-1")
+	if got.SecretHash != "def" || got.RotatedAt == nil || got.RotatedAt.Before(before) {
+		t.Fatalf("after UpdateSecret = %+v", got)
+	}
+	if err := r.UpdateSecret(ctx, "missing", "x"); !errors.Is(err, repo.ErrMachineClientNotFound) {
+		t.Fatalf("UpdateSecret of unknown client = %v, want ErrMachineClientNotFound", err)
+	}
+
+	if err := r.Disable(ctx, "machine-1"); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	got, _ = r.FindByID(ctx, "machine-1")
+	if !got.Disabled() {
+		t.Fatalf("client not disabled: %+v", got)
+	}
+	first := *got.DisabledAt
+	time.Sleep(5 * time.Millisecond)
+	if err := r.Disable(ctx, "machine-1"); err != nil {
+		t.Fatalf("second Disable: %v", err)
+	}
+	if got, _ = r.FindByID(ctx, "machine-1"); !sameTime(*got.DisabledAt, first) {
+		t.Fatalf("second Disable moved DisabledAt from %v to %v", first, *got.DisabledAt)
+	}
+	if err := r.Disable(ctx, "missing"); !errors.Is(err, repo.ErrMachineClientNotFound) {
+		t.Fatalf("Disable of unknown client = %v, want ErrMachineClientNotFound", err)
+	}
+}
@@ -0,0 +1,129 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+// TestOAuthClients ตรวจการลงทะเบียนและค้นหา client
+func TestOAuthClients(t *testing.T, newRepo func() repo.OAuthClientRepository) {
+	ctx := context.Background()
+	r := newRepo()
+	before := time.Now().Add(-time.Second)
+	c := &domain.OAuthClient{
+		ID: "client-1", Name: "Example", SecretHash: "abc",
+		RedirectURIs: []string{"https://app.example.com/cb"}, Scopes: []string{domain.ScopeProfile},
+	}
+	if err := r.Create(ctx, c); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if c.CreatedAt.Before(before) {
+		t.Fatalf("Create did not set CreatedAt: %v", c.CreatedAt)
+	}
+	if err := r.Create(ctx, &domain.OAuthClient{ID: "client-1", Name: "Other"}); !errors.Is(err, repo.ErrDuplicateOAuthClient) {
+		t.Fatalf("duplicate Create = %v, want ErrDuplicateOAuthClient", err)
+	}
+
+	got, err := r.FindByID(ctx, "client-1")
+	if err != nil || got.Name != "Example" || got.SecretHash != "abc" || !sameTime(got.CreatedAt, c.CreatedAt) ||
+		!equalIDs(got.RedirectURIs, c.RedirectURIs) || !equalIDs(got.Scopes, c.Scopes) {
+		t.Fatalf("FindByID = %+v, %v", got, err)
+	}
+	if _, err := r.FindByID(ctx, "missing"); !errors.Is(err, repo.ErrOAuthClientNotFound) {
+		t.Fatalf("FindByID of unknown client = %v, want ErrOAuthClientNotFound", err)
+	}
+}
+
+// TestAuthorizationCodes ตรวจ authorization code: ใช้ได้ครั้งเดียว หมดอายุได้ และแลกพร้อมกันได้ครั้งเดียว
+func TestAuthorizationCodes(t *testing.T, newRepo func() repo.AuthorizationCodeRepository) {
+	ctx := context.Background()
+	newCode := func(hash string, ttl time.Duration) *domain.AuthorizationCode {
+		return &domain.AuthorizationCode{
+			CodeHash: hash, ClientID: "client-1", UserID: "user-1", RedirectURI: "https://app.example.com/cb",
+			Scopes: []string{domain.ScopeProfile}, CodeChallenge: "challenge", Nonce: "n-0S6_WzA2Mj", ExpiresAt: time.Now().Add(ttl),
+		}
+	}
+
+	t.Run("ConsumeOnce", func(t *testing.T) {
+		r := newRepo()
+		c := newCode("h1", time.Minute)
+		if err := r.Create(ctx, c); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		got, err := r.Consume(ctx, "h1", "client-1")
+		if err != nil || got.ClientID != c.ClientID || got.UserID != c.UserID || got.RedirectURI != c.RedirectURI ||
+			got.CodeChallenge != c.CodeChallenge || got.Nonce != c.Nonce || !equalIDs(got.Scopes, c.Scopes) || !sameTime(got.ExpiresAt, c.ExpiresAt) {
+			t.Fatalf("Consume = %+v, %v", got, err)
+		}
+		if _, err := r.Consume(ctx, "h1", "client-1"); !errors.Is(err, repo.ErrAuthorizationCodeNotFound) {
+			t.Fatalf("second Consume = %v, want ErrAuthorizationCodeNotFound", err)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		r := newRepo()
+		r.Create(ctx, newCode("old", -time.Second))
+		if _, err := r.Consume(ctx, "old", "client-1"); !errors.Is(err, repo.ErrAuthorizationCodeNotFound) {
+			t.Fatalf("Consume of expired code = %v, want ErrAuthorizationCodeNotFound", err)
+		}
+	})
+
+	t.Run("OtherClient", func(t *testing.T) {
+		r := newRepo()
+		r.Create(ctx, newCode("h2", time.Minute))
+		if _, err := r.Consume(ctx, "h2", "client-2"); !errors.Is(err, repo.ErrAuthorizationCodeNotFound) {
+			t.Fatalf("Consume by another client = %v, want ErrAuthorizationCodeNotFound", err)
+		}
+		// code ต้องยังอยู่ให้ client เจ้าของแลกได้
+		if _, err := r.Consume(ctx, "h2", "client-1"); err != nil {
+			t.Fatalf("Consume by the owning client after another client tried = %v", err)
+		}
+	})
+
+	t.Run("ConcurrentConsume", func(t *testing.T) {
+		r := newRepo()
+		r.Create(ctx, newCode("race", time.Minute))
+		n := concurrentSuccesses(10, func() bool {
+			_, err := r.Consume(ctx, "race", "client-1")
+			return err == nil
+		})
+		if n != 1 {
+			t.Fatalf("%d concurrent Consume calls succeeded, want 1", n)
+		}
+	})
+}
+
+// TestConsents ตรวจว่า Grant รวม scope กับที่เคยอนุญาตไว้ และแยกตามคู่ user/client
+func TestConsents(t *testing.T, newRepo func() repo.ConsentRepository) {
+	ctx := context.Background()
+	r := newRepo()
+	if _, err := r.Find(ctx, "user-1", "client-1"); !errors.Is(err, repo.ErrConsentNotFound) {
+		t.Fatalf("Find before Grant = %v, want ErrConsentNotFound", err)
+	}
+	if err := r.Grant(ctx, "user-1", "client-1", []string{"a"}); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	if err := r.Grant(ctx, "user-1", "client-1", []string{"a", "b"}); err != nil {
+		t.Fatalf("second Grant: %v", err)
+	}
+	r.Grant(ctx, "user-1", "client-2", []string{"c"})
+
+	got, err := r.Find(ctx, "user-1", "client-1")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	scopes := append([]string(nil), got.Scopes...)
+	sort.Strings(scopes)
+	if got.UserID != "user-1" || got.ClientID != "client-1" || !equalIDs(scopes, []string{"a", "b"}) || got.GrantedAt.IsZero() {
+		t.Fatalf("Find = %+v, want scopes [a b]", got)
+	}
+	if _, err := r.Find(ctx, "user-2", "client-1"); !errors.Is(err, repo.ErrConsentNotFound) {
+		t.Fatalf("Find for other user = %v, want ErrConsentNotFound", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package repotest คือชุดทดสอบกลาง (conformance suite) ที่ repository ทุก backend ต้องผ่าน
+// เพื่อให้ memory, Mongo และ backend อื่นๆ มีความหมายเดียวกัน
+//
+// ใช้จากไฟล์ _test.go ของ backend เช่น
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, repotest.Backend{
+//			Users:  memory.NewUserRepository,
+//			Tokens: memory.NewTokenRepository,
+//			...
+//		})
+//	}
+package repotest
+
+import (
+	"testing"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+)
+
+// Backend รวม constructor ของ repository แต่ละตัว ทุกครั้งที่เรียกต้องได้ storage ว่าง
+// constructor ที่เป็น nil จะถูกข้าม
+type Backend struct {
+	Users               func() repo.UserRepository
+	Tokens              func() repo.TokenRepository
+	PasswordResets      func() pr.PasswordResetRepository
+	EmailVerifications  func() ev.EmailVerificationRepository
+	RefreshTokens       func() repo.RefreshTokenRepository
+	WebAuthnCredentials func() repo.WebAuthnCredentialRepository
+	WebAuthnSessions    func() repo.WebAuthnSessionRepository
+	Audit               func() repo.AuditRepository
+	OAuthClients        func() repo.OAuthClientRepository
+	AuthorizationCodes  func() repo.AuthorizationCodeRepository
+	Consents            func() repo.ConsentRepository
+	MachineClients      func() repo.MachineClientRepository
+}
+
+// Run รันชุดทดสอบของทุก repository ใน backend
+func Run(t *testing.T, b Backend) {
+	if b.Users != nil {
+		t.Run("Users", func(t *testing.T) { TestUsers(t, b.Users) })
+	}
+	if b.Tokens != nil {
+		t.Run("Tokens", func(t *testing.T) { TestTokens(t, b.Tokens) })
+	}
+	if b.PasswordResets != nil {
+		t.Run("PasswordResets", func(t *testing.T) { TestPasswordResets(t, b.PasswordResets) })
+	}
+	if b.EmailVerifications != nil {
+		t.Run("EmailVerifications", func(t *testing.T) { TestEmailVerifications(t, b.EmailVerifications) })
+	}
+	if b.RefreshTokens != nil {
+		t.Run("RefreshTokens", func(t *testing.T) { TestRefreshTokens(t, b.RefreshTokens) })
+	}
+	if b.WebAuthnCredentials != nil {
+		t.Run("WebAuthnCredentials", func(t *testing.T) { TestWebAuthnCredentials(t, b.WebAuthnCredentials) })
+	}
+	if b.WebAuthnSessions != nil {
+		t.Run("WebAuthnSessions", func(t *testing.T) { TestWebAuthnSessions(t, b.WebAuthnSessions) })
+	}
+	if b.Audit != nil {
+		t.Run("Audit", func(t *testing.T) { TestAudit(t, b.Audit) })
+	}
+	if b.OAuthClients != nil {
+		t.Run("OAuthClients", func(t *testing.T) { TestOAuthClients(t, b.OAuthClients) })
+	}
+	if b.AuthorizationCodes != nil {
+		t.Run("AuthorizationCodes", func(t *testing.T) { TestAuthorizationCodes(t, b.AuthorizationCodes) })
+	}
+	if b.Consents != nil {
+		t.Run("Consents", func(t *testing.T) { TestConsents(t, b.Consents) })
+	}
+	if b.MachineClients != nil {
+		t.Run("MachineClients", func(t *testing.T) { TestMachineClients(t, b.MachineClients) })
+	}
+	t.Run("Cancellation", func(t *testing.T) { TestCancellation(t, b) })
+}
@@ -0,0 +1,308 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+)
+
+// TestTokens ตรวจ blacklist ตาม jti และ watermark ของ subject: ค่าที่หมดอายุแล้วต้องไม่ถูกนับ
+func TestTokens(t *testing.T, newRepo func() repo.TokenRepository) {
+	ctx := context.Background()
+	t.Run("Blacklist", func(t *testing.T) {
+		r := newRepo()
+		if err := r.Blacklist(ctx, "live", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Blacklist: %v", err)
+		}
+		if err := r.Blacklist(ctx, "expired", time.Now().Add(-time.Second)); err != nil {
+			t.Fatalf("Blacklist: %v", err)
+		}
+		// เพิกถอนซ้ำด้วยเวลาที่เก่ากว่าต้องไม่ทำให้ token กลับมาใช้ได้
+		if err := r.Blacklist(ctx, "live", time.Now().Add(-time.Second)); err != nil {
+			t.Fatalf("second Blacklist: %v", err)
+		}
+		for jti, want := range map[string]bool{"live": true, "expired": false, "unknown": false} {
+			got, err := r.IsBlacklisted(ctx, jti)
+			if err != nil {
+				t.Fatalf("IsBlacklisted(%s): %v", jti, err)
+			}
+			if got != want {
+				t.Errorf("IsBlacklisted(%s) = %v, want %v", jti, got, want)
+			}
+		}
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		r := newRepo()
+		exp := time.Now().Add(time.Hour)
+		if ok, err := r.Claim(ctx, "once", exp); err != nil || !ok {
+			t.Fatalf("first Claim = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.Claim(ctx, "once", exp); err != nil || ok {
+			t.Fatalf("second Claim = %v, %v; want false", ok, err)
+		}
+		// jti ที่ claim แล้วนับเป็น blacklist ด้วย และ jti ที่ blacklist แล้ว claim ไม่ได้
+		if got, _ := r.IsBlacklisted(ctx, "once"); !got {
+			t.Fatal("claimed jti is not blacklisted")
+		}
+		r.Blacklist(ctx, "revoked", exp)
+		if ok, _ := r.Claim(ctx, "revoked", exp); ok {
+			t.Fatal("Claim of a blacklisted jti succeeded")
+		}
+		if n := concurrentSuccesses(8, func() bool { ok, err := r.Claim(ctx, "race", exp); return ok && err == nil }); n != 1 {
+			t.Fatalf("%d concurrent claims succeeded, want 1", n)
+		}
+	})
+
+	t.Run("Watermark", func(t *testing.T) {
+		r := newRepo()
+		if got, err := r.RevokedBefore(ctx, "alice"); err != nil || !got.IsZero() {
+			t.Fatalf("RevokedBefore without watermark = %v, %v", got, err)
+		}
+		before := time.Now()
+		exp := before.Add(time.Hour)
+		if err := r.RevokeIssuedBefore(ctx, "alice", before, exp); err != nil {
+			t.Fatalf("RevokeIssuedBefore: %v", err)
+		}
+		if err := r.RevokeIssuedBefore(ctx, "alice", before.Add(-time.Minute), exp); err != nil {
+			t.Fatalf("second RevokeIssuedBefore: %v", err)
+		}
+		if got, err := r.RevokedBefore(ctx, "alice"); err != nil || !sameTime(got, before) {
+			t.Fatalf("RevokedBefore = %v, %v; want %v (older watermark must not win)", got, err, before)
+		}
+		if err := r.RevokeIssuedBefore(ctx, "bob", before, time.Now().Add(-time.Second)); err != nil {
+			t.Fatalf("RevokeIssuedBefore: %v", err)
+		}
+		if got, err := r.RevokedBefore(ctx, "bob"); err != nil || !got.IsZero() {
+			t.Fatalf("RevokedBefore of expired watermark = %v, %v", got, err)
+		}
+	})
+}
+
+// TestPasswordResets ตรวจ reset token: ใช้ได้ครั้งเดียว, หมดอายุ, ลบทั้งหมดของผู้ใช้
+func TestPasswordResets(t *testing.T, newRepo func() pr.PasswordResetRepository) {
+	ctx := context.Background()
+	t.Run("ConsumeOnce", func(t *testing.T) {
+		r := newRepo()
+		if err := r.Create(ctx, "tok-1", "user-1", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		userID, err := r.Consume(ctx, "tok-1")
+		if err != nil || userID != "user-1" {
+			t.Fatalf("Consume = %q, %v; want user-1", userID, err)
+		}
+		if _, err := r.Consume(ctx, "tok-1"); err == nil {
+			t.Fatal("second Consume succeeded")
+		}
+		if _, err := r.Consume(ctx, "unknown"); err == nil {
+			t.Fatal("Consume of unknown token succeeded")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		r := newRepo()
+		if err := r.Create(ctx, "old", "user-1", time.Now().Add(-time.Second)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := r.Consume(ctx, "old"); err == nil {
+			t.Fatal("Consume of expired token succeeded")
+		}
+	})
+
+	t.Run("DeleteByUser", func(t *testing.T) {
+		r := newRepo()
+		exp := time.Now().Add(time.Hour)
+		r.Create(ctx, "a1", "alice", exp)
+		r.Create(ctx, "a2", "alice", exp)
+		r.Create(ctx, "b1", "bob", exp)
+		if err := r.DeleteByUser(ctx, "alice"); err != nil {
+			t.Fatalf("DeleteByUser: %v", err)
+		}
+		for _, tok := range []string{"a1", "a2"} {
+			if _, err := r.Consume(ctx, tok); err == nil {
+				t.Fatalf("token %s survived DeleteByUser", tok)
+			}
+		}
+		if _, err := r.Consume(ctx, "b1"); err != nil {
+			t.Fatalf("another user's token was removed: %v", err)
+		}
+	})
+
+	t.Run("ConcurrentConsume", func(t *testing.T) {
+		r := newRepo()
+		r.Create(ctx, "race", "user-1", time.Now().Add(time.Hour))
+		if n := concurrentSuccesses(8, func() bool { _, err := r.Consume(ctx, "race"); return err == nil }); n != 1 {
+			t.Fatalf("%d concurrent consumes succeeded, want 1", n)
+		}
+	})
+}
+
+// TestEmailVerifications ตรวจ token ยืนยันอีเมล: Verify ไม่ลบ token, Delete ลบ, หมดอายุ
+func TestEmailVerifications(t *testing.T, newRepo func() ev.EmailVerificationRepository) {
+	ctx := context.Background()
+	r := newRepo()
+	if err := r.Create(ctx, "v1", "user-1", "a@example.com", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := r.Create(ctx, "old", "user-1", "a@example.com", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		userID, email, err := r.Verify(ctx, "v1")
+		if err != nil || userID != "user-1" || email != "a@example.com" {
+			t.Fatalf("Verify #%d = %q, %q, %v", i+1, userID, email, err)
+		}
+	}
+	if _, _, err := r.Verify(ctx, "old"); err == nil {
+		t.Fatal("Verify of expired token succeeded")
+	}
+	if err := r.Delete(ctx, "v1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := r.Verify(ctx, "v1"); err == nil {
+		t.Fatal("Verify after Delete succeeded")
+	}
+}
+
+// TestRefreshTokens ตรวจ rotation แบบ atomic และการเพิกถอนทั้ง family
+func TestRefreshTokens(t *testing.T, newRepo func() repo.RefreshTokenRepository) {
+	ctx := context.Background()
+	newToken := func(hash, family string) *domain.RefreshToken {
+		return &domain.RefreshToken{TokenHash: hash, UserID: "user-1", FamilyID: family, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("RotateOnce", func(t *testing.T) {
+		r := newRepo()
+		if err := r.Create(ctx, newToken("h1", "f1")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		got, err := r.FindByHash(ctx, "h1")
+		if err != nil || got.UserID != "user-1" || got.FamilyID != "f1" || got.RotatedAt != nil {
+			t.Fatalf("FindByHash = %+v, %v", got, err)
+		}
+		if ok, err := r.MarkRotated(ctx, "h1"); !ok || err != nil {
+			t.Fatalf("first MarkRotated = %v, %v", ok, err)
+		}
+		if ok, _ := r.MarkRotated(ctx, "h1"); ok {
+			t.Fatal("second MarkRotated succeeded")
+		}
+		got, _ = r.FindByHash(ctx, "h1")
+		if got.RotatedAt == nil {
+			t.Fatal("RotatedAt not recorded")
+		}
+		if _, err := r.FindByHash(ctx, "unknown"); err == nil {
+			t.Fatal("FindByHash of unknown hash succeeded")
+		}
+	})
+
+	t.Run("RevokeFamily", func(t *testing.T) {
+		r := newRepo()
+		r.Create(ctx, newToken("a", "fam"))
+		r.Create(ctx, newToken("b", "fam"))
+		r.Create(ctx, newToken("c", "other"))
+		if err := r.RevokeFamily(ctx, "fam"); err != nil {
+			t.Fatalf("RevokeFamily: %v", err)
+		}
+		for hash, revoked := range map[string]bool{"a": true, "b": true, "c": false} {
+			got, err := r.FindByHash(ctx, hash)
+			if err != nil {
+				t.Fatalf("FindByHash(%s): %v", hash, err)
+			}
+			if (got.RevokedAt != nil) != revoked {
+				t.Errorf("token %s revoked = %v, want %v", hash, got.RevokedAt != nil, revoked)
+			}
+		}
+		if ok, _ := r.MarkRotated(ctx, "a"); ok {
+			t.Fatal("MarkRotated succeeded on a revoked token")
+		}
+	})
+
+	t.Run("ConcurrentRotate", func(t *testing.T) {
+		r := newRepo()
+		r.Create(ctx, newToken("race", "f"))
+		if n := concurrentSuccesses(8, func() bool { ok, _ := r.MarkRotated(ctx, "race"); return ok }); n != 1 {
+			t.Fatalf("%d concurrent rotations succeeded, want 1", n)
+		}
+	})
+}
+
+// TestWebAuthnCredentials ตรวจการเก็บ passkey และ sign counter
+func TestWebAuthnCredentials(t *testing.T, newRepo func() repo.WebAuthnCredentialRepository) {
+	ctx := context.Background()
+	r := newRepo()
+	c := &domain.WebAuthnCredential{ID: "cred-1", UserID: "user-1", Name: "laptop", PublicKey: []byte{1, 2, 3}, SignCount: 1, CreatedAt: time.Now()}
+	if err := r.Create(ctx, c); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := r.Create(ctx, c); err == nil {
+		t.Fatal("duplicate credential ID accepted")
+	}
+	r.Create(ctx, &domain.WebAuthnCredential{ID: "cred-2", UserID: "user-2", PublicKey: []byte{4}, CreatedAt: time.Now()})
+
+	got, err := r.FindByID(ctx, "cred-1")
+	if err != nil || got.UserID != "user-1" || string(got.PublicKey) != string([]byte{1, 2, 3}) {
+		t.Fatalf("FindByID = %+v, %v", got, err)
+	}
+	list, err := r.FindByUserID(ctx, "user-1")
+	if err != nil || len(list) != 1 || list[0].ID != "cred-1" {
+		t.Fatalf("FindByUserID = %d credentials, %v", len(list), err)
+	}
+	if _, err := r.FindByID(ctx, "missing"); err == nil {
+		t.Fatal("FindByID of unknown credential succeeded")
+	}
+
+	usedAt := time.Now()
+	if err := r.UpdateSignCount(ctx, "cred-1", 7, true, usedAt); err != nil {
+		t.Fatalf("UpdateSignCount: %v", err)
+	}
+	got, _ = r.FindByID(ctx, "cred-1")
+	if got.SignCount != 7 || !got.BackupState || got.LastUsedAt == nil || !sameTime(*got.LastUsedAt, usedAt) {
+		t.Fatalf("after UpdateSignCount = %+v", got)
+	}
+}
+
+// TestWebAuthnSessions ตรวจ session ของ ceremony: ใช้ได้ครั้งเดียวและหมดอายุได้
+func TestWebAuthnSessions(t *testing.T, newRepo func() repo.WebAuthnSessionRepository) {
+	ctx := context.Background()
+	r := newRepo()
+	if err := r.Create(ctx, "s1", []byte("data"), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	data, err := r.Consume(ctx, "s1")
+	if err != nil || string(data) != "data" {
+		t.Fatalf("Consume = %q, %v", data, err)
+	}
+	if _, err := r.Consume(ctx, "s1"); err == nil {
+		t.Fatal("second Consume succeeded")
+	}
+	r.Create(ctx, "old", []byte("x"), time.Now().Add(-time.Second))
+	if _, err := r.Consume(ctx, "old"); err == nil {
+		t.Fatal("Consume of expired session succeeded")
+	}
+}
+
+// concurrentSuccesses เรียก fn พร้อมกัน n ครั้งแล้วนับจำนวนที่สำเร็จ
+func concurrentSuccesses(n int, fn func() bool) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	count := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if fn() {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return count
+}
@@ -0,0 +1,313 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+// TestUsers ตรวจ UserRepository: email ไม่ซ้ำ, soft delete, filter และ pagination
+func TestUsers(t *testing.T, newRepo func() repo.UserRepository) {
+	ctx := context.Background()
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepo()
+		u := &domain.User{Email: "alice@example.com", PasswordHash: "hash", Name: "Alice", Roles: []string{domain.RoleUser}}
+		before := time.Now().Add(-time.Second)
+		if err := r.Create(ctx, u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if u.ID == "" {
+			t.Fatal("Create did not assign an ID")
+		}
+		if u.CreatedAt.Before(before) {
+			t.Fatalf("Create did not set CreatedAt: %v", u.CreatedAt)
+		}
+
+		byID, err := r.FindByID(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		byEmail, err := r.FindByEmail(ctx, "alice@example.com")
+		if err != nil {
+			t.Fatalf("FindByEmail: %v", err)
+		}
+		for _, got := range []*domain.User{byID, byEmail} {
+			if got.ID != u.ID || got.Email != u.Email || got.PasswordHash != "hash" || got.Name != "Alice" {
+				t.Fatalf("stored user = %+v, want %+v", got, u)
+			}
+			if len(got.Roles) != 1 || got.Roles[0] != domain.RoleUser {
+				t.Fatalf("roles = %v", got.Roles)
+			}
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		r := newRepo()
+		mustCreateUser(t, r, "dup@example.com")
+		err := r.Create(ctx, &domain.User{Email: "dup@example.com"})
+		if !errors.Is(err, repo.ErrDuplicateEmail) {
+			t.Fatalf("second Create = %v, want ErrDuplicateEmail", err)
+		}
+	})
+
+	t.Run("ConcurrentDuplicateEmail", func(t *testing.T) {
+		r := newRepo()
+		n := concurrentSuccesses(8, func() bool { return r.Create(ctx, &domain.User{Email: "race@example.com"}) == nil })
+		if n != 1 {
+			t.Fatalf("%d concurrent creates succeeded, want 1", n)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo()
+		if _, err := r.FindByEmail(ctx, "nobody@example.com"); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Fatalf("FindByEmail = %v, want ErrUserNotFound", err)
+		}
+		_, err := r.FindByID(ctx, "000000000000000000000000")
+		if !errors.Is(err, repo.ErrUserNotFound) && !errors.Is(err, repo.ErrInvalidUserID) {
+			t.Fatalf("FindByID = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := newRepo()
+		u := mustCreateUser(t, r, "bob@example.com")
+		mustCreateUser(t, r, "taken@example.com")
+
+		now := time.Now()
+		u.Email = "robert@example.com"
+		u.Name = "Robert"
+		u.Roles = []string{domain.RoleUser, domain.RoleAdmin}
+		u.EmailVerified = true
+		u.EmailVerifiedAt = &now
+		u.TOTPPendingSecret = "PENDING"
+		if err := r.Update(ctx, u); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := r.FindByID(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.Email != "robert@example.com" || got.Name != "Robert" || !got.EmailVerified || got.TOTPPendingSecret != "PENDING" {
+			t.Fatalf("updated user = %+v", got)
+		}
+		if len(got.Roles) != 2 {
+			t.Fatalf("roles = %v", got.Roles)
+		}
+		if got.EmailVerifiedAt == nil || !sameTime(*got.EmailVerifiedAt, now) {
+			t.Fatalf("EmailVerifiedAt = %v, want %v", got.EmailVerifiedAt, now)
+		}
+		if _, err := r.FindByEmail(ctx, "bob@example.com"); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Fatalf("old email still found: %v", err)
+		}
+
+		u.Email = "taken@example.com"
+		if err := r.Update(ctx, u); !errors.Is(err, repo.ErrDuplicateEmail) {
+			t.Fatalf("Update to taken email = %v, want ErrDuplicateEmail", err)
+		}
+	})
+
+	t.Run("SetMFA", func(t *testing.T) {
+		r := newRepo()
+		u := mustCreateUser(t, r, "mfa@example.com")
+		u.TOTPPendingSecret = "PENDING"
+		if err := r.Update(ctx, u); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if err := r.SetMFA(ctx, u.ID, "SECRET", 42, []string{"a", "b"}); err != nil {
+			t.Fatalf("SetMFA: %v", err)
+		}
+		got, _ := r.FindByID(ctx, u.ID)
+		if !got.MFAEnabled || got.TOTPSecret != "SECRET" || got.TOTPPendingSecret != "" ||
+			got.TOTPLastStep != 42 || len(got.RecoveryCodes) != 2 {
+			t.Fatalf("after SetMFA: %+v", got)
+		}
+		if err := r.SetMFA(ctx, u.ID, "", 0, nil); err != nil {
+			t.Fatalf("SetMFA(disable): %v", err)
+		}
+		got, _ = r.FindByID(ctx, u.ID)
+		if got.MFAEnabled || got.TOTPSecret != "" || got.TOTPLastStep != 0 || len(got.RecoveryCodes) != 0 {
+			t.Fatalf("after disabling: %+v", got)
+		}
+		if err := r.SetMFA(ctx, "000000000000000000000000", "SECRET", 0, nil); !errors.Is(err, repo.ErrUserNotFound) && !errors.Is(err, repo.ErrInvalidUserID) {
+			t.Fatalf("SetMFA(unknown) = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("UpdateKeepsMFAState", func(t *testing.T) {
+		// Update จากข้อมูลที่อ่านไว้ก่อนใช้รหัส ต้องไม่คืนชีพ step หรือ recovery code ที่ใช้ไปแล้ว
+		r := newRepo()
+		u := mustCreateUser(t, r, "stale@example.com")
+		if err := r.SetMFA(ctx, u.ID, "SECRET", 10, []string{"a", "b"}); err != nil {
+			t.Fatalf("SetMFA: %v", err)
+		}
+		stale, _ := r.FindByID(ctx, u.ID)
+		if ok, _ := r.ConsumeTOTPStep(ctx, u.ID, 11); !ok {
+			t.Fatal("ConsumeTOTPStep failed")
+		}
+		if ok, _ := r.ConsumeRecoveryCode(ctx, u.ID, "a"); !ok {
+			t.Fatal("ConsumeRecoveryCode failed")
+		}
+		stale.Name = "Stale"
+		stale.MFAEnabled = false
+		if err := r.Update(ctx, stale); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, _ := r.FindByID(ctx, u.ID)
+		if got.Name != "Stale" || !got.MFAEnabled || got.TOTPSecret != "SECRET" || got.TOTPLastStep != 11 ||
+			len(got.RecoveryCodes) != 1 || got.RecoveryCodes[0] != "b" {
+			t.Fatalf("stale Update overwrote MFA state: %+v", got)
+		}
+	})
+
+	t.Run("ConsumeTOTPStep", func(t *testing.T) {
+		r := newRepo()
+		u := mustCreateUser(t, r, "totp@example.com")
+		if ok, err := r.ConsumeTOTPStep(ctx, u.ID, 100); err != nil || !ok {
+			t.Fatalf("first ConsumeTOTPStep = %v, %v; want true", ok, err)
+		}
+		for _, step := range []int64{100, 99} {
+			if ok, err := r.ConsumeTOTPStep(ctx, u.ID, step); err != nil || ok {
+				t.Fatalf("ConsumeTOTPStep(%d) after 100 = %v, %v; want false", step, ok, err)
+			}
+		}
+		if n := concurrentSuccesses(8, func() bool { ok, err := r.ConsumeTOTPStep(ctx, u.ID, 101); return ok && err == nil }); n != 1 {
+			t.Fatalf("%d concurrent uses of the same step succeeded, want 1", n)
+		}
+		if got, _ := r.FindByID(ctx, u.ID); got.TOTPLastStep != 101 {
+			t.Fatalf("TOTPLastStep = %d, want 101", got.TOTPLastStep)
+		}
+	})
+
+	t.Run("ConsumeRecoveryCode", func(t *testing.T) {
+		r := newRepo()
+		u := mustCreateUser(t, r, "recovery@example.com")
+		if err := r.SetMFA(ctx, u.ID, "SECRET", 0, []string{"a", "b", "c"}); err != nil {
+			t.Fatalf("SetMFA: %v", err)
+		}
+		if ok, err := r.ConsumeRecoveryCode(ctx, u.ID, "missing"); err != nil || ok {
+			t.Fatalf("ConsumeRecoveryCode(unknown) = %v, %v; want false", ok, err)
+		}
+		if n := concurrentSuccesses(8, func() bool { ok, err := r.ConsumeRecoveryCode(ctx, u.ID, "b"); return ok && err == nil }); n != 1 {
+			t.Fatalf("%d concurrent uses of the same code succeeded, want 1", n)
+		}
+		got, _ := r.FindByID(ctx, u.ID)
+		if len(got.RecoveryCodes) != 2 || got.RecoveryCodes[0] != "a" || got.RecoveryCodes[1] != "c" {
+			t.Fatalf("recovery codes = %v, want [a c]", got.RecoveryCodes)
+		}
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		r := newRepo()
+		u := mustCreateUser(t, r, "copy@example.com")
+		got, _ := r.FindByID(ctx, u.ID)
+		got.Email = "changed@example.com"
+		got.Roles = append(got.Roles, domain.RoleAdmin)
+		again, _ := r.FindByID(ctx, u.ID)
+		if again.Email != "copy@example.com" || len(again.Roles) != 0 {
+			t.Fatalf("mutating a returned user changed the store: %+v", again)
+		}
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		r := newRepo()
+		u := mustCreateUser(t, r, "gone@example.com")
+		if err := r.SoftDelete(ctx, u.ID); err != nil {
+			t.Fatalf("SoftDelete: %v", err)
+		}
+		if _, err := r.FindByID(ctx, u.ID); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Fatalf("FindByID after delete = %v, want ErrUserNotFound", err)
+		}
+		if _, err := r.FindByEmail(ctx, "gone@example.com"); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Fatalf("FindByEmail after delete = %v, want ErrUserNotFound", err)
+		}
+		users, total, err := r.FindAll(ctx, "", "", 1, 10)
+		if err != nil || total != 0 || len(users) != 0 {
+			t.Fatalf("FindAll after delete = %d users, total %d, err %v", len(users), total, err)
+		}
+		if err := r.SoftDelete(ctx, u.ID); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Fatalf("second SoftDelete = %v, want ErrUserNotFound", err)
+		}
+		if err := r.Update(ctx, u); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Fatalf("Update after delete = %v, want ErrUserNotFound", err)
+		}
+		// email ของบัญชีที่ถูกลบยังจองไว้
+		if err := r.Create(ctx, &domain.User{Email: "gone@example.com"}); !errors.Is(err, repo.ErrDuplicateEmail) {
+			t.Fatalf("re-register deleted email = %v, want ErrDuplicateEmail", err)
+		}
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		r := newRepo()
+		var ids []string
+		for i := 0; i < 5; i++ {
+			u := &domain.User{Email: fmt.Sprintf("user%d@example.com", i), Name: fmt.Sprintf("User %d", i)}
+			if i == 4 {
+				u.Email, u.Name = "carol@other.org", "Carol"
+			}
+			if err := r.Create(ctx, u); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			ids = append(ids, u.ID)
+			time.Sleep(2 * time.Millisecond) // ให้ CreatedAt ต่างกันแม้ backend เก็บแค่ระดับ ms
+		}
+
+		page1, total, err := r.FindAll(ctx, "", "", 1, 2)
+		if err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+		if total != 5 || len(page1) != 2 || page1[0].ID != ids[0] || page1[1].ID != ids[1] {
+			t.Fatalf("page 1 = %v (total %d), want first two users in creation order", userIDs(page1), total)
+		}
+		page3, _, _ := r.FindAll(ctx, "", "", 3, 2)
+		if len(page3) != 1 || page3[0].ID != ids[4] {
+			t.Fatalf("page 3 = %v, want [%s]", userIDs(page3), ids[4])
+		}
+		beyond, total, _ := r.FindAll(ctx, "", "", 9, 2)
+		if len(beyond) != 0 || total != 5 {
+			t.Fatalf("page past the end = %v (total %d)", userIDs(beyond), total)
+		}
+
+		byEmail, total, _ := r.FindAll(ctx, "", "EXAMPLE\\.com$", 1, 10)
+		if total != 4 || len(byEmail) != 4 {
+			t.Fatalf("email filter matched %d (total %d), want 4", len(byEmail), total)
+		}
+		byName, total, _ := r.FindAll(ctx, "^carol", "", 1, 10)
+		if total != 1 || len(byName) != 1 || byName[0].ID != ids[4] {
+			t.Fatalf("name filter = %v (total %d), want [%s]", userIDs(byName), total, ids[4])
+		}
+		both, total, _ := r.FindAll(ctx, "user", "other", 1, 10)
+		if total != 0 || len(both) != 0 {
+			t.Fatalf("combined filter = %v, want none", userIDs(both))
+		}
+	})
+}
+
+func mustCreateUser(t *testing.T, r repo.UserRepository, email string) *domain.User {
+	ctx := context.Background()
+	t.Helper()
+	u := &domain.User{Email: email, PasswordHash: "hash"}
+	if err := r.Create(ctx, u); err != nil {
+		t.Fatalf("Create(%s): %v", email, err)
+	}
+	return u
+}
+
+func userIDs(users []*domain.User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+// sameTime เทียบเวลาโดยยอมให้ backend เก็บความละเอียดได้แค่ระดับ ms
+func sameTime(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d < time.Millisecond && d > -time.Millisecond
+}
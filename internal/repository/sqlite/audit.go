@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+)
+
+const auditColumns = `id, type, outcome, actor_id, subject_id, email, ip, user_agent, reason, created_at`
+
+type auditRepo struct {
+	db *sql.DB
+}
+
+// NewAuditRepository สร้าง AuditRepository บน SQLite
+func NewAuditRepository(db *sql.DB) repo.AuditRepository {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) Append(ctx context.Context, e *domain.AuditEvent) error {
+	id, now := uuid.NewString(), time.Now()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO audit_events (`+auditColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, e.Type, e.Outcome, e.ActorID, e.SubjectID, e.Email, e.IP, e.UserAgent, e.Reason, toMillis(now))
+	if err != nil {
+		return err
+	}
+	e.ID, e.CreatedAt = id, now
+	return nil
+}
+
+func (r *auditRepo) List(ctx context.Context, f domain.AuditFilter, page, size int) ([]*domain.AuditEvent, int64, error) {
+	const where = ` FROM audit_events WHERE (?1 = '' OR type = ?1)
+		AND (?2 = '' OR outcome = ?2)
+		AND (?3 = '' OR actor_id = ?3)
+		AND (?4 = '' OR subject_id = ?4)
+		AND (?5 IS NULL OR created_at >= ?5)
+		AND (?6 IS NULL OR created_at < ?6)`
+	args := []interface{}{f.Type, f.Outcome, f.ActorID, f.SubjectID, optionalMillis(f.Since), optionalMillis(f.Until)}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT count(*)`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	limit, offset := int64(-1), int64(0)
+	if size > 0 {
+		limit = int64(size)
+		offset = int64(page-1) * limit
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+auditColumns+where+`
+		ORDER BY created_at DESC, id DESC LIMIT ?7 OFFSET ?8`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []*domain.AuditEvent
+	for rows.Next() {
+		var e domain.AuditEvent
+		var created int64
+		if err := rows.Scan(&e.ID, &e.Type, &e.Outcome, &e.ActorID, &e.SubjectID, &e.Email, &e.IP, &e.UserAgent, &e.Reason, &created); err != nil {
+			return nil, 0, err
+		}
+		e.CreatedAt = fromMillis(created)
+		events = append(events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// optionalMillis แปลง zero time เป็น NULL (ไม่กรอง)
+func optionalMillis(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: toMillis(t), Valid: true}
+}
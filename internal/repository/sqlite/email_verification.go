@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+)
+
+type emailVerificationRepo struct {
+	db *sql.DB
+}
+
+// NewEmailVerificationRepository สร้าง EmailVerificationRepository บน SQLite
+func NewEmailVerificationRepository(db *sql.DB) ev.EmailVerificationRepository {
+	return &emailVerificationRepo{db: db}
+}
+
+func (r *emailVerificationRepo) Create(ctx context.Context, token, userID, email string, expiresAt time.Time) error {
+	if err := sweepExpired(ctx, r.db, "email_verifications", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO email_verifications (token_hash, user_id, email, expires_at) VALUES (?, ?, ?, ?)`,
+		ev.HashToken(token), userID, email, toMillis(expiresAt))
+	if isUniqueViolation(err) {
+		return errors.New("verification token already exists")
+	}
+	return err
+}
+
+func (r *emailVerificationRepo) Verify(ctx context.Context, token string) (string, string, error) {
+	var userID, email string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT user_id, email FROM email_verifications WHERE token_hash = ? AND expires_at > ?`,
+		ev.HashToken(token), toMillis(time.Now())).Scan(&userID, &email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", errors.New("verification token not found")
+	}
+	return userID, email, err
+}
+
+func (r *emailVerificationRepo) Delete(ctx context.Context, token string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM email_verifications WHERE token_hash = ?`, ev.HashToken(token))
+	return err
+}
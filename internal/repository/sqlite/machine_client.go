@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type machineClientRepo struct {
+	db *sql.DB
+}
+
+// NewMachineClientRepository สร้าง MachineClientRepository บน SQLite
+func NewMachineClientRepository(db *sql.DB) repo.MachineClientRepository {
+	return &machineClientRepo{db: db}
+}
+
+func (r *machineClientRepo) Create(ctx context.Context, c *domain.MachineClient) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO machine_clients
+		(id, name, secret_hash, scopes, created_at, rotated_at, disabled_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, c.Name, c.SecretHash, encodeList(c.Scopes), toMillis(now), nullMillis(c.RotatedAt), nullMillis(c.DisabledAt))
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateMachineClient
+	}
+	if err != nil {
+		return err
+	}
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *machineClientRepo) FindByID(ctx context.Context, id string) (*domain.MachineClient, error) {
+	var (
+		c                     domain.MachineClient
+		scopes                string
+		createdAt             int64
+		rotatedAt, disabledAt sql.NullInt64
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, secret_hash, scopes, created_at, rotated_at, disabled_at
+		FROM machine_clients WHERE id = ?`, id).
+		Scan(&c.ID, &c.Name, &c.SecretHash, &scopes, &createdAt, &rotatedAt, &disabledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrMachineClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Scopes, err = decodeList(scopes); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = fromMillis(createdAt)
+	c.RotatedAt = timePtr(rotatedAt)
+	c.DisabledAt = timePtr(disabledAt)
+	return &c, nil
+}
+
+func (r *machineClientRepo) UpdateSecret(ctx context.Context, id, secretHash string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE machine_clients SET secret_hash = ?, rotated_at = ? WHERE id = ?`,
+		secretHash, toMillis(time.Now()), id)
+	if err != nil {
+		return err
+	}
+	return mustAffect(res, repo.ErrMachineClientNotFound)
+}
+
+func (r *machineClientRepo) Disable(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE machine_clients SET disabled_at = COALESCE(disabled_at, ?) WHERE id = ?`,
+		toMillis(time.Now()), id)
+	if err != nil {
+		return err
+	}
+	return mustAffect(res, repo.ErrMachineClientNotFound)
+}
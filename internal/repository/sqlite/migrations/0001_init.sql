@@ -0,0 +1,78 @@
+-- schema เริ่มต้น: ตารางเดียวกับ backend Postgres
+-- เวลาเก็บเป็น unix milliseconds (INTEGER) เพื่อให้เทียบและเรียงได้ตรงๆ
+-- list (roles, recovery codes, transports) เก็บเป็น JSON array
+
+CREATE TABLE users (
+    id                  TEXT PRIMARY KEY,
+    email               TEXT NOT NULL UNIQUE, -- รวมบัญชีที่ถูก soft delete ด้วย
+    password_hash       TEXT NOT NULL DEFAULT '',
+    name                TEXT NOT NULL DEFAULT '',
+    roles               TEXT NOT NULL DEFAULT '[]',
+    email_verified      INTEGER NOT NULL DEFAULT 0,
+    email_verified_at   INTEGER,
+    mfa_enabled         INTEGER NOT NULL DEFAULT 0,
+    totp_secret         TEXT NOT NULL DEFAULT '',
+    totp_pending_secret TEXT NOT NULL DEFAULT '',
+    totp_last_step      INTEGER NOT NULL DEFAULT 0,
+    recovery_codes      TEXT NOT NULL DEFAULT '[]',
+    created_at          INTEGER NOT NULL,
+    deleted_at          INTEGER
+);
+CREATE INDEX users_created_at_idx ON users (created_at, id) WHERE deleted_at IS NULL;
+
+CREATE TABLE invalidated_tokens (
+    token      TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX invalidated_tokens_expires_at_idx ON invalidated_tokens (expires_at);
+
+CREATE TABLE password_resets (
+    token_hash TEXT PRIMARY KEY, -- SHA-256 (hex) ของ token
+    user_id    TEXT NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
+CREATE INDEX password_resets_expires_at_idx ON password_resets (expires_at);
+
+CREATE TABLE email_verifications (
+    token      TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX email_verifications_expires_at_idx ON email_verifications (expires_at);
+
+CREATE TABLE refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    family_id  TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    rotated_at INTEGER,
+    revoked_at INTEGER
+);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
+
+CREATE TABLE webauthn_credentials (
+    id               TEXT PRIMARY KEY, -- credential ID (base64url)
+    user_id          TEXT NOT NULL,
+    name             TEXT NOT NULL DEFAULT '',
+    public_key       BLOB NOT NULL,
+    attestation_type TEXT NOT NULL DEFAULT '',
+    transports       TEXT NOT NULL DEFAULT '[]',
+    aaguid           BLOB,
+    sign_count       INTEGER NOT NULL DEFAULT 0,
+    backup_eligible  INTEGER NOT NULL DEFAULT 0,
+    backup_state     INTEGER NOT NULL DEFAULT 0,
+    created_at       INTEGER NOT NULL,
+    last_used_at     INTEGER
+);
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE webauthn_sessions (
+    id         TEXT PRIMARY KEY,
+    data       BLOB NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX webauthn_sessions_expires_at_idx ON webauthn_sessions (expires_at);
@@ -0,0 +1,17 @@
+-- audit log: เขียนต่อท้ายอย่างเดียว (created_at เป็น unix ms เหมือนตารางอื่น)
+
+CREATE TABLE audit_events (
+    id         TEXT PRIMARY KEY,
+    type       TEXT NOT NULL,
+    outcome    TEXT NOT NULL,
+    actor_id   TEXT NOT NULL DEFAULT '',
+    subject_id TEXT NOT NULL DEFAULT '',
+    email      TEXT NOT NULL DEFAULT '',
+    ip         TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at DESC, id DESC);
+CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id, created_at DESC);
+CREATE INDEX audit_events_subject_id_idx ON audit_events (subject_id, created_at DESC);
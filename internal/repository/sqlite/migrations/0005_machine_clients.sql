@@ -0,0 +1,12 @@
+-- client ของ client credentials grant (service-to-service)
+-- (scopes เป็น JSON array, เวลาเป็น unix ms เหมือนตารางอื่น)
+
+CREATE TABLE machine_clients (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    secret_hash TEXT NOT NULL, -- SHA-256 (hex) ของ client secret
+    scopes      TEXT NOT NULL DEFAULT '[]',
+    created_at  INTEGER NOT NULL,
+    rotated_at  INTEGER,
+    disabled_at INTEGER
+);
@@ -0,0 +1,5 @@
+-- token ยืนยันอีเมลเก็บเป็น SHA-256 (hex) แบบเดียวกับ reset token
+-- SQLite ไม่มีฟังก์ชัน sha256 จึงลบแถวเดิมทิ้ง ผู้ใช้ขอลิงก์ใหม่ได้ด้วย SendVerificationEmail
+
+DELETE FROM email_verifications;
+ALTER TABLE email_verifications RENAME COLUMN token TO token_hash;
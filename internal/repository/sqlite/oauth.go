@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type oauthClientRepo struct {
+	db *sql.DB
+}
+
+// NewOAuthClientRepository สร้าง OAuthClientRepository บน SQLite
+func NewOAuthClientRepository(db *sql.DB) repo.OAuthClientRepository {
+	return &oauthClientRepo{db: db}
+}
+
+func (r *oauthClientRepo) Create(ctx context.Context, c *domain.OAuthClient) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		c.ID, c.Name, c.SecretHash, encodeList(c.RedirectURIs), encodeList(c.Scopes), toMillis(now))
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateOAuthClient
+	}
+	if err != nil {
+		return err
+	}
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *oauthClientRepo) FindByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	var (
+		c                    domain.OAuthClient
+		redirectURIs, scopes string
+		createdAt            int64
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, secret_hash, redirect_uris, scopes, created_at
+		FROM oauth_clients WHERE id = ?`, id).
+		Scan(&c.ID, &c.Name, &c.SecretHash, &redirectURIs, &scopes, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.RedirectURIs, err = decodeList(redirectURIs); err != nil {
+		return nil, err
+	}
+	if c.Scopes, err = decodeList(scopes); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = fromMillis(createdAt)
+	return &c, nil
+}
+
+type authorizationCodeRepo struct {
+	db *sql.DB
+}
+
+// NewAuthorizationCodeRepository สร้าง AuthorizationCodeRepository บน SQLite
+func NewAuthorizationCodeRepository(db *sql.DB) repo.AuthorizationCodeRepository {
+	return &authorizationCodeRepo{db: db}
+}
+
+func (r *authorizationCodeRepo) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	if err := sweepExpired(ctx, r.db, "oauth_authorization_codes", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO oauth_authorization_codes
+		(code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.CodeHash, c.ClientID, c.UserID, c.RedirectURI, encodeList(c.Scopes), c.CodeChallenge, c.Nonce, toMillis(c.ExpiresAt))
+	return err
+}
+
+func (r *authorizationCodeRepo) Consume(ctx context.Context, codeHash, clientID string) (*domain.AuthorizationCode, error) {
+	// DELETE ... RETURNING ทำให้ตรวจและลบในคำสั่งเดียว
+	var (
+		c         domain.AuthorizationCode
+		scopes    string
+		expiresAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `DELETE FROM oauth_authorization_codes
+		WHERE code_hash = ? AND client_id = ? AND expires_at > ?
+		RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at`,
+		codeHash, clientID, toMillis(time.Now())).
+		Scan(&c.CodeHash, &c.ClientID, &c.UserID, &c.RedirectURI, &scopes, &c.CodeChallenge, &c.Nonce, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrAuthorizationCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Scopes, err = decodeList(scopes); err != nil {
+		return nil, err
+	}
+	c.ExpiresAt = fromMillis(expiresAt)
+	return &c, nil
+}
+
+type consentRepo struct {
+	db *sql.DB
+}
+
+// NewConsentRepository สร้าง ConsentRepository บน SQLite
+func NewConsentRepository(db *sql.DB) repo.ConsentRepository {
+	return &consentRepo{db: db}
+}
+
+func (r *consentRepo) Grant(ctx context.Context, userID, clientID string, scopes []string) error {
+	// _txlock=immediate ทำให้ BEGIN จอง write lock ทันที การอ่าน-รวม-เขียนจึงไม่ทับกัน
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c := domain.Consent{Scopes: []string{}}
+	var stored string
+	err = tx.QueryRowContext(ctx, `SELECT scopes FROM oauth_consents WHERE user_id = ? AND client_id = ?`,
+		userID, clientID).Scan(&stored)
+	switch {
+	case err == nil:
+		if c.Scopes, err = decodeList(stored); err != nil {
+			return err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+	for _, s := range scopes {
+		if !c.Covers([]string{s}) {
+			c.Scopes = append(c.Scopes, s)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO oauth_consents (user_id, client_id, scopes, granted_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = excluded.scopes, granted_at = excluded.granted_at`,
+		userID, clientID, encodeList(c.Scopes), toMillis(time.Now())); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *consentRepo) Find(ctx context.Context, userID, clientID string) (*domain.Consent, error) {
+	var (
+		c         domain.Consent
+		scopes    string
+		grantedAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT user_id, client_id, scopes, granted_at
+		FROM oauth_consents WHERE user_id = ? AND client_id = ?`, userID, clientID).
+		Scan(&c.UserID, &c.ClientID, &scopes, &grantedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrConsentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Scopes, err = decodeList(scopes); err != nil {
+		return nil, err
+	}
+	c.GrantedAt = fromMillis(grantedAt)
+	return &c, nil
+}
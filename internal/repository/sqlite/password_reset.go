@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+)
+
+type passwordResetRepo struct {
+	db *sql.DB
+}
+
+// NewPasswordResetRepository สร้าง PasswordResetRepository บน SQLite
+func NewPasswordResetRepository(db *sql.DB) pr.PasswordResetRepository {
+	return &passwordResetRepo{db: db}
+}
+
+func (r *passwordResetRepo) Create(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	if err := sweepExpired(ctx, r.db, "password_resets", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES (?, ?, ?)`,
+		pr.HashToken(token), userID, toMillis(expiresAt))
+	if isUniqueViolation(err) {
+		return errors.New("reset token already exists")
+	}
+	return err
+}
+
+func (r *passwordResetRepo) Consume(ctx context.Context, token string) (string, error) {
+	// DELETE ... RETURNING ทำให้ตรวจและลบในคำสั่งเดียว
+	var userID string
+	err := r.db.QueryRowContext(ctx,
+		`DELETE FROM password_resets WHERE token_hash = ? AND expires_at > ? RETURNING user_id`,
+		pr.HashToken(token), toMillis(time.Now())).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New("reset token not found")
+	}
+	return userID, err
+}
+
+func (r *passwordResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = ?`, userID)
+	return err
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type refreshTokenRepo struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenRepository สร้าง RefreshTokenRepository บน SQLite
+func NewRefreshTokenRepository(db *sql.DB) repo.RefreshTokenRepository {
+	return &refreshTokenRepo{db: db}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	now := time.Now()
+	if err := sweepExpired(ctx, r.db, "refresh_tokens", now); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO refresh_tokens
+		(token_hash, user_id, family_id, created_at, expires_at, rotated_at, revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.TokenHash, t.UserID, t.FamilyID, toMillis(now), toMillis(t.ExpiresAt),
+		nullMillis(t.RotatedAt), nullMillis(t.RevokedAt))
+	if isUniqueViolation(err) {
+		return errors.New("refresh token already exists")
+	}
+	if err != nil {
+		return err
+	}
+	t.CreatedAt = now
+	return nil
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var (
+		t                    domain.RefreshToken
+		createdAt, expiresAt int64
+		rotatedAt, revokedAt sql.NullInt64
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT
+		token_hash, user_id, family_id, created_at, expires_at, rotated_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = ?`, hash).
+		Scan(&t.TokenHash, &t.UserID, &t.FamilyID, &createdAt, &expiresAt, &rotatedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("refresh token not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.CreatedAt = fromMillis(createdAt)
+	t.ExpiresAt = fromMillis(expiresAt)
+	t.RotatedAt = timePtr(rotatedAt)
+	t.RevokedAt = timePtr(revokedAt)
+	return &t, nil
+}
+
+func (r *refreshTokenRepo) MarkRotated(ctx context.Context, hash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE refresh_tokens SET rotated_at = ?
+		WHERE token_hash = ? AND rotated_at IS NULL AND revoked_at IS NULL`, toMillis(time.Now()), hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ?
+		WHERE family_id = ? AND revoked_at IS NULL`, toMillis(time.Now()), familyID)
+	return err
+}
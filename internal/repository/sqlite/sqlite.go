@@ -0,0 +1,181 @@
+// Package sqlite มี repository ทุกตัวบน SQLite แบบฝังในโปรเซส (pure Go ไม่ต้องใช้ cgo)
+// สำหรับ edge install และเครื่อง dev ที่ไม่อยากรัน MongoDB
+// ความหมายเหมือน implementation ของ Mongo และผ่าน repotest ชุดเดียวกัน
+//
+// SQLite ไม่มี TTL index จึงลบแถวที่หมดอายุตอนเขียนแถวใหม่ของตารางนั้น
+// และทุก query กรองแถวที่หมดอายุเองเสมอ
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sqlitedriver "github.com/glebarez/go-sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+func init() {
+	// SQLite ไม่มี REGEXP ในตัว: "x REGEXP y" จะเรียก regexp(y, x)
+	sqlitedriver.MustRegisterDeterministicScalarFunction("regexp", 2, regexpFunc)
+}
+
+// Open เปิด (หรือสร้าง) ไฟล์ฐานข้อมูลที่ path ในโหมด WAL แล้วรัน migration ที่ยังไม่เคยรัน
+// path ต้องเป็นไฟล์ ไม่ใช่ :memory: เพราะแต่ละ connection ใน pool จะได้ฐานข้อมูลคนละก้อน
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := path + "?_pragma=journal_mode(WAL)" +
+		"&_pragma=busy_timeout(5000)" + // รอ lock แทนที่จะคืน SQLITE_BUSY ทันที
+		"&_pragma=synchronous(NORMAL)" +
+		"&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite open error: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite open error: %w", err)
+	}
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate รันไฟล์ใน migrations/ ตามลำดับชื่อ ไฟล์ละหนึ่ง transaction
+// และจดไฟล์ที่รันแล้วไว้ในตาราง schema_migrations
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		if err := applyMigration(ctx, db, name, version); err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, name, version string) error {
+	body, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	// _txlock=immediate ทำให้ BEGIN จอง write lock ทันที โปรเซสอื่นจึง migrate ซ้อนไม่ได้
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)", version).Scan(&applied)
+	if err != nil || applied {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)",
+		version, toMillis(time.Now())); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// sweepExpired ลบแถวที่หมดอายุแล้วออกจากตาราง (แทน TTL index ของ Mongo)
+func sweepExpired(ctx context.Context, db *sql.DB, table string, now time.Time) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM "+table+" WHERE expires_at <= ?", toMillis(now))
+	return err
+}
+
+// isUniqueViolation บอกว่า error มาจาก UNIQUE / PRIMARY KEY constraint หรือไม่
+func isUniqueViolation(err error) bool {
+	var sqlErr *sqlitedriver.Error
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+	return sqlErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqlErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// regexCache เก็บ regex ที่ compile แล้ว เพราะ regexp() ถูกเรียกทุกแถว
+var regexCache sync.Map // pattern -> *regexp.Regexp
+
+// regexpFunc ไม่สนตัวพิมพ์เล็กใหญ่ เหมือน $options: "i" ของ Mongo
+func regexpFunc(_ *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+	pattern, _ := args[0].(string)
+	value, _ := args[1].(string)
+	re, ok := regexCache.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, err
+		}
+		re, _ = regexCache.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(value), nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms)
+}
+
+// nullMillis แปลงเวลาที่เป็น optional ให้เป็น NULL หรือ unix ms
+func nullMillis(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: toMillis(*t), Valid: true}
+}
+
+func timePtr(ms sql.NullInt64) *time.Time {
+	if !ms.Valid {
+		return nil
+	}
+	t := fromMillis(ms.Int64)
+	return &t
+}
+
+// encodeList / decodeList เก็บ []string เป็น JSON array
+func encodeList(s []string) string {
+	if s == nil {
+		s = []string{}
+	}
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func decodeList(s string) ([]string, error) {
+	var out []string
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
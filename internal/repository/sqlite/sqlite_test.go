@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
+	pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
+	"github.com/LengLKR/auth-microservice/internal/repository/repotest"
+)
+
+// openTemp เปิดฐานข้อมูลใหม่ในไฟล์ชั่วคราว (migration รันแล้ว) ปิดเองตอนจบ test
+func openTemp(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestConformance(t *testing.T) {
+	// fresh ให้ constructor แต่ละครั้งได้ไฟล์ฐานข้อมูลว่างของตัวเอง
+	n := 0
+	fresh := func() *sql.DB {
+		n++
+		return openTemp(t, "repotest-"+strconv.Itoa(n))
+	}
+
+	repotest.Run(t, repotest.Backend{
+		Users:               func() repo.UserRepository { return NewUserRepository(fresh()) },
+		Tokens:              func() repo.TokenRepository { return NewTokenRepository(fresh()) },
+		PasswordResets:      func() pr.PasswordResetRepository { return NewPasswordResetRepository(fresh()) },
+		EmailVerifications:  func() ev.EmailVerificationRepository { return NewEmailVerificationRepository(fresh()) },
+		RefreshTokens:       func() repo.RefreshTokenRepository { return NewRefreshTokenRepository(fresh()) },
+		WebAuthnCredentials: func() repo.WebAuthnCredentialRepository { return NewWebAuthnCredentialRepository(fresh()) },
+		WebAuthnSessions:    func() repo.WebAuthnSessionRepository { return NewWebAuthnSessionRepository(fresh()) },
+		Audit:               func() repo.AuditRepository { return NewAuditRepository(fresh()) },
+		OAuthClients:        func() repo.OAuthClientRepository { return NewOAuthClientRepository(fresh()) },
+		AuthorizationCodes:  func() repo.AuthorizationCodeRepository { return NewAuthorizationCodeRepository(fresh()) },
+		Consents:            func() repo.ConsentRepository { return NewConsentRepository(fresh()) },
+		MachineClients:      func() repo.MachineClientRepository { return NewMachineClientRepository(fresh()) },
+	})
+}
+
+// TestWritesSweepExpiredRows ตรวจว่าการเขียนแถวใหม่ลบแถวที่หมดอายุของตารางนั้นออกจริง
+// (repotest เห็นแค่ว่าแถวหมดอายุอ่านไม่ได้ ไม่เห็นว่ายังค้างอยู่ในไฟล์หรือไม่)
+func TestWritesSweepExpiredRows(t *testing.T) {
+	ctx := context.Background()
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	for _, tc := range []struct {
+		table string
+		write func(db *sql.DB, id string, exp time.Time) error
+	}{
+		{"invalidated_tokens", func(db *sql.DB, id string, exp time.Time) error {
+			return NewTokenRepository(db).Blacklist(ctx, id, exp)
+		}},
+		{"password_resets", func(db *sql.DB, id string, exp time.Time) error {
+			return NewPasswordResetRepository(db).Create(ctx, id, "user-1", exp)
+		}},
+		{"email_verifications", func(db *sql.DB, id string, exp time.Time) error {
+			return NewEmailVerificationRepository(db).Create(ctx, id, "user-1", "a@example.com", exp)
+		}},
+		{"webauthn_sessions", func(db *sql.DB, id string, exp time.Time) error {
+			return NewWebAuthnSessionRepository(db).Create(ctx, id, []byte("{}"), exp)
+		}},
+	} {
+		t.Run(tc.table, func(t *testing.T) {
+			db := openTemp(t, tc.table)
+			for _, id := range []string{"old-1", "old-2"} {
+				if err := tc.write(db, id, past); err != nil {
+					t.Fatalf("write expired row: %v", err)
+				}
+			}
+			if got := countRows(t, db, tc.table); got != 1 {
+				// แถวแรกถูกลบตอนเขียนแถวที่สอง
+				t.Fatalf("%d rows after two expired writes, want 1", got)
+			}
+			if err := tc.write(db, "live", future); err != nil {
+				t.Fatalf("write live row: %v", err)
+			}
+			if got := countRows(t, db, tc.table); got != 1 {
+				t.Fatalf("%d rows after writing a live row, want only the live row", got)
+			}
+		})
+	}
+}
+
+// TestTokensAreStoredHashed ตรวจว่า reset token และ token ยืนยันอีเมลถูกเก็บเป็น SHA-256 ไม่ใช่ token ดิบ
+func TestTokensAreStoredHashed(t *testing.T) {
+	ctx := context.Background()
+	db := openTemp(t, "hashed")
+	const raw = "raw-secret-token"
+	exp := time.Now().Add(time.Hour)
+	if err := NewPasswordResetRepository(db).Create(ctx, raw, "user-1", exp); err != nil {
+		t.Fatalf("Create reset: %v", err)
+	}
+	if err := NewEmailVerificationRepository(db).Create(ctx, raw, "user-1", "a@example.com", exp); err != nil {
+		t.Fatalf("Create verification: %v", err)
+	}
+
+	for table, want := range map[string]string{
+		"password_resets":     pr.HashToken(raw),
+		"email_verifications": ev.HashToken(raw),
+	} {
+		var stored string
+		if err := db.QueryRow("SELECT token_hash FROM " + table).Scan(&stored); err != nil {
+			t.Fatalf("read %s: %v", table, err)
+		}
+		if stored != want {
+			t.Errorf("%s stores %q, want the SHA-256 digest %q", table, stored, want)
+		}
+		var leaked int
+		if err := db.QueryRow("SELECT count(*) FROM "+table+" WHERE instr(token_hash || user_id || expires_at, ?) > 0", raw).Scan(&leaked); err != nil {
+			t.Fatalf("search %s: %v", table, err)
+		}
+		if leaked != 0 {
+			t.Errorf("%s contains the raw token", table)
+		}
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+type tokenRepo struct {
+	db *sql.DB
+}
+
+// NewTokenRepository สร้าง TokenRepository (blacklist) บน SQLite
+func NewTokenRepository(db *sql.DB) repo.TokenRepository {
+	return &tokenRepo{db: db}
+}
+
+func (r *tokenRepo) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := sweepExpired(ctx, r.db, "invalidated_tokens", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO invalidated_tokens (jti, expires_at) VALUES (?, ?)
+		ON CONFLICT (jti) DO UPDATE SET expires_at = max(expires_at, excluded.expires_at)`,
+		jti, toMillis(expiresAt))
+	return err
+}
+
+func (r *tokenRepo) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	var found bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM invalidated_tokens WHERE jti = ? AND expires_at > ?)`,
+		jti, toMillis(time.Now())).Scan(&found)
+	return found, err
+}
+
+func (r *tokenRepo) Claim(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if err := sweepExpired(ctx, r.db, "invalidated_tokens", time.Now()); err != nil {
+		return false, err
+	}
+	res, err := r.db.ExecContext(ctx, `INSERT INTO invalidated_tokens (jti, expires_at) VALUES (?, ?)
+		ON CONFLICT (jti) DO NOTHING`, jti, toMillis(expiresAt))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *tokenRepo) RevokeIssuedBefore(ctx context.Context, subject string, before, expiresAt time.Time) error {
+	if err := sweepExpired(ctx, r.db, "token_watermarks", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO token_watermarks (subject, revoked_before, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (subject) DO UPDATE SET
+			revoked_before = max(revoked_before, excluded.revoked_before),
+			expires_at = max(expires_at, excluded.expires_at)`,
+		subject, toMillis(before), toMillis(expiresAt))
+	return err
+}
+
+func (r *tokenRepo) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	var before int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT revoked_before FROM token_watermarks WHERE subject = ? AND expires_at > ?`,
+		subject, toMillis(time.Now())).Scan(&before)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fromMillis(before), nil
+}
@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+)
+
+const userColumns = `id, email, password_hash, name, roles, email_verified, email_verified_at,
+	mfa_enabled, totp_secret, totp_pending_secret, totp_last_step, recovery_codes, created_at, deleted_at`
+
+type userRepo struct {
+	db *sql.DB
+}
+
+// NewUserRepository สร้าง UserRepository บน SQLite
+func NewUserRepository(db *sql.DB) repo.UserRepository {
+	return &userRepo{db: db}
+}
+
+func (r *userRepo) Create(ctx context.Context, u *domain.User) error {
+	id := uuid.NewString()
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO users (`+userColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL)`,
+		id, u.Email, u.PasswordHash, u.Name, encodeList(u.Roles), u.EmailVerified, nullMillis(u.EmailVerifiedAt),
+		u.MFAEnabled, u.TOTPSecret, u.TOTPPendingSecret, u.TOTPLastStep, encodeList(u.RecoveryCodes), toMillis(now))
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateEmail
+	}
+	if err != nil {
+		return err
+	}
+	u.ID = id
+	u.CreatedAt = now
+	return nil
+}
+
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE email = ? AND deleted_at IS NULL`, email)
+}
+
+func (r *userRepo) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+// FindAll ใช้ REGEXP (ดู regexpFunc) ให้ตรงกับ $regex + $options "i" ของ Mongo
+func (r *userRepo) FindAll(ctx context.Context, filterName, filterEmail string, page, size int) ([]*domain.User, int64, error) {
+	const where = ` FROM users WHERE deleted_at IS NULL
+		AND (?1 = '' OR name REGEXP ?1)
+		AND (?2 = '' OR email REGEXP ?2)`
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT count(*)`+where, filterName, filterEmail).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	// LIMIT -1 = ไม่จำกัด เหมือน SetLimit(0) ของ Mongo
+	limit, offset := int64(-1), int64(0)
+	if size > 0 {
+		limit = int64(size)
+		offset = int64(page-1) * limit
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+where+`
+		ORDER BY created_at, id LIMIT ?3 OFFSET ?4`, filterName, filterEmail, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepo) Update(ctx context.Context, u *domain.User) error {
+	// เหมือน $set ของ Mongo: ไม่แตะ id, created_at, deleted_at และสถานะ MFA (เขียนผ่าน SetMFA)
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET
+		email = ?, password_hash = ?, name = ?, roles = ?,
+		email_verified = ?, email_verified_at = ?, totp_pending_secret = ?
+		WHERE id = ? AND deleted_at IS NULL`,
+		u.Email, u.PasswordHash, u.Name, encodeList(u.Roles),
+		u.EmailVerified, nullMillis(u.EmailVerifiedAt), u.TOTPPendingSecret, u.ID)
+	if isUniqueViolation(err) {
+		return repo.ErrDuplicateEmail
+	}
+	if err != nil {
+		return err
+	}
+	return mustAffect(res, repo.ErrUserNotFound)
+}
+
+func (r *userRepo) SetMFA(ctx context.Context, id, secret string, lastStep int64, recoveryCodes []string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET
+		mfa_enabled = ?, totp_secret = ?, totp_pending_secret = '', totp_last_step = ?, recovery_codes = ?
+		WHERE id = ? AND deleted_at IS NULL`,
+		secret != "", secret, lastStep, encodeList(recoveryCodes), id)
+	if err != nil {
+		return err
+	}
+	return mustAffect(res, repo.ErrUserNotFound)
+}
+
+func (r *userRepo) ConsumeTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET totp_last_step = ?
+		WHERE id = ? AND deleted_at IS NULL AND totp_last_step < ?`, step, id, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *userRepo) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	// recovery_codes เป็น JSON array: กรอง code ออกด้วย json_each ใน statement เดียว
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET recovery_codes =
+		(SELECT json_group_array(value) FROM json_each(users.recovery_codes) WHERE value <> ?1)
+		WHERE id = ?2 AND deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM json_each(users.recovery_codes) WHERE value = ?1)`, codeHash, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *userRepo) SoftDelete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, toMillis(time.Now()), id)
+	if err != nil {
+		return err
+	}
+	return mustAffect(res, repo.ErrUserNotFound)
+}
+
+func (r *userRepo) findOne(ctx context.Context, query string, arg string) (*domain.User, error) {
+	u, err := scanUser(r.db.QueryRowContext(ctx, query, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrUserNotFound
+	}
+	return u, err
+}
+
+// rowScanner ครอบทั้ง *sql.Row และ *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var (
+		u                    domain.User
+		roles, recoveryCodes string
+		verifiedAt, deleted  sql.NullInt64
+		createdAt            int64
+	)
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Name, &roles, &u.EmailVerified, &verifiedAt,
+		&u.MFAEnabled, &u.TOTPSecret, &u.TOTPPendingSecret, &u.TOTPLastStep, &recoveryCodes, &createdAt, &deleted)
+	if err != nil {
+		return nil, err
+	}
+	if u.Roles, err = decodeList(roles); err != nil {
+		return nil, err
+	}
+	if u.RecoveryCodes, err = decodeList(recoveryCodes); err != nil {
+		return nil, err
+	}
+	u.EmailVerifiedAt = timePtr(verifiedAt)
+	u.CreatedAt = fromMillis(createdAt)
+	u.DeletedAt = timePtr(deleted)
+	return &u, nil
+}
+
+// mustAffect คืน notFound ถ้าคำสั่งไม่ได้แก้แถวใดเลย
+func mustAffect(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+const credentialColumns = `id, user_id, name, public_key, attestation_type, transports, aaguid,
+	sign_count, backup_eligible, backup_state, created_at, last_used_at`
+
+type webAuthnCredentialRepo struct {
+	db *sql.DB
+}
+
+// NewWebAuthnCredentialRepository สร้าง WebAuthnCredentialRepository บน SQLite
+func NewWebAuthnCredentialRepository(db *sql.DB) repo.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepo{db: db}
+}
+
+func (r *webAuthnCredentialRepo) Create(ctx context.Context, c *domain.WebAuthnCredential) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webauthn_credentials (`+credentialColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, c.UserID, c.Name, c.PublicKey, c.AttestationType, encodeList(c.Transports), c.AAGUID,
+		int64(c.SignCount), c.BackupEligible, c.BackupState, toMillis(now), nullMillis(c.LastUsedAt))
+	if isUniqueViolation(err) {
+		return errors.New("credential already exists")
+	}
+	if err != nil {
+		return err
+	}
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *webAuthnCredentialRepo) FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+credentialColumns+`
+		FROM webauthn_credentials WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []*domain.WebAuthnCredential
+	for rows.Next() {
+		c, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+	return creds, rows.Err()
+}
+
+func (r *webAuthnCredentialRepo) FindByID(ctx context.Context, id string) (*domain.WebAuthnCredential, error) {
+	c, err := scanCredential(r.db.QueryRowContext(ctx, `SELECT `+credentialColumns+`
+		FROM webauthn_credentials WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("credential not found")
+	}
+	return c, err
+}
+
+func (r *webAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE webauthn_credentials
+		SET sign_count = ?, backup_state = ?, last_used_at = ? WHERE id = ?`,
+		int64(signCount), backupState, toMillis(usedAt), id)
+	return err
+}
+
+func scanCredential(row rowScanner) (*domain.WebAuthnCredential, error) {
+	var (
+		c                    domain.WebAuthnCredential
+		transports           string
+		signCount, createdAt int64
+		lastUsedAt           sql.NullInt64
+	)
+	err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.PublicKey, &c.AttestationType, &transports, &c.AAGUID,
+		&signCount, &c.BackupEligible, &c.BackupState, &createdAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	if c.Transports, err = decodeList(transports); err != nil {
+		return nil, err
+	}
+	c.SignCount = uint32(signCount)
+	c.CreatedAt = fromMillis(createdAt)
+	c.LastUsedAt = timePtr(lastUsedAt)
+	return &c, nil
+}
+
+type webAuthnSessionRepo struct {
+	db *sql.DB
+}
+
+// NewWebAuthnSessionRepository สร้าง WebAuthnSessionRepository บน SQLite
+func NewWebAuthnSessionRepository(db *sql.DB) repo.WebAuthnSessionRepository {
+	return &webAuthnSessionRepo{db: db}
+}
+
+func (r *webAuthnSessionRepo) Create(ctx context.Context, id string, data []byte, expiresAt time.Time) error {
+	if err := sweepExpired(ctx, r.db, "webauthn_sessions", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webauthn_sessions (id, data, expires_at) VALUES (?, ?, ?)`,
+		id, data, toMillis(expiresAt))
+	return err
+}
+
+func (r *webAuthnSessionRepo) Consume(ctx context.Context, id string) ([]byte, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx,
+		`DELETE FROM webauthn_sessions WHERE id = ? AND expires_at > ? RETURNING data`,
+		id, toMillis(time.Now())).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("session not found")
+	}
+	return data, err
+}
@@ -1,49 +1,106 @@
 package repository
 
 import (
-
 	"context"
 	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options" 
-
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// TokenRepository interface สำหรับจัดการ blacklist tokens
+// TokenRepository เก็บการเพิกถอน access token: ทีละตัวตาม jti และทั้งหมดของ subject ด้วย watermark
+// ไม่เก็บ token ดิบ (token ใน DB dump จะถูกนำไปใช้ต่อได้)
 type TokenRepository interface {
-
-	Blacklist(token string, expiresAt time.Time) error
-	IsBlacklisted(token string) (bool, error)
-
+	// Blacklist เพิกถอน token ที่มี jti นี้ เก็บไว้ถึง expiresAt (เวลาหมดอายุของ token)
+	Blacklist(ctx context.Context, jti string, expiresAt time.Time) error
+	IsBlacklisted(ctx context.Context, jti string) (bool, error)
+	// Claim บันทึก jti ถ้ายังไม่มี (insert-if-absent) คืน false ถ้ามี jti นี้อยู่แล้ว
+	// ใช้กับ token ที่ใช้ได้ครั้งเดียว: คำขอพร้อมกันด้วย jti เดียวกันได้ true แค่คำขอเดียว
+	Claim(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	// RevokeIssuedBefore ทำให้ token ของ subject ที่ออกก่อน before ใช้ไม่ได้ (logout ทุกเครื่อง)
+	// ถ้ามี watermark อยู่แล้วเก็บค่าที่ใหม่กว่า ลบทิ้งได้หลัง expiresAt เมื่อ token เหล่านั้นหมดอายุหมดแล้ว
+	RevokeIssuedBefore(ctx context.Context, subject string, before, expiresAt time.Time) error
+	// RevokedBefore คืน watermark ของ subject (zero time ถ้าไม่มีหรือหมดอายุแล้ว)
+	RevokedBefore(ctx context.Context, subject string) (time.Time, error)
 }
 
 type mongoTokenRepo struct {
-
 	col *mongo.Collection
-
 }
 
-// NewMongoTokenRepository สร้าง instance และตั้ง TTL index
+// NewMongoTokenRepository สร้าง instance พร้อม index บน jti, subject และ TTL
+// document ของ jti และ watermark อยู่ใน collection เดียวกัน
+// document รุ่นเก่าที่เก็บ token ดิบถูกลบทิ้ง (token ที่ไม่มี jti ใช้ไม่ได้แล้ว)
 func NewMongoTokenRepository(col *mongo.Collection) TokenRepository {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	col.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.M{"expiresAt": 1},
-		Options: options.Index().SetExpireAfterSeconds(0),
+	col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.M{"jti": 1},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"jti": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.M{"subject": 1},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"subject": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
 	})
+	col.DeleteMany(ctx, bson.M{"token": bson.M{"$exists": true}})
 	return &mongoTokenRepo{col: col}
 }
 
-func (r *mongoTokenRepo) Blacklist(token string, expiresAt time.Time) error {
-	_, err := r.col.InsertOne(context.Background(), bson.M{
-	"token":     token,
-	"expiresAt": expiresAt,
-	})
+func (r *mongoTokenRepo) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"jti": jti},
+		bson.M{"$max": bson.M{"expiresAt": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
 	return err
 }
 
-func (r *mongoTokenRepo) IsBlacklisted(token string) (bool, error) {
-	count, err := r.col.CountDocuments(context.Background(), bson.M{"token": token})
+func (r *mongoTokenRepo) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	// TTL monitor ของ Mongo ลบเป็นรอบ ๆ จึงต้องกรอง token ที่หมดอายุแล้วเองด้วย
+	count, err := r.col.CountDocuments(ctx, bson.M{
+		"jti":       jti,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	})
 	return count > 0, err
-}
\ No newline at end of file
+}
+
+func (r *mongoTokenRepo) Claim(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	// unique index บน jti ทำให้ insert ซ้ำล้มเหลว
+	_, err := r.col.InsertOne(ctx, bson.M{"jti": jti, "expiresAt": expiresAt})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *mongoTokenRepo) RevokeIssuedBefore(ctx context.Context, subject string, before, expiresAt time.Time) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"subject": subject},
+		bson.M{"$max": bson.M{"revokedBefore": before, "expiresAt": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *mongoTokenRepo) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	var doc struct {
+		RevokedBefore time.Time `bson:"revokedBefore"`
+	}
+	err := r.col.FindOne(ctx, bson.M{
+		"subject":   subject,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	return doc.RevokedBefore, err
+}
@@ -13,15 +13,31 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// error ที่ UserRepository ทุก implementation ต้องคืนให้ตรงกัน
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrInvalidUserID  = errors.New("invalid user ID format")
+	ErrDuplicateEmail = errors.New("email already registered")
+)
+
 //Userrepository is inter for user data access
 type UserRepository interface {
 
-	Create(u *domain.User) error
-	FindByEmail(email string) (*domain.User, error)
-	FindAll(filterName, filterEmail string, page, size int ) ([]*domain.User, int64, error)
-	FindByID(id string) (*domain.User, error)
-	Update( u *domain.User) error
-	SoftDelete(id string) error
+	Create(ctx context.Context, u *domain.User) error
+	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindAll(ctx context.Context, filterName, filterEmail string, page, size int ) ([]*domain.User, int64, error)
+	FindByID(ctx context.Context, id string) (*domain.User, error)
+	// Update เขียนข้อมูลทั่วไปของผู้ใช้ ไม่แตะสถานะ MFA (MFAEnabled, TOTPSecret, TOTPLastStep, RecoveryCodes)
+	// เพื่อให้การ Update จากข้อมูลที่อ่านไว้ก่อนไม่ทับผลของ ConsumeTOTPStep / ConsumeRecoveryCode
+	Update(ctx context.Context, u *domain.User) error
+	SoftDelete(ctx context.Context, id string) error
+	// SetMFA เขียนสถานะ MFA ทั้งชุดและล้าง TOTPPendingSecret: secret ว่าง = ปิด MFA
+	SetMFA(ctx context.Context, id, secret string, lastStep int64, recoveryCodes []string) error
+	// ConsumeTOTPStep บันทึก step ของ TOTP ที่ใช้แล้วแบบ atomic (compare-and-swap)
+	// คืน false ถ้ามี step นี้หรือใหม่กว่าถูกใช้ไปแล้ว
+	ConsumeTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+	// ConsumeRecoveryCode ลบ recovery code (hash) ออกแบบ atomic คืน false ถ้าไม่มี code นี้แล้ว
+	ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error)
 }
 
 //mongoUserRepo is MongoDB implementtation of Userrepository
@@ -46,9 +62,12 @@ func NewMongoUserRepository(col *mongo.Collection) UserRepository {
     return &mongoUserRepo{col: col}
 }
 
-func (r *mongoUserRepo) Create(u *domain.User) error {
+func (r *mongoUserRepo) Create(ctx context.Context, u *domain.User) error {
 	u.CreatedAt = time.Now()
-	res, err := r.col.InsertOne(context.Background(), u)
+	res, err := r.col.InsertOne(ctx, u)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateEmail
+	}
 	if err != nil {
 		return err
 	}
@@ -61,19 +80,21 @@ func (r *mongoUserRepo) Create(u *domain.User) error {
     return nil
 }
 
-func (r *mongoUserRepo) FindByEmail(email string) (*domain.User, error) {
+func (r *mongoUserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var u domain.User
-	err := r.col.FindOne(context.Background(), bson.M{"email": email}).Decode(&u)
+	err := r.col.FindOne(ctx, bson.M{
+		"email":     email,
+		"deletedAt": bson.M{"$exists": false},
+	}).Decode(&u)
 	if err == mongo.ErrNoDocuments {
-	return nil, errors.New("user not found")
+	return nil, ErrUserNotFound
 	}
 	return &u, err
 }
 
 // FindAll return filtered users and total count.
-func (r *mongoUserRepo) FindAll(filterName, filterEmail string, page, size int) ([]*domain.User, int64, error) {
+func (r *mongoUserRepo) FindAll(ctx context.Context, filterName, filterEmail string, page, size int) ([]*domain.User, int64, error) {
 
-	ctx := context.Background()
 	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
 	if filterName != "" {
 		filter["name"] = bson.M{"$regex": filterName, "$options": "i"}
@@ -87,7 +108,11 @@ func (r *mongoUserRepo) FindAll(filterName, filterEmail string, page, size int)
         return nil, 0, err
     }
 
+    if page < 1 {
+        page = 1
+    }
     opts := options.Find().
+        SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
         SetSkip(int64((page - 1) * size)).
         SetLimit(int64(size))
 	
@@ -105,11 +130,10 @@ func (r *mongoUserRepo) FindAll(filterName, filterEmail string, page, size int)
 }
 
 // FindByID returns a single user by ID excluding soft-deleted.
-func (r *mongoUserRepo) FindByID(id string) (*domain.User, error) {
-    ctx := context.Background()
+func (r *mongoUserRepo) FindByID(ctx context.Context, id string) (*domain.User, error) {
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return nil, errors.New("invalid user ID format")
+        return nil, ErrInvalidUserID
     }
     filter := bson.M{
         "_id":       objID,
@@ -118,39 +142,129 @@ func (r *mongoUserRepo) FindByID(id string) (*domain.User, error) {
     var u domain.User
     err = r.col.FindOne(ctx, filter).Decode(&u)
     if err == mongo.ErrNoDocuments {
-        return nil, errors.New("user not found")
+        return nil, ErrUserNotFound
     }
     return &u, err
 }
 
 
 // Update modifies allowed fields of a user.
-func (r *mongoUserRepo) Update(u *domain.User) error {
+func (r *mongoUserRepo) Update(ctx context.Context, u *domain.User) error {
     objID, err := primitive.ObjectIDFromHex(u.ID)
     if err != nil {
-        return errors.New("invalid user ID format")
+        return ErrInvalidUserID
     }
-    _, err = r.col.UpdateOne(
-        context.Background(),
-        bson.M{"_id": objID},
+    res, err := r.col.UpdateOne(
+        ctx,
+        bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}},
         bson.M{"$set": bson.M{
-            "email": u.Email,
-            // เพิ่มฟิลด์อื่นๆ ตามต้องการ
+            "email":             u.Email,
+            "password_hash":     u.PasswordHash,
+            "name":              u.Name,
+            "roles":             u.Roles,
+            "emailVerified":     u.EmailVerified,
+            "emailVerifiedAt":   u.EmailVerifiedAt,
+            "totpPendingSecret": u.TOTPPendingSecret,
         }},
     )
-    return err
+    if mongo.IsDuplicateKeyError(err) {
+        return ErrDuplicateEmail
+    }
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return ErrUserNotFound
+    }
+    return nil
+}
+
+// SetMFA replaces the whole MFA state in one update and clears the pending secret.
+func (r *mongoUserRepo) SetMFA(ctx context.Context, id, secret string, lastStep int64, recoveryCodes []string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+	res, err := r.col.UpdateOne(
+		ctx,
+		bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}},
+		bson.M{
+			"$set": bson.M{
+				"mfaEnabled":    secret != "",
+				"totpSecret":    secret,
+				"totpLastStep":  lastStep,
+				"recoveryCodes": recoveryCodes,
+			},
+			"$unset": bson.M{"totpPendingSecret": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ConsumeTOTPStep updates totpLastStep only when the stored step is older.
+func (r *mongoUserRepo) ConsumeTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, ErrInvalidUserID
+	}
+	// totpLastStep เป็น omitempty: ค่า 0 จะไม่มี field
+	res, err := r.col.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":       objID,
+			"deletedAt": bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"totpLastStep": bson.M{"$lt": step}},
+				bson.M{"totpLastStep": bson.M{"$exists": false}},
+			},
+		},
+		bson.M{"$set": bson.M{"totpLastStep": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// ConsumeRecoveryCode pulls the exact code hash so it can be used only once.
+func (r *mongoUserRepo) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, ErrInvalidUserID
+	}
+	res, err := r.col.UpdateOne(
+		ctx,
+		bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}, "recoveryCodes": codeHash},
+		bson.M{"$pull": bson.M{"recoveryCodes": codeHash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
 }
 
 // SoftDelete marks a user as deleted by setting deletedAt.
-func (r *mongoUserRepo) SoftDelete(id string) error {
+func (r *mongoUserRepo) SoftDelete(ctx context.Context, id string) error {
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return errors.New("invalid user ID format")
+        return ErrInvalidUserID
     }
-    _, err = r.col.UpdateOne(
-        context.Background(),
-        bson.M{"_id": objID},
+    res, err := r.col.UpdateOne(
+        ctx,
+        bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}},
         bson.M{"$set": bson.M{"deletedAt": time.Now()}},
     )
-    return err
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return ErrUserNotFound
+    }
+    return nil
 }
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebAuthnCredentialRepository เก็บ passkey ของผู้ใช้ (ค้นด้วย user ID หรือ credential ID)
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, c *domain.WebAuthnCredential) error
+	FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error)
+	FindByID(ctx context.Context, id string) (*domain.WebAuthnCredential, error)
+	// UpdateSignCount บันทึก sign counter ล่าสุดหลัง login สำเร็จ
+	UpdateSignCount(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error
+}
+
+// WebAuthnSessionRepository เก็บ session data ระหว่าง begin/finish ของ ceremony
+type WebAuthnSessionRepository interface {
+	Create(ctx context.Context, id string, data []byte, expiresAt time.Time) error
+	// Consume คืน session data และลบทิ้งทันที (ใช้ได้ครั้งเดียว)
+	Consume(ctx context.Context, id string) ([]byte, error)
+}
+
+type mongoWebAuthnCredentialRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoWebAuthnCredentialRepository สร้าง instance พร้อม index บน userID
+func NewMongoWebAuthnCredentialRepository(col *mongo.Collection) WebAuthnCredentialRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"userID": 1},
+	})
+	return &mongoWebAuthnCredentialRepo{col: col}
+}
+
+func (r *mongoWebAuthnCredentialRepo) Create(ctx context.Context, c *domain.WebAuthnCredential) error {
+	c.CreatedAt = time.Now()
+	_, err := r.col.InsertOne(ctx, c)
+	return err
+}
+
+func (r *mongoWebAuthnCredentialRepo) FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+	var creds []*domain.WebAuthnCredential
+	if err := cursor.All(ctx, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (r *mongoWebAuthnCredentialRepo) FindByID(ctx context.Context, id string) (*domain.WebAuthnCredential, error) {
+	var c domain.WebAuthnCredential
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("credential not found")
+	}
+	return &c, err
+}
+
+func (r *mongoWebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	_, err := r.col.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"signCount":   signCount,
+			"backupState": backupState,
+			"lastUsedAt":  usedAt,
+		}},
+	)
+	return err
+}
+
+type mongoWebAuthnSessionRepo struct {
+	col *mongo.Collection
+}
+
+// NewMongoWebAuthnSessionRepository สร้าง instance และตั้ง TTL index
+func NewMongoWebAuthnSessionRepository(col *mongo.Collection) WebAuthnSessionRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return &mongoWebAuthnSessionRepo{col: col}
+}
+
+func (r *mongoWebAuthnSessionRepo) Create(ctx context.Context, id string, data []byte, expiresAt time.Time) error {
+	_, err := r.col.InsertOne(ctx, bson.M{
+		"_id":       id,
+		"data":      data,
+		"expiresAt": expiresAt,
+	})
+	return err
+}
+
+func (r *mongoWebAuthnSessionRepo) Consume(ctx context.Context, id string) ([]byte, error) {
+	var doc struct {
+		Data      []byte    `bson:"data"`
+		ExpiresAt time.Time `bson:"expiresAt"`
+	}
+	err := r.col.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("session not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// TTL monitor ของ Mongo ลบเป็นรอบ ๆ จึงต้องเช็คเวลาหมดอายุเองด้วย
+	if time.Now().After(doc.ExpiresAt) {
+		return nil, errors.New("session expired")
+	}
+	return doc.Data, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+// WithAuditLog กำหนดที่เก็บ audit event ถ้าไม่กำหนด service จะไม่บันทึกอะไรและ ListAuditEvents คืนรายการว่าง
+func WithAuditLog(r repo.AuditRepository) Option {
+	return func(s *AuthService) {
+		s.auditRepo = r
+	}
+}
+
+// audit บันทึก e พร้อมผลจาก err (ถ้า e ยังไม่ได้ตั้ง Outcome เอง) และ IP / user agent / ผู้กระทำจาก ctx
+// ใช้ context ที่ไม่ถูกยกเลิกตาม request เพื่อให้ความล้มเหลวจากการยกเลิกถูกบันทึกด้วย
+// บันทึกไม่สำเร็จแค่ log ไว้ ไม่ทำให้การกระทำหลักล้มเหลว
+func (s *AuthService) audit(ctx context.Context, e *domain.AuditEvent, err error) {
+	if s.auditRepo == nil {
+		return
+	}
+	c := ClientFromContext(ctx)
+	e.IP, e.UserAgent = c.IP, c.UserAgent
+	if p, ok := PrincipalFromContext(ctx); ok && e.ActorID == "" {
+		e.ActorID = p.UserID
+	}
+	if err != nil {
+		e.Outcome, e.Reason = domain.AuditFailure, auditReason(err)
+	} else if e.Outcome == "" {
+		e.Outcome = domain.AuditSuccess
+	}
+	if err := s.auditRepo.Append(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("failed to record %s audit event: %v", e.Type, err)
+	}
+}
+
+// auditReason คืน reason ของ error สำหรับ audit log
+func auditReason(err error) string {
+	var svcErr *Error
+	switch {
+	case errors.As(err, &svcErr):
+		return svcErr.Reason
+	case errors.Is(err, context.Canceled):
+		return "CANCELLED"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "DEADLINE_EXCEEDED"
+	}
+	return "INTERNAL"
+}
+
+// ขนาดหน้าของ ListAuditEvents: size <= 0 ได้ค่าเริ่มต้น มากกว่าค่าสูงสุดถูกตัดลง
+// (repository ตีความ size <= 0 ว่าไม่จำกัด ซึ่งไม่ควรเปิดให้ client ขอทั้ง log ในครั้งเดียว)
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// ListAuditEvents ค้น audit log จากใหม่ไปเก่า (ต้องมี permission audit:read)
+func (s *AuthService) ListAuditEvents(ctx context.Context, f domain.AuditFilter, page, size int) ([]domain.AuditEvent, int64, error) {
+	if _, err := s.requirePermission(ctx, domain.PermAuditRead); err != nil {
+		return nil, 0, err
+	}
+	if s.auditRepo == nil {
+		return nil, 0, nil
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Since.Before(f.Until) {
+		return nil, 0, ErrInvalidTimeRange
+	}
+	switch {
+	case size <= 0:
+		size = defaultAuditPageSize
+	case size > maxAuditPageSize:
+		size = maxAuditPageSize
+	}
+	events, total, err := s.auditRepo.List(ctx, f, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]domain.AuditEvent, len(events))
+	for i, e := range events {
+		out[i] = *e
+	}
+	return out, total, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/totp"
+)
+
+// lastAudit คืน event ล่าสุดของชนิด typ
+func lastAudit(t *testing.T, s *AuthService, typ string) *domain.AuditEvent {
+	t.Helper()
+	events := auditEvents(t, s, typ)
+	if len(events) == 0 {
+		t.Fatalf("no %s audit event", typ)
+	}
+	return events[0]
+}
+
+func TestVerifyMFAIsAudited(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u := mustRegister(t, s, "mfa@example.com", true)
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	if err := s.repo.SetMFA(ctx, u.ID, secret, 0, nil); err != nil {
+		t.Fatalf("SetMFA: %v", err)
+	}
+	pair, err := s.Login(ctx, u.Email, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if _, err := s.VerifyMFA(ctx, pair.MFAToken, "000000x"); err != ErrInvalidMFACode {
+		t.Fatalf("VerifyMFA(wrong code) = %v, want ErrInvalidMFACode", err)
+	}
+	ev := lastAudit(t, s, domain.AuditMFAVerify)
+	if ev.Outcome != domain.AuditFailure || ev.Reason != ErrInvalidMFACode.Reason || ev.SubjectID != u.ID || ev.ActorID != "" {
+		t.Fatalf("failed VerifyMFA event = %+v", ev)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	if _, err := s.VerifyMFA(ctx, pair.MFAToken, code); err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+	ev = lastAudit(t, s, domain.AuditMFAVerify)
+	if ev.Outcome != domain.AuditSuccess || ev.ActorID != u.ID || ev.SubjectID != u.ID || ev.Email != u.Email {
+		t.Fatalf("VerifyMFA event = %+v", ev)
+	}
+
+	if _, err := s.VerifyMFA(ctx, "not-a-token", code); err != ErrInvalidMFAToken {
+		t.Fatalf("VerifyMFA(bad token) = %v, want ErrInvalidMFAToken", err)
+	}
+	if ev = lastAudit(t, s, domain.AuditMFAVerify); ev.Outcome != domain.AuditFailure || ev.Reason != ErrInvalidMFAToken.Reason {
+		t.Fatalf("VerifyMFA(bad token) event = %+v", ev)
+	}
+}
+
+func TestRefreshTokenIsAudited(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u := mustRegister(t, s, "refresh@example.com", true)
+	pair, err := s.Login(ctx, u.Email, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if _, err := s.RefreshToken(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	ev := lastAudit(t, s, domain.AuditTokenRefresh)
+	if ev.Outcome != domain.AuditSuccess || ev.ActorID != u.ID || ev.SubjectID != u.ID {
+		t.Fatalf("RefreshToken event = %+v", ev)
+	}
+
+	// ใช้ token เดิมซ้ำ: บันทึกเป็น failure ของเจ้าของ token
+	if _, err := s.RefreshToken(ctx, pair.RefreshToken); err != ErrRefreshTokenReused {
+		t.Fatalf("RefreshToken(reused) = %v, want ErrRefreshTokenReused", err)
+	}
+	ev = lastAudit(t, s, domain.AuditTokenRefresh)
+	if ev.Outcome != domain.AuditFailure || ev.Reason != ErrRefreshTokenReused.Reason || ev.SubjectID != u.ID {
+		t.Fatalf("reused RefreshToken event = %+v", ev)
+	}
+}
+
+func TestRoleChangesAreAudited(t *testing.T) {
+	s := newTestService(t)
+	admin := mustRegister(t, s, "admin@example.com", true)
+	target := mustRegister(t, s, "target@example.com", true)
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: admin.ID, Roles: []string{domain.RoleAdmin}})
+
+	if _, err := s.AssignRole(ctx, target.ID, domain.RoleAdmin); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	ev := lastAudit(t, s, domain.AuditRoleAssign)
+	if ev.Outcome != domain.AuditSuccess || ev.ActorID != admin.ID || ev.SubjectID != target.ID || ev.Reason != domain.RoleAdmin {
+		t.Fatalf("AssignRole event = %+v", ev)
+	}
+
+	if _, err := s.RevokeRole(ctx, target.ID, domain.RoleAdmin); err != nil {
+		t.Fatalf("RevokeRole: %v", err)
+	}
+	ev = lastAudit(t, s, domain.AuditRoleRevoke)
+	if ev.Outcome != domain.AuditSuccess || ev.ActorID != admin.ID || ev.SubjectID != target.ID || ev.Reason != domain.RoleAdmin {
+		t.Fatalf("RevokeRole event = %+v", ev)
+	}
+
+	// ผู้ไม่มีสิทธิ์ก็ถูกบันทึก
+	userCtx := WithPrincipal(context.Background(), &Principal{UserID: target.ID, Roles: []string{domain.RoleUser}})
+	if _, err := s.AssignRole(userCtx, target.ID, domain.RoleAdmin); err == nil {
+		t.Fatal("AssignRole without roles:write succeeded")
+	}
+	ev = lastAudit(t, s, domain.AuditRoleAssign)
+	if ev.Outcome != domain.AuditFailure || ev.ActorID != target.ID || ev.Reason != ErrPermissionDenied.Reason {
+		t.Fatalf("denied AssignRole event = %+v", ev)
+	}
+	if _, err := s.RevokeRole(ctx, admin.ID, domain.RoleAdmin); err == nil {
+		t.Fatal("RevokeRole of own admin role succeeded")
+	}
+	if ev = lastAudit(t, s, domain.AuditRoleRevoke); ev.Outcome != domain.AuditFailure || ev.SubjectID != admin.ID {
+		t.Fatalf("denied RevokeRole event = %+v", ev)
+	}
+}
+
+func TestListAuditEventsClampsPageSize(t *testing.T) {
+	s := newTestService(t)
+	admin := mustRegister(t, s, "admin@example.com", true)
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: admin.ID, Roles: []string{domain.RoleAdmin}})
+	for i := 0; i < maxAuditPageSize+10; i++ {
+		s.audit(ctx, &domain.AuditEvent{Type: domain.AuditLogin}, nil)
+	}
+
+	for _, tc := range []struct{ size, want int }{
+		{0, defaultAuditPageSize},
+		{-1, defaultAuditPageSize},
+		{10, 10},
+		{maxAuditPageSize + 1, maxAuditPageSize},
+		{1 << 30, maxAuditPageSize},
+	} {
+		events, total, err := s.ListAuditEvents(ctx, domain.AuditFilter{Type: domain.AuditLogin}, 1, tc.size)
+		if err != nil {
+			t.Fatalf("ListAuditEvents(size=%d): %v", tc.size, err)
+		}
+		if len(events) != tc.want || total != int64(maxAuditPageSize+10) {
+			t.Errorf("ListAuditEvents(size=%d) = %d events of %d, want %d", tc.size, len(events), total, tc.want)
+		}
+	}
+}
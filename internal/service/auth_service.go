@@ -3,117 +3,271 @@ package service
 import (
     "context"
     "errors"
+    "fmt"
     "log"
+    "net/url"
     "strings"
     "sync"
     "time"
 
     "github.com/LengLKR/auth-microservice/internal/domain"
+    "github.com/LengLKR/auth-microservice/internal/keys"
+    "github.com/LengLKR/auth-microservice/internal/mail"
+    "github.com/LengLKR/auth-microservice/internal/password"
+    "github.com/LengLKR/auth-microservice/internal/ratelimit"
     repo "github.com/LengLKR/auth-microservice/internal/repository"
+    ev "github.com/LengLKR/auth-microservice/internal/repository/email_verification"
     pr "github.com/LengLKR/auth-microservice/internal/repository/password_reset"
 
+    "github.com/go-webauthn/webauthn/webauthn"
     "github.com/golang-jwt/jwt/v4"
     "github.com/google/uuid"
-    "golang.org/x/crypto/bcrypt"
-    "google.golang.org/grpc/metadata"
 )
 
 
 // AuthService stub ของ service layer
 type AuthService struct {
-    repo      repo.UserRepository
-    tokenRepo repo.TokenRepository
-    resetRepo pr.PasswordResetRepository
-    jwtSecret string
-    attempts  map[string][]time.Time
-    mu        sync.Mutex
+    repo        repo.UserRepository
+    tokenRepo   repo.TokenRepository
+    resetRepo   pr.PasswordResetRepository
+    verifyRepo  ev.EmailVerificationRepository
+    refreshRepo repo.RefreshTokenRepository
+    keyRing     *keys.Ring
+    mailer      mail.Mailer
+    linkBaseURL string
+    issuer      string
+    accessTTL   time.Duration
+    refreshTTL  time.Duration
+    totpIssuer  string
+
+    requireVerifiedEmail bool
+
+    webauthn        *webauthn.WebAuthn
+    credRepo        repo.WebAuthnCredentialRepository
+    passkeySessions repo.WebAuthnSessionRepository
+
+    hasher         password.Hasher
+    passwordPolicy *password.Policy
+    dummyHash      string
+    dummyOnce      sync.Once
+
+    auditRepo repo.AuditRepository
+
+    oauthClients repo.OAuthClientRepository
+    authCodes    repo.AuthorizationCodeRepository
+    consents     repo.ConsentRepository
+
+    machineClients repo.MachineClientRepository
+
+    limiter       ratelimit.RateLimiter
+    accountPolicy ratelimit.Policy
+    ipPolicy      ratelimit.Policy
+
+    mailSlots chan struct{}
 }
 
-// NewAuthService สร้าง AuthService พร้อม userRepo, tokenRepo, resetRepo, และ secret
+// Option ปรับแต่งค่าเสริมของ AuthService
+type Option func(*AuthService)
+
+// WithTokenTTL กำหนดอายุของ access token และ refresh token
+func WithTokenTTL(access, refresh time.Duration) Option {
+    return func(s *AuthService) {
+        if access > 0 {
+            s.accessTTL = access
+        }
+        if refresh > 0 {
+            s.refreshTTL = refresh
+        }
+    }
+}
+
+// NewAuthService สร้าง AuthService พร้อม userRepo, tokenRepo, resetRepo, verifyRepo, refreshRepo,
+// key ring สำหรับเซ็น JWT และ mailer สำหรับส่งอีเมลถึงผู้ใช้
 func NewAuthService(
     r repo.UserRepository,
     t repo.TokenRepository,
     rr pr.PasswordResetRepository,
-    secret string,
+    vr ev.EmailVerificationRepository,
+    rt repo.RefreshTokenRepository,
+    keyRing *keys.Ring,
+    mailer mail.Mailer,
+    opts ...Option,
 ) *AuthService {
-    return &AuthService{
-        repo:      r,
-        tokenRepo: t,
-        resetRepo: rr,
-        jwtSecret: secret,
-        attempts:  make(map[string][]time.Time),
+    s := &AuthService{
+        repo:        r,
+        tokenRepo:   t,
+        resetRepo:   rr,
+        verifyRepo:  vr,
+        refreshRepo: rt,
+        keyRing:     keyRing,
+        mailer:      mailer,
+        linkBaseURL: "http://localhost:3000",
+        issuer:      "http://localhost:8080",
+        accessTTL:   15 * time.Minute,
+        refreshTTL:  30 * 24 * time.Hour,
+        totpIssuer:  "AuthService",
+
+        hasher:         password.NewArgon2id(),
+        passwordPolicy: password.DefaultPolicy(),
+
+        limiter:       ratelimit.NewMemoryLimiter(),
+        accountPolicy: ratelimit.DefaultPolicy,
+        ipPolicy:      defaultIPPolicy,
+
+        mailSlots: make(chan struct{}, defaultBackgroundMails),
     }
+    for _, opt := range opts {
+        opt(s)
+    }
+    return s
 }
 
-// Register สร้างบัญชีใหม่: hash, save, คืน JWT
-func (s *AuthService) Register(ctx context.Context, email, password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// Register สร้างบัญชีใหม่: hash, save, คืน access + refresh token
+func (s *AuthService) Register(ctx context.Context, email, password string) (_ *TokenPair, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditRegister, Email: email}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	if err := s.checkPassword(ctx, "password", password, email); err != nil {
+		return nil, err
+	}
+	hash, err := s.hasher.Hash(password)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	user := &domain.User{Email: email, PasswordHash: hash, Roles: []string{domain.RoleUser}}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, userErr(err)
 	}
-	user := &domain.User{Email: email, PasswordHash: string(hash)}
-	if err := s.repo.Create(user); err != nil {
-		return "", err
+	ev.ActorID, ev.SubjectID = user.ID, user.ID
+	// บัญชีสร้างแล้ว ส่งไม่สำเร็จให้ผู้ใช้ขอส่งใหม่ได้ผ่าน SendVerificationEmail
+	// ส่งหลังตอบกลับ เวลาตอบจึงไม่ขึ้นกับ SMTP
+	v := *user
+	s.sendInBackground(ctx, "verification", v.Email, func(ctx context.Context) error {
+		return s.sendVerification(ctx, &v)
+	})
+	if s.requireVerifiedEmail {
+		return &TokenPair{VerificationRequired: true}, nil
 	}
-	return s.generateToken(user.ID)
+	return s.issueTokens(ctx, user, "")
 }
 
-// Login ตรวจ credentials แล้วคืน JWT
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
-	s.mu.Lock()
-	// ปลดล็อกเมื่อออกจากฟังก์ชันเสมอ
-	defer s.mu.Unlock()
+// Login ตรวจ credentials แล้วคืน access + refresh token
+// นับความล้มเหลวทั้งต่ออีเมลและต่อ IP ของผู้เรียก ถ้า key ใดถูกล็อกอยู่จะไม่ตรวจรหัสผ่านเลย
+func (s *AuthService) Login(ctx context.Context, email, password string) (_ *TokenPair, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditLogin, Email: email}
+	defer func() { s.audit(ctx, &ev, err) }()
 
-	// Rate limiting: สูงสุด 5 failed attempts ใน 1 นาที
-	now := time.Now()
-	attempts := s.attempts[email]
-	// กรองเฉพาะ attempts ที่ยังไม่เกิน 1 นาที
-	var recent []time.Time
-	for _, ts := range attempts {
-		if now.Sub(ts) < time.Minute {
-			recent = append(recent, ts)
+	limits := s.loginKeys(ctx, email)
+	if err := s.checkLimit(ctx, ErrTooManyLoginAttempts, limits...); err != nil {
+		return nil, err
+	}
+
+	// ตรวจสอบ credentials
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
+		// storage ล้มเหลวหรือ request ถูกยกเลิก ไม่นับเป็นการเดารหัสผิด
+		return nil, err
+	}
+	ok := false
+	if err == nil {
+		ev.SubjectID = user.ID
+		if ok, err = s.hasher.Verify(password, user.PasswordHash); err != nil {
+			return nil, err
 		}
+	} else {
+		s.verifyDummy(password)
+	}
+	if !ok {
+		if err := s.recordFailure(ctx, ErrTooManyLoginAttempts, limits...); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
 	}
-	// อัปเดต map ด้วย recent attempts
-	s.attempts[email] = recent
 
-	if len(recent) >= 5 {
-		return "", errors.New("too many login attempts; please try again later")
+	// สำเร็จ: ล้างเฉพาะ key ของบัญชี ตัวนับของ IP ยังอยู่ เพื่อไม่ให้ login บัญชีตัวเองล้างโควต้าการเดาบัญชีอื่น
+	if err := s.limiter.Reset(ctx, limits[0].key); err != nil {
+		return nil, err
 	}
+	s.upgradeHash(ctx, user, password)
 
-	// ตรวจสอบ credentials
-	user, err := s.repo.FindByEmail(email)
-	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
-		// เพิ่ม failed attempt ทันทีภายใต้ล็อก
-		s.attempts[email] = append(s.attempts[email], now)
-		return "", errors.New("invalid credentials")
+	if s.requireVerifiedEmail && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
 	}
 
-	// ถ้าสำเร็จ ลบประวัติ attempts ทั้งหมด
-	delete(s.attempts, email)
+	ev.ActorID = user.ID
+	// เปิด MFA ไว้: ยังไม่ออก token จริง ให้ไปยืนยันรหัสที่ VerifyMFA ก่อน
+	if user.MFAEnabled {
+		challenge, err := s.generateChallenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		ev.Reason = "MFA_REQUIRED"
+		return &TokenPair{MFAToken: challenge}, nil
+	}
 
-	return s.generateToken(user.ID)
+	return s.issueTokens(ctx, user, "")
 }
 
-// Logout แปลง rawToken → ดึง expiresAt → บันทึกลง blacklist
-func (s *AuthService) Logout(ctx context.Context, rawToken string) error {
-	tok, err := jwt.ParseWithClaims(rawToken, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(s.jwtSecret), nil
-	})
+// upgradeHash hash รหัสผ่านใหม่ด้วย hasher ปัจจุบัน ถ้า hash ที่เก็บไว้ใช้ algorithm หรือ parameter เก่า
+// ทำได้เฉพาะตอนที่รู้รหัสผ่านจริง (login สำเร็จ) ล้มเหลวก็แค่ log ไว้ ไม่กระทบการ login
+func (s *AuthService) upgradeHash(ctx context.Context, u *domain.User, plain string) {
+	if !s.hasher.NeedsRehash(u.PasswordHash) {
+		return
+	}
+	hash, err := s.hasher.Hash(plain)
+	if err == nil {
+		u.PasswordHash = hash
+		err = s.repo.Update(ctx, u)
+	}
+	if err != nil {
+		log.Printf("failed to upgrade password hash for user %s: %v", u.ID, err)
+	}
+}
+
+// Logout แปลง rawToken → บันทึก jti ลง blacklist จนถึง expiresAt ของ token
+func (s *AuthService) Logout(ctx context.Context, rawToken string) (err error) {
+	ev := domain.AuditEvent{Type: domain.AuditLogout}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	claims, err := s.parseToken(rawToken)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	ev.ActorID, ev.SubjectID = claims.Subject, claims.Subject
+	return s.tokenRepo.Blacklist(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// LogoutAll เพิกถอน access token และ refresh token ทุกตัวของผู้เรียกที่ออกก่อนตอนนี้ (logout ทุกเครื่อง)
+// watermark ต้องอยู่จนกว่า token ที่ออกก่อนหน้าจะหมดอายุหมด คือนานเท่าอายุ refresh token
+func (s *AuthService) LogoutAll(ctx context.Context) (err error) {
+	ev := domain.AuditEvent{Type: domain.AuditLogoutAll}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	p, err := principalFromCtx(ctx)
 	if err != nil {
 		return err
 	}
-	claims, ok := tok.Claims.(*jwt.RegisteredClaims)
-	if !ok || !tok.Valid {
-		return errors.New("invalid token")
+	ev.SubjectID = p.UserID
+	now := time.Now()
+	return s.tokenRepo.RevokeIssuedBefore(ctx, p.UserID, now, now.Add(max(s.accessTTL, s.refreshTTL)))
+}
+
+// revokedByLogoutAll เช็คว่า token ของ subject ที่ออกเมื่อ issuedAt ถูกเพิกถอนด้วย LogoutAll หรือไม่
+// iat ของ access token ละเอียดแค่วินาที token ที่ออกในวินาทีเดียวกับ LogoutAll จึงถูกเพิกถอนด้วย
+func (s *AuthService) revokedByLogoutAll(ctx context.Context, subject string, issuedAt time.Time) (bool, error) {
+	before, err := s.tokenRepo.RevokedBefore(ctx, subject)
+	if err != nil || before.IsZero() {
+		return false, err
 	}
-	return s.tokenRepo.Blacklist(rawToken, claims.ExpiresAt.Time)
+	return issuedAt.Before(before), nil
 }
 
-// ListUsers ดึงรายชื่อผู้ใช้พร้อม filter + pagination
+// ListUsers ดึงรายชื่อผู้ใช้พร้อม filter + pagination (ต้องมี permission users:read)
 func (s *AuthService) ListUsers(ctx context.Context, filterName, filterEmail string, page, size int) ([]domain.User, int64, error) {
-	//ถ้าอยากจำกัดเฉพาะ admin ให้ เช็ค metadata จาก ctx ตรงนี้
-	users, total, err := s.repo.FindAll(filterName, filterEmail, page, size)
+	if _, err := s.requirePermission(ctx, domain.PermUsersRead); err != nil {
+		return nil, 0, err
+	}
+	users, total, err := s.repo.FindAll(ctx, filterName, filterEmail, page, size)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -124,128 +278,276 @@ func (s *AuthService) ListUsers(ctx context.Context, filterName, filterEmail str
 	return out, total, nil
 }
 
-// GetProfile ดึง profile ของตัวเอง
+// GetProfile ดึง profile ของตัวเอง (หรือของคนอื่นถ้ามี permission users:read)
 func (s *AuthService) GetProfile(ctx context.Context, id string) (domain.User, error) {
-	sub, err := s.subjectFromCtx(ctx)
+	p, err := principalFromCtx(ctx)
 	if err != nil {
 		return domain.User{}, err
 	}
-	if sub != id {
-		return domain.User{}, errors.New("permission denied")
+	if p.UserID != id && !domain.HasPermission(p.Roles, domain.PermUsersRead) {
+		return domain.User{}, ErrPermissionDenied
 	}
-	u, err := s.repo.FindByID(id)
+	u, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		return domain.User{}, err
+		return domain.User{}, userErr(err)
 	}
 	return *u, nil
 }
 
 // UpdateProfile ให้แก้ไข email (หรือ field อื่นได้ตามต้องการ)
-func (s *AuthService) UpdateProfile(ctx context.Context, id, email string) (domain.User, error) {
-	sub, err := s.subjectFromCtx(ctx)
+func (s *AuthService) UpdateProfile(ctx context.Context, id, email string) (_ domain.User, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditProfileUpdate, SubjectID: id, Email: email}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	p, err := principalFromCtx(ctx)
 	if err != nil {
 		return domain.User{}, err
 	}
-	if sub != id {
-		return domain.User{}, errors.New("permission denied")
+	if p.UserID != id {
+		return domain.User{}, ErrPermissionDenied
 	}
-	u, err := s.repo.FindByID(id)
+	u, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		return domain.User{}, err
+		return domain.User{}, userErr(err)
 	}
+	changed := u.Email != email
 	u.Email = email
-	if err := s.repo.Update(u); err != nil {
-		return domain.User{}, err
+	if changed {
+		// อีเมลใหม่ต้องยืนยันใหม่
+		u.EmailVerified = false
+		u.EmailVerifiedAt = nil
+	}
+	if err := s.repo.Update(ctx, u); err != nil {
+		return domain.User{}, userErr(err)
+	}
+	if changed {
+		v := *u
+		s.sendInBackground(ctx, "verification", v.Email, func(ctx context.Context) error {
+			return s.sendVerification(ctx, &v)
+		})
 	}
 	return *u, nil
 }
 
 // DeleteProfile ทำ soft delete
-func (s *AuthService) DeleteProfile(ctx context.Context, id string) error {
-	sub, err := s.subjectFromCtx(ctx)
+func (s *AuthService) DeleteProfile(ctx context.Context, id string) (err error) {
+	ev := domain.AuditEvent{Type: domain.AuditProfileDelete, SubjectID: id}
+	defer func() { s.audit(ctx, &ev, err) }()
 
+	p, err := principalFromCtx(ctx)
 	if err != nil {
 		return err
 	}
-	if sub != id {
-		return errors.New("permission denied")
+	if p.UserID != id {
+		return ErrPermissionDenied
 	}
-	return s.repo.SoftDelete(id)
+	return userErr(s.repo.SoftDelete(ctx, id))
 
 }
 
-// subjectFromCtx ดึง JWT subject จาก metadata: "authorization: Bearer <token>"
-func (s *AuthService) subjectFromCtx(ctx context.Context) (string, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return "", errors.New("missing metadata")
-	}
-	auth := md["authorization"]
-	if len(auth) == 0 {
-		return "", errors.New("missing authorization header")
-	}
-	parts := strings.SplitN(auth[0], " ", 2)
-	if len(parts) != 2 {
-		return "", errors.New("invalid authorization format")
-	}
-	raw := parts[1]
-	tok, err := jwt.ParseWithClaims(raw, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(s.jwtSecret), nil
-	})
-	if err != nil {
-		return "", err
-	}
-	claims, ok := tok.Claims.(*jwt.RegisteredClaims)
-	if !ok || !tok.Valid {
-		return "", errors.New("invalid token")
-	}
-	return claims.Subject, nil
-}
-
 // RequestPasswordReset สั่งสร้าง reset token
-func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
-    user, err := s.repo.FindByEmail(email)
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) (err error) {
+    ev := domain.AuditEvent{Type: domain.AuditPasswordResetRequest, Email: email}
+    defer func() { s.audit(ctx, &ev, err) }()
+
+    // นับทุกคำขอเป็นโควต้า (ต่ออีเมลและต่อ IP) รวมอีเมลที่ไม่มีบัญชี เหมือน SendVerificationEmail
+    limits := append([]limitKey{{ratelimit.EmailKey("reset", email), s.accountPolicy}}, s.ipKeys(ctx)...)
+    if err := s.checkLimit(ctx, ErrTooManyPasswordResets, limits...); err != nil {
+        return err
+    }
+    if err := s.recordFailure(ctx, ErrTooManyPasswordResets, limits...); err != nil && !errors.Is(err, ErrTooManyPasswordResets) {
+        return err
+    }
+
+    user, err := s.repo.FindByEmail(ctx, email)
     if err != nil {
-        // แกล้งทำเหมือนสำเร็จ เพื่อไม่บอกว่ามีหรือไม่มี user
+        // แกล้งทำเหมือนสำเร็จ เพื่อไม่บอกว่ามีหรือไม่มี user (audit log บันทึกตามจริง)
+        ev.Outcome, ev.Reason = domain.AuditFailure, auditReason(userErr(err))
         return nil
     }
-    token := uuid.NewString()
-    expires := time.Now().Add(15 * time.Minute)
-    if err := s.resetRepo.Create(token, user.ID, expires); err != nil {
+    ev.SubjectID = user.ID
+    // สร้าง token และส่งอีเมลหลังตอบกลับแล้ว เวลาตอบจึงไม่บอกว่ามีบัญชีนี้หรือไม่
+    // ส่งไม่สำเร็จก็แค่ log ไว้ เหมือนกรณีไม่พบ user
+    s.sendInBackground(ctx, "password reset", user.Email, func(ctx context.Context) error {
+        token := uuid.NewString()
+        if err := s.resetRepo.Create(ctx, token, user.ID, time.Now().Add(resetTTL)); err != nil {
+            return err
+        }
+        return s.sendTokenEmail(ctx, mail.TemplatePasswordReset, user.Email, "/reset-password", token, resetTTL)
+    })
+    return nil
+}
+
+const (
+    // backgroundMailTimeout เวลาสูงสุดของงานส่งอีเมลที่ทำหลังตอบผู้เรียกแล้ว (รวม retry ของ SMTP)
+    backgroundMailTimeout = time.Minute
+    // defaultBackgroundMails จำนวนงานส่งอีเมลใน background ที่ทำพร้อมกันได้
+    defaultBackgroundMails = 64
+)
+
+// WithBackgroundMailLimit กำหนดจำนวนงานส่งอีเมลใน background ที่ทำพร้อมกันได้
+func WithBackgroundMailLimit(n int) Option {
+    return func(s *AuthService) {
+        if n > 0 {
+            s.mailSlots = make(chan struct{}, n)
+        }
+    }
+}
+
+// sendInBackground รัน send ใน goroutine ด้วย context ที่ไม่ถูกยกเลิกตาม request (แต่ยังมี timeout)
+// ถ้างานเต็มทุก slot จะทิ้งงานนี้แล้ว log ไว้ แทนที่จะสร้าง goroutine เพิ่มไม่จำกัดเมื่อ SMTP ช้า
+// ผู้ใช้ขอส่งใหม่ได้ภายหลัง
+func (s *AuthService) sendInBackground(ctx context.Context, kind, to string, send func(context.Context) error) {
+    select {
+    case s.mailSlots <- struct{}{}:
+    default:
+        log.Printf("dropped %s email to %s: too many emails in flight", kind, to)
+        return
+    }
+    ctx = context.WithoutCancel(ctx)
+    go func() {
+        defer func() { <-s.mailSlots }()
+        ctx, cancel := context.WithTimeout(ctx, backgroundMailTimeout)
+        defer cancel()
+        if err := send(ctx); err != nil {
+            log.Printf("failed to send %s email to %s: %v", kind, to, err)
+        }
+    }()
+}
+
+// resetTTL อายุของ reset token
+const resetTTL = 15 * time.Minute
+
+// WithLinkBaseURL กำหนด URL ของหน้าเว็บที่ลิงก์ในอีเมลชี้ไป เช่น https://app.example.com
+func WithLinkBaseURL(base string) Option {
+    return func(s *AuthService) {
+        if base != "" {
+            s.linkBaseURL = strings.TrimRight(base, "/")
+        }
+    }
+}
+
+// sendTokenEmail ส่งอีเมลที่มีลิงก์พร้อม token ตาม template
+func (s *AuthService) sendTokenEmail(ctx context.Context, template, to, path, token string, ttl time.Duration) error {
+    msg, err := mail.Render(template, to, map[string]interface{}{
+        "Email":     to,
+        "Link":      s.linkBaseURL + path + "?token=" + url.QueryEscape(token),
+        "ExpiresIn": humanDuration(ttl),
+    })
+    if err != nil {
         return err
     }
-    log.Printf("Password reset token for %s: %s\n", email, token)
-    return nil
+    return s.mailer.Send(ctx, msg)
+}
+
+// humanDuration แปลง duration เป็นข้อความสำหรับอีเมล เช่น "15 minutes", "24 hours"
+func humanDuration(d time.Duration) string {
+    if d >= time.Hour && d%time.Hour == 0 {
+        return fmt.Sprintf("%d hours", d/time.Hour)
+    }
+    return fmt.Sprintf("%d minutes", d/time.Minute)
 }
 
-// ResetPassword ตรวจ token, เปลี่ยนรหัสผ่าน และลบ token ทิ้ง
-func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
-    userID, err := s.resetRepo.Verify(token)
+// ResetPassword ใช้ token (ครั้งเดียว), เปลี่ยนรหัสผ่าน และยกเลิก token อื่นที่ค้างอยู่ของผู้ใช้
+// ตรวจ policy ก่อนใช้ token เพื่อให้รหัสที่ไม่ผ่านไม่ทำให้ลิงก์หมดอายุ ยกเว้น rule อีเมลที่ต้องรู้ผู้ใช้ก่อน
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+    ev := domain.AuditEvent{Type: domain.AuditPasswordReset}
+    defer func() { s.audit(ctx, &ev, err) }()
+
+    if err := s.checkPassword(ctx, "new_password", newPassword, ""); err != nil {
+        return err
+    }
+    userID, err := s.resetRepo.Consume(ctx, token)
     if err != nil {
-        return errors.New("invalid or expired reset token")
+        return lookupErr(err, ErrInvalidResetToken)
     }
-    hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+    ev.SubjectID = userID
+    u, err := s.repo.FindByID(ctx, userID)
     if err != nil {
         return err
     }
-    u, err := s.repo.FindByID(userID)
+    ev.Email = u.Email
+    if err := s.checkPassword(ctx, "new_password", newPassword, u.Email); err != nil {
+        return err
+    }
+    hashed, err := s.hasher.Hash(newPassword)
     if err != nil {
         return err
     }
-    u.PasswordHash = string(hashed)
-    if err := s.repo.Update(u); err != nil {
+    u.PasswordHash = hashed
+    if err := s.repo.Update(ctx, u); err != nil {
         return err
     }
-    return s.resetRepo.Delete(token)
+    return s.resetRepo.DeleteByUser(ctx, userID)
+}
+
+// generateToken สร้าง access token ของผู้ใช้ที่ login เอง โดยแนบ role ไว้ใน claim "roles"
+func (s *AuthService) generateToken(u *domain.User) (string, error) {
+	return s.signAccessToken(u.ID, accessClaims{Roles: rolesOf(u)})
+}
+
+// signAccessToken เติม subject, jti และอายุตาม accessTTL ให้ claims แล้วเซ็นด้วย active key พร้อม kid ใน header
+// jti ใช้เพิกถอน token ทีละตัวโดยไม่ต้องเก็บ token ดิบ
+func (s *AuthService) signAccessToken(subject string, claims accessClaims) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   subject,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+	}
+	key := s.keyRing.Active()
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.SigningKey())
 }
 
-// generateToken สร้าง JWT ด้วย HS256
-func (s *AuthService) generateToken(userID string) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+// parseToken ตรวจลายเซ็นและอายุของ JWT แล้วคืน claims
+func (s *AuthService) parseToken(raw string) (*accessClaims, error) {
+	tok, err := jwt.ParseWithClaims(raw, &accessClaims{}, s.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := tok.Claims.(*accessClaims)
+	if !ok || !tok.Valid {
+		return nil, errors.New("invalid token")
+	}
+	// access token ไม่มี aud: challenge token ของ MFA และ ID token ใช้แทน access token ไม่ได้
+	// และต้องมี jti (token รุ่นเก่าที่ไม่มี jti เพิกถอนไม่ได้ จึงไม่รับ)
+	if len(claims.Audience) > 0 || claims.ID == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// keyFunc เลือก key ตรวจสอบจาก kid ใน key ring และบังคับให้ alg ตรงกับ key
+// (token รุ่นเก่าที่ไม่มี kid จะตรวจด้วย active key)
+func (s *AuthService) keyFunc(t *jwt.Token) (interface{}, error) {
+	key := s.keyRing.Active()
+	if kid, _ := t.Header["kid"].(string); kid != "" {
+		k, err := s.keyRing.Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.VerifyKey(), nil
+}
+
+// JWKS คืน public key ทุกตัวที่ยังใช้ตรวจ token ได้ (key แบบ HS256 จะไม่ถูกเผยแพร่)
+func (s *AuthService) JWKS() keys.JWKS {
+	set := keys.JWKS{Keys: []keys.JWK{}}
+	for _, k := range s.keyRing.VerificationKeys() {
+		if k.Symmetric() {
+			continue
+		}
+		if jwk, err := k.JWK(); err == nil {
+			set.Keys = append(set.Keys, jwk)
+		}
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return set
 }
 
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/ratelimit"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// waitForMail รอจนมีอีเมลอย่างน้อย n ฉบับ (อีเมลบางชนิดถูกส่งใน background)
+func waitForMail(t *testing.T, s *AuthService, n int) []mail.Message {
+	t.Helper()
+	mailer := s.mailer.(*mail.MemoryMailer)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		msgs := mailer.Messages()
+		if len(msgs) >= n || time.Now().After(deadline) {
+			return msgs
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// mailedToken ดึง token จากลิงก์ในอีเมลที่ส่งถึง to
+func mailedToken(t *testing.T, msg mail.Message, to string) string {
+	t.Helper()
+	i := strings.Index(msg.Text, "token=")
+	if msg.To != to || i < 0 {
+		t.Fatalf("email = %+v, want a link with a token for %s", msg, to)
+	}
+	return strings.Fields(msg.Text[i+len("token="):])[0]
+}
+
+func TestRequestPasswordResetSendsInBackground(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u := mustRegister(t, s, "reset@example.com", true)
+	sent := len(waitForMail(t, s, 1)) // อีเมลยืนยันตอนสมัคร
+
+	if err := s.RequestPasswordReset(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset(unknown) = %v, want nil", err)
+	}
+	// ctx ของ request ถูกยกเลิกทันทีหลังตอบ อีเมลต้องยังถูกส่ง
+	reqCtx, cancel := context.WithCancel(ctx)
+	err := s.RequestPasswordReset(reqCtx, u.Email)
+	cancel()
+	if err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	msgs := waitForMail(t, s, sent+1)
+	if len(msgs) != sent+1 {
+		t.Fatalf("got %d emails, want %d", len(msgs), sent+1)
+	}
+	token := mailedToken(t, msgs[len(msgs)-1], u.Email)
+	if err := s.ResetPassword(ctx, token, "another-horse-battery-7"); err != nil {
+		t.Fatalf("ResetPassword with the mailed token: %v", err)
+	}
+}
+
+func TestLoginLockoutIgnoresEmailCase(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	mustRegister(t, s, "case@example.com", true)
+
+	// สลับตัวพิมพ์และเติมช่องว่างก็ยังนับเป็นบัญชีเดียวกัน
+	variants := []string{"case@example.com", "CASE@example.com", " Case@Example.com ", "case@EXAMPLE.com", "cAse@example.com"}
+	var err error
+	for _, email := range variants {
+		_, err = s.Login(ctx, email, "wrong-password")
+	}
+	if !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("failure %d = %v, want ErrTooManyLoginAttempts", len(variants), err)
+	}
+	if _, err := s.Login(ctx, "case@example.com", testPassword); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("correct password on a locked account = %v, want ErrTooManyLoginAttempts", err)
+	}
+}
+
+func TestRequestPasswordResetIsRateLimited(t *testing.T) {
+	s := newTestService(t)
+	u := mustRegister(t, s, "limit@example.com", true)
+	ctx := WithClient(context.Background(), Client{IP: "203.0.113.9"})
+
+	var err error
+	calls := 0
+	for ; calls < 20 && err == nil; calls++ {
+		err = s.RequestPasswordReset(ctx, u.Email)
+	}
+	if !errors.Is(err, ErrTooManyPasswordResets) {
+		t.Fatalf("RequestPasswordReset never limited: %v", err)
+	}
+	if calls != ratelimit.DefaultPolicy.MaxAttempts+1 {
+		t.Fatalf("limited after %d calls, want %d", calls, ratelimit.DefaultPolicy.MaxAttempts+1)
+	}
+	if ev := lastAudit(t, s, domain.AuditPasswordResetRequest); ev.Outcome != domain.AuditFailure || ev.Reason != ErrTooManyPasswordResets.Reason {
+		t.Fatalf("limited request event = %+v", ev)
+	}
+
+	// อีเมลที่ไม่มีบัญชีก็ถูกนับเหมือนกัน ไม่บอกว่ามีบัญชีหรือไม่
+	for i := 0; i < ratelimit.DefaultPolicy.MaxAttempts; i++ {
+		err = s.RequestPasswordReset(context.Background(), "nobody@example.com")
+	}
+	if err != nil {
+		t.Fatalf("last allowed request for an unknown email = %v", err)
+	}
+	if err := s.RequestPasswordReset(context.Background(), "nobody@example.com"); !errors.Is(err, ErrTooManyPasswordResets) {
+		t.Fatalf("request over the limit for an unknown email = %v, want ErrTooManyPasswordResets", err)
+	}
+}
+
+// blockingMailer ค้างทุกการส่งจนกว่าจะปิด release
+type blockingMailer struct {
+	release chan struct{}
+	mu      sync.Mutex
+	started int
+	sent    int
+}
+
+func (m *blockingMailer) Send(ctx context.Context, _ mail.Message) error {
+	m.mu.Lock()
+	m.started++
+	m.mu.Unlock()
+	select {
+	case <-m.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	m.mu.Lock()
+	m.sent++
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *blockingMailer) counts() (started, sent int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started, m.sent
+}
+
+// waitStarted รอจนมีการส่งเริ่มแล้วอย่างน้อย n ครั้ง
+func (m *blockingMailer) waitStarted(n int) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		started, _ := m.counts()
+		if started >= n || time.Now().After(deadline) {
+			return started
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestVerificationMailDoesNotBlockRegister(t *testing.T) {
+	mailer := &blockingMailer{release: make(chan struct{})}
+	defer close(mailer.release)
+	s := newTestService(t)
+	s.mailer = mailer
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() {
+		if _, err := s.Register(ctx, "slow@example.com", testPassword); err != nil {
+			done <- err
+			return
+		}
+		u, _ := s.repo.FindByEmail(ctx, "slow@example.com")
+		_, err := s.UpdateProfile(WithPrincipal(ctx, &Principal{UserID: u.ID}), u.ID, "slower@example.com")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Register/UpdateProfile: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Register/UpdateProfile waited for the mailer")
+	}
+	if n := mailer.waitStarted(2); n != 2 {
+		t.Fatalf("%d verification emails started, want 2", n)
+	}
+}
+
+func TestBackgroundMailIsBounded(t *testing.T) {
+	mailer := &blockingMailer{release: make(chan struct{})}
+	s := newTestService(t, WithBackgroundMailLimit(2))
+	s.mailer = mailer
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := s.Register(ctx, "user"+strconv.Itoa(i)+"@example.com", testPassword); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	mailer.waitStarted(2)
+
+	// ทุก slot ค้างอยู่: งานใหม่ถูกทิ้ง ไม่สร้าง goroutine รอเพิ่ม
+	for i := 0; i < 5; i++ {
+		if err := s.SendVerificationEmail(ctx, "user0@example.com"); err != nil {
+			t.Fatalf("SendVerificationEmail: %v", err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if started, _ := mailer.counts(); started != 2 {
+		t.Fatalf("%d emails started with a limit of 2", started)
+	}
+
+	// slot ว่างแล้วส่งได้อีก
+	close(mailer.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.mailSlots) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := s.RequestPasswordReset(ctx, "user1@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	if n := mailer.waitStarted(3); n != 3 {
+		t.Fatalf("%d emails started after the slots freed up, want 3", n)
+	}
+}
+
+// newServiceWithKey สร้าง AuthService แบบ newTestService แต่เซ็น token ด้วย key ที่ให้มา
+func newServiceWithKey(t *testing.T, key *keys.Key) *AuthService {
+	t.Helper()
+	ring, err := keys.NewRing(key, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	return NewAuthService(memory.NewUserRepository(), memory.NewTokenRepository(), memory.NewPasswordResetRepository(),
+		memory.NewEmailVerificationRepository(), memory.NewRefreshTokenRepository(), ring, mail.NewMemoryMailer())
+}
+
+func TestKeyFuncRejectsAlgorithmMismatch(t *testing.T) {
+	ctx := context.Background()
+	rsaKey, err := keys.Generate(keys.RS256)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	s := newServiceWithKey(t, rsaKey)
+	mustRegister(t, s, "alg@example.com", true)
+	pair, err := s.Login(ctx, "alg@example.com", testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := s.Authenticate(ctx, pair.AccessToken); err != nil {
+		t.Fatalf("Authenticate(genuine token): %v", err)
+	}
+
+	// เอา claims ของ token จริงมาเซ็นใหม่ด้วยอัลกอริทึมอื่นโดยอ้าง kid ของ RSA key
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(pair.AccessToken, claims); err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	forge := func(method jwt.SigningMethod, key interface{}) string {
+		tok := jwt.NewWithClaims(method, claims)
+		tok.Header["kid"] = rsaKey.ID
+		raw, err := tok.SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString(%s): %v", method.Alg(), err)
+		}
+		return raw
+	}
+	// public key ของ RSA เผยแพร่ใน JWKS: ถ้าเชื่อ alg ใน header จะถูกใช้เป็น HMAC secret ได้
+	pkix, err := x509.MarshalPKIXPublicKey(rsaKey.VerifyKey())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})
+	ecKey, _ := keys.Generate(keys.ES256)
+	otherRSA, _ := keys.Generate(keys.RS256)
+
+	for _, tc := range []struct {
+		name     string
+		raw      string
+		mismatch bool // alg ใน header ไม่ตรงกับ key ของ kid: keyFunc ต้องไม่คืน key
+	}{
+		{"HS256 keyed with the RSA public key", forge(jwt.SigningMethodHS256, pubPEM), true},
+		{"ES256 with the RSA kid", forge(jwt.SigningMethodES256, ecKey.SigningKey()), true},
+		{"none", forge(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType), true},
+		{"RS256 signed by another key", forge(jwt.SigningMethodRS256, otherRSA.SigningKey()), false},
+	} {
+		if _, err := s.Authenticate(ctx, tc.raw); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Authenticate = %v, want ErrInvalidToken", tc.name, err)
+		}
+		if _, err := s.keyFunc(mustParseUnverified(t, tc.raw)); tc.mismatch && err == nil {
+			t.Errorf("%s: keyFunc returned a key", tc.name)
+		}
+	}
+}
+
+func mustParseUnverified(t *testing.T, raw string) *jwt.Token {
+	t.Helper()
+	tok, _, err := new(jwt.Parser).ParseUnverified(raw, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	return tok
+}
+
+func TestTokensAcrossKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	prev, _ := keys.Generate(keys.ES256)
+	s := newServiceWithKey(t, prev)
+	mustRegister(t, s, "rotate-key@example.com", true)
+	login := func() string {
+		t.Helper()
+		pair, err := s.Login(ctx, "rotate-key@example.com", testPassword)
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		return pair.AccessToken
+	}
+	old := login()
+
+	next, _ := keys.Generate(keys.ES256)
+	if err := s.keyRing.Rotate(next); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	fresh := login()
+	if kid := mustParseUnverified(t, fresh).Header["kid"]; kid != next.ID {
+		t.Fatalf("new token kid = %v, want %s", kid, next.ID)
+	}
+	// ช่วง overlap: token ที่เซ็นด้วย key เดิมยังใช้ได้
+	if _, err := s.Authenticate(ctx, old); err != nil {
+		t.Fatalf("Authenticate(previous key) during overlap: %v", err)
+	}
+
+	// หมด overlap แล้ว: token ของ key เดิมใช้ไม่ได้ ของ key ใหม่ยังใช้ได้
+	s.keyRing.Prune(time.Now().Add(2 * time.Hour))
+	if _, err := s.Authenticate(ctx, old); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Authenticate(pruned key) = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Authenticate(ctx, fresh); err != nil {
+		t.Fatalf("Authenticate(active key): %v", err)
+	}
+
+	// kid ที่ไม่รู้จักถูกปฏิเสธ แม้ลายเซ็นจะมาจาก active key
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(fresh, claims); err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	tok := jwt.NewWithClaims(next.Method(), claims)
+	tok.Header["kid"] = "unknown-kid"
+	raw, err := tok.SignedString(next.SigningKey())
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if _, err := s.Authenticate(ctx, raw); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Authenticate(unknown kid) = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,22 @@
+package service
+
+import "context"
+
+// Client คือข้อมูลของเครื่องที่เรียกเข้ามา (ใช้กับ rate limit และ audit log)
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// WithClient แนบข้อมูลของผู้เรียกไว้ใน ctx (เรียกจาก interceptor ของ transport)
+func WithClient(ctx context.Context, c Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// ClientFromContext ดึงข้อมูลของผู้เรียก ช่องที่ไม่รู้เป็น ""
+func ClientFromContext(ctx context.Context) Client {
+	c, _ := ctx.Value(clientKey{}).(Client)
+	return c
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/ratelimit"
+	"github.com/google/uuid"
+)
+
+// verificationTTL อายุของลิงก์/token ยืนยันอีเมล
+const verificationTTL = 24 * time.Hour
+
+// ErrEmailNotVerified ถูกคืนจาก Login เมื่อเปิด policy บังคับยืนยันอีเมล
+var ErrEmailNotVerified = FailedPrecondition("EMAIL_NOT_VERIFIED", "email address not verified")
+
+// WithRequireVerifiedEmail เปิด policy ห้าม Login จนกว่าจะยืนยันอีเมล
+func WithRequireVerifiedEmail(required bool) Option {
+	return func(s *AuthService) {
+		s.requireVerifiedEmail = required
+	}
+}
+
+// SendVerificationEmail ส่ง token ยืนยันอีเมลอีกครั้ง
+// คืน nil เสมอถ้าไม่พบผู้ใช้หรือยืนยันแล้ว เพื่อไม่บอกว่ามีบัญชีนี้หรือไม่
+func (s *AuthService) SendVerificationEmail(ctx context.Context, email string) error {
+	// นับทุกคำขอเป็นโควต้า (ต่ออีเมลและต่อ IP) รวมอีเมลที่ไม่มีบัญชี เพื่อไม่ให้ใช้ส่งอีเมลรัว ๆ
+	limits := append([]limitKey{{ratelimit.EmailKey("verify", email), s.accountPolicy}}, s.ipKeys(ctx)...)
+	if err := s.checkLimit(ctx, ErrTooManyVerificationEmails, limits...); err != nil {
+		return err
+	}
+	if err := s.recordFailure(ctx, ErrTooManyVerificationEmails, limits...); err != nil && !errors.Is(err, ErrTooManyVerificationEmails) {
+		return err
+	}
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil || user.DeletedAt != nil || user.EmailVerified {
+		return nil
+	}
+	// ส่งหลังตอบกลับแล้ว เหมือน RequestPasswordReset
+	s.sendInBackground(ctx, "verification", email, func(ctx context.Context) error {
+		return s.sendVerification(ctx, user)
+	})
+	return nil
+}
+
+// VerifyEmail ตรวจ token แล้วทำเครื่องหมายว่าอีเมลได้รับการยืนยัน
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := s.verifyRepo.Verify(ctx, token)
+	if err != nil {
+		return lookupErr(err, ErrInvalidVerificationToken)
+	}
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return userErr(err)
+	}
+	// ผู้ใช้เปลี่ยนอีเมลไปแล้วหลังจากส่ง token
+	if u.Email != email {
+		return ErrInvalidVerificationToken
+	}
+	now := time.Now()
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &now
+	if err := s.repo.Update(ctx, u); err != nil {
+		return err
+	}
+	return s.verifyRepo.Delete(ctx, token)
+}
+
+// sendVerification สร้าง token ใหม่สำหรับอีเมลปัจจุบันของผู้ใช้แล้วส่งลิงก์ยืนยัน
+func (s *AuthService) sendVerification(ctx context.Context, u *domain.User) error {
+	token := uuid.NewString()
+	if err := s.verifyRepo.Create(ctx, token, u.ID, u.Email, time.Now().Add(verificationTTL)); err != nil {
+		return err
+	}
+	return s.sendTokenEmail(ctx, mail.TemplateEmailVerification, u.Email, "/verify-email", token, verificationTTL)
+}
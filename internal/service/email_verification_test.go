@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/ratelimit"
+)
+
+func TestSendVerificationEmailIsRateLimited(t *testing.T) {
+	s := newTestService(t)
+	u := mustRegister(t, s, "verify@example.com", false)
+	sent := len(waitForMail(t, s, 1))
+	ctx := WithClient(context.Background(), Client{IP: "203.0.113.7"})
+
+	var err error
+	calls := 0
+	for ; calls < 20 && err == nil; calls++ {
+		err = s.SendVerificationEmail(ctx, u.Email)
+	}
+	if !errors.Is(err, ErrTooManyVerificationEmails) {
+		t.Fatalf("SendVerificationEmail never limited: %v", err)
+	}
+	if calls != ratelimit.DefaultPolicy.MaxAttempts+1 {
+		t.Fatalf("limited after %d calls, want %d", calls, ratelimit.DefaultPolicy.MaxAttempts+1)
+	}
+	if msgs := waitForMail(t, s, sent+calls-1); len(msgs) != sent+calls-1 {
+		t.Fatalf("got %d emails, want %d", len(msgs), sent+calls-1)
+	}
+
+	// อีเมลที่ไม่มีบัญชีก็ถูกนับเหมือนกัน ไม่บอกว่ามีบัญชีหรือไม่
+	for i := 0; i < ratelimit.DefaultPolicy.MaxAttempts; i++ {
+		err = s.SendVerificationEmail(context.Background(), "nobody@example.com")
+	}
+	if err != nil {
+		t.Fatalf("last allowed request for an unknown email = %v", err)
+	}
+	if err := s.SendVerificationEmail(context.Background(), "nobody@example.com"); !errors.Is(err, ErrTooManyVerificationEmails) {
+		t.Fatalf("request over the limit for an unknown email = %v, want ErrTooManyVerificationEmails", err)
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	s := newPasskeyService(t, WithRequireVerifiedEmail(true))
+	const email = "pending@example.com"
+
+	pair, err := s.Register(ctx, email, testPassword)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !pair.VerificationRequired || pair.AccessToken != "" || pair.RefreshToken != "" {
+		t.Fatalf("Register = %+v, want no tokens until the email is verified", pair)
+	}
+	u, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	a := newSoftAuthenticator(t)
+	registerPasskey(t, s, u, a)
+
+	if _, err := s.Login(ctx, email, testPassword); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("Login before verifying = %v, want ErrEmailNotVerified", err)
+	}
+	// รหัสผิดยังได้ ErrInvalidCredentials: สถานะการยืนยันไม่หลุดให้คนที่ไม่รู้รหัส
+	if _, err := s.Login(ctx, email, "wrong-password-1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with a wrong password = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := passkeyLogin(t, s, a, email); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("passkey login before verifying = %v, want ErrEmailNotVerified", err)
+	}
+
+	// token จากอีเมลตอนสมัครยืนยันได้ และหลังยืนยัน login ได้ทั้งสองทาง
+	token := mailedToken(t, waitForMail(t, s, 1)[0], email)
+	if err := s.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if err := s.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("second VerifyEmail = %v, want ErrInvalidVerificationToken", err)
+	}
+	if _, err := s.Login(ctx, email, testPassword); err != nil {
+		t.Fatalf("Login after verifying: %v", err)
+	}
+	if _, err := passkeyLogin(t, s, a, ""); err != nil {
+		t.Fatalf("passkey login after verifying: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+)
+
+// Code คือประเภทของ error ที่ client ใช้แยกกรณีได้ (transport แปลงเป็น gRPC status code)
+type Code int
+
+const (
+	CodeInvalidArgument Code = iota + 1
+	CodeNotFound
+	CodeAlreadyExists
+	CodeUnauthenticated
+	CodePermissionDenied
+	CodeFailedPrecondition
+	CodeResourceExhausted
+)
+
+// Error คือ error ที่มีประเภทของ service layer
+type Error struct {
+	Code    Code
+	Reason  string // ค่าคงที่แบบ UPPER_SNAKE_CASE สำหรับ google.rpc.ErrorInfo
+	Message string
+
+	// RetryAfter ใช้กับ CodeResourceExhausted: เวลาที่ต้องรอก่อนลองใหม่
+	RetryAfter time.Duration
+	// Violations ใช้กับ CodeInvalidArgument: input ที่ไม่ผ่านการตรวจทีละ field
+	Violations []FieldViolation
+}
+
+// FieldViolation คือเหตุผลหนึ่งข้อที่ field ของ request ไม่ผ่านการตรวจ
+// (transport แปลงเป็น google.rpc.BadRequest)
+type FieldViolation struct {
+	Field       string
+	Reason      string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is ให้ errors.Is เทียบด้วย Code + Reason (RetryAfter/ข้อความต่างกันได้)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Reason == e.Reason
+}
+
+// NotFound สร้าง error ว่าไม่พบข้อมูล
+func NotFound(reason, msg string) *Error {
+	return &Error{Code: CodeNotFound, Reason: reason, Message: msg}
+}
+
+// AlreadyExists สร้าง error ว่าข้อมูลซ้ำ
+func AlreadyExists(reason, msg string) *Error {
+	return &Error{Code: CodeAlreadyExists, Reason: reason, Message: msg}
+}
+
+// Unauthenticated สร้าง error ว่ายืนยันตัวตนไม่ผ่าน
+func Unauthenticated(reason, msg string) *Error {
+	return &Error{Code: CodeUnauthenticated, Reason: reason, Message: msg}
+}
+
+// PermissionDenied สร้าง error ว่าไม่มีสิทธิ์
+func PermissionDenied(reason, msg string) *Error {
+	return &Error{Code: CodePermissionDenied, Reason: reason, Message: msg}
+}
+
+// InvalidArgument สร้าง error ว่า input ไม่ถูกต้อง
+func InvalidArgument(reason, msg string) *Error {
+	return &Error{Code: CodeInvalidArgument, Reason: reason, Message: msg}
+}
+
+// FailedPrecondition สร้าง error ว่าสถานะของบัญชียังไม่พร้อมสำหรับการกระทำนี้
+func FailedPrecondition(reason, msg string) *Error {
+	return &Error{Code: CodeFailedPrecondition, Reason: reason, Message: msg}
+}
+
+// ResourceExhausted สร้าง error ว่าเรียกถี่เกินไป พร้อมเวลาที่ต้องรอ
+func ResourceExhausted(reason, msg string, retryAfter time.Duration) *Error {
+	return &Error{Code: CodeResourceExhausted, Reason: reason, Message: msg, RetryAfter: retryAfter}
+}
+
+// error ที่ service คืนให้ client
+var (
+	ErrUnauthenticated    = Unauthenticated("UNAUTHENTICATED", "unauthenticated")
+	ErrInvalidToken       = Unauthenticated("INVALID_TOKEN", "invalid or expired token")
+	ErrTokenRevoked       = Unauthenticated("TOKEN_REVOKED", "token has been revoked")
+	ErrInvalidCredentials = Unauthenticated("INVALID_CREDENTIALS", "invalid credentials")
+	ErrPermissionDenied   = PermissionDenied("PERMISSION_DENIED", "permission denied")
+
+	ErrUserNotFound = NotFound("USER_NOT_FOUND", "user not found")
+	ErrEmailTaken   = AlreadyExists("EMAIL_TAKEN", "email already registered")
+	ErrUnknownRole  = InvalidArgument("UNKNOWN_ROLE", "unknown role")
+	ErrWeakPassword = InvalidArgument("WEAK_PASSWORD", "password does not meet the password policy")
+
+	ErrInvalidTimestamp = InvalidArgument("INVALID_TIMESTAMP", "timestamps must be RFC 3339")
+	ErrInvalidTimeRange = InvalidArgument("INVALID_TIME_RANGE", "since must be before until")
+
+	ErrTooManyLoginAttempts      = ResourceExhausted("TOO_MANY_LOGIN_ATTEMPTS", "too many login attempts; please try again later", 0)
+	ErrTooManyMFAAttempts        = ResourceExhausted("TOO_MANY_MFA_ATTEMPTS", "too many mfa attempts; please try again later", 0)
+	ErrTooManyVerificationEmails = ResourceExhausted("TOO_MANY_VERIFICATION_EMAILS", "too many verification emails requested; please try again later", 0)
+	ErrTooManyPasswordResets     = ResourceExhausted("TOO_MANY_PASSWORD_RESETS", "too many password reset requests; please try again later", 0)
+
+	ErrInvalidResetToken        = NotFound("INVALID_RESET_TOKEN", "invalid or expired reset token")
+	ErrInvalidVerificationToken = NotFound("INVALID_VERIFICATION_TOKEN", "invalid or expired verification token")
+	ErrInvalidRefreshToken      = Unauthenticated("INVALID_REFRESH_TOKEN", "invalid or expired refresh token")
+	ErrInvalidMFAToken          = Unauthenticated("INVALID_MFA_TOKEN", "invalid or expired mfa token")
+	ErrInvalidMFACode           = Unauthenticated("INVALID_MFA_CODE", "invalid mfa code")
+
+	ErrMFAAlreadyEnabled = FailedPrecondition("MFA_ALREADY_ENABLED", "mfa already enabled")
+	ErrMFANotPending     = FailedPrecondition("MFA_NOT_PENDING", "no pending mfa enrollment")
+	ErrMFANotEnabled     = FailedPrecondition("MFA_NOT_ENABLED", "mfa not enabled")
+	ErrMFACodeMismatch   = InvalidArgument("MFA_CODE_MISMATCH", "invalid mfa code")
+
+	ErrNoPasskeys              = NotFound("NO_PASSKEYS", "no passkeys registered for this account")
+	ErrInvalidPasskeyResponse  = InvalidArgument("INVALID_PASSKEY_RESPONSE", "invalid passkey response")
+	ErrInvalidPasskeySession   = InvalidArgument("INVALID_PASSKEY_SESSION", "invalid or expired passkey session")
+	ErrInvalidPasskeyAssertion = Unauthenticated("INVALID_PASSKEY_ASSERTION", "invalid passkey assertion")
+	ErrPasskeyCloned           = Unauthenticated("PASSKEY_CLONE_DETECTED", "passkey sign counter did not increase; possible cloned authenticator")
+
+	ErrOAuthDisabled           = FailedPrecondition("OAUTH_DISABLED", "oauth authorization server is not configured")
+	ErrOIDCDisabled            = FailedPrecondition("OIDC_DISABLED", "openid connect needs an asymmetric signing key (RS256, ES256 or EdDSA)")
+	ErrInvalidOAuthRequest     = InvalidArgument("INVALID_OAUTH_REQUEST", "invalid oauth request")
+	ErrInvalidOAuthClient      = Unauthenticated("INVALID_CLIENT", "unknown client or invalid client credentials")
+	ErrInvalidClientMetadata   = InvalidArgument("INVALID_CLIENT_METADATA", "invalid client metadata")
+	ErrInvalidRedirectURI      = InvalidArgument("INVALID_REDIRECT_URI", "redirect_uri is not registered for this client")
+	ErrUnsupportedResponseType = InvalidArgument("UNSUPPORTED_RESPONSE_TYPE", "only response_type=code is supported")
+	ErrUnsupportedGrantType    = InvalidArgument("UNSUPPORTED_GRANT_TYPE", "unsupported grant_type")
+	ErrPKCERequired            = InvalidArgument("PKCE_REQUIRED", "code_challenge with code_challenge_method=S256 is required")
+	ErrInvalidScope            = InvalidArgument("INVALID_SCOPE", "requested scope is unknown or not allowed for this client")
+	ErrInvalidGrant            = InvalidArgument("INVALID_GRANT", "invalid, expired or already used authorization code")
+	ErrAccessDenied            = PermissionDenied("ACCESS_DENIED", "the user denied the authorization request")
+	ErrInsufficientScope       = PermissionDenied("INSUFFICIENT_SCOPE", "access token does not grant this operation")
+
+	ErrMachineClientNotFound = NotFound("MACHINE_CLIENT_NOT_FOUND", "machine client not found")
+	ErrMachineClientDisabled = FailedPrecondition("MACHINE_CLIENT_DISABLED", "machine client is disabled")
+)
+
+// retryLater คืน error ResourceExhausted เดิมพร้อมเวลาที่ต้องรอ
+func retryLater(base *Error, after time.Duration) *Error {
+	e := *base
+	e.RetryAfter = after
+	return &e
+}
+
+// withViolations คืน error ประเภทเดิมพร้อมรายการ field ที่ไม่ผ่าน
+func withViolations(base *Error, vs []FieldViolation) *Error {
+	e := *base
+	e.Violations = vs
+	return &e
+}
+
+// withMessage คืน error ประเภทเดิมแต่ใช้ข้อความอื่น
+func withMessage(base *Error, msg string) *Error {
+	e := *base
+	e.Message = msg
+	return &e
+}
+
+// lookupErr คืน err เดิมถ้า storage ล้มเหลวเพราะ context (client ยกเลิกหรือหมดเวลา)
+// ไม่อย่างนั้นคืน notFound ตามเดิม เพื่อไม่ให้ request ที่ถูกตัดกลายเป็น "token ไม่ถูกต้อง"
+func lookupErr(err error, notFound *Error) error {
+	if interrupted(err) {
+		return err
+	}
+	return notFound
+}
+
+// interrupted บอกว่า err เกิดจาก context ถูกยกเลิกหรือหมดเวลา
+func interrupted(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// userErr แปลง error ของ UserRepository เป็น error ของ service
+func userErr(err error) error {
+	switch {
+	case errors.Is(err, repo.ErrUserNotFound), errors.Is(err, repo.ErrInvalidUserID):
+		return ErrUserNotFound
+	case errors.Is(err, repo.ErrDuplicateEmail):
+		return ErrEmailTaken
+	}
+	return err
+}
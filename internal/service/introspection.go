@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+)
+
+// ชนิดของ token ที่ใช้เป็น token_type_hint (RFC 7662 §2.1, RFC 7009 §2.1)
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
+
+// TokenIntrospection คือสถานะของ token (RFC 7662 §2.2)
+// ช่องอื่นนอกจาก Active มีค่าเฉพาะเมื่อ token ยังใช้ได้
+type TokenIntrospection struct {
+	Active    bool
+	TokenType string // TokenTypeAccess หรือ TokenTypeRefresh
+	Subject   string
+	ClientID  string // ว่างถ้าผู้ใช้ login เอง
+	Scopes    []string
+	Roles     []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IntrospectToken บอกว่า token ยังใช้ได้หรือไม่ พร้อมเจ้าของ อายุ scope และ role
+// ผู้เรียกต้องมี permission tokens:introspect หรือเป็น machine client ที่ได้ scope tokens:introspect
+// token ที่ไม่ถูกต้อง หมดอายุ หรือถูกเพิกถอนได้ผล Active = false ไม่ใช่ error
+func (s *AuthService) IntrospectToken(ctx context.Context, token, hint string) (*TokenIntrospection, error) {
+	if err := s.requireIntrospect(ctx); err != nil {
+		return nil, err
+	}
+	for _, typ := range tokenTypes(hint) {
+		var info *TokenIntrospection
+		var err error
+		if typ == TokenTypeAccess {
+			info, err = s.introspectAccessToken(ctx, token)
+		} else {
+			info, err = s.introspectRefreshToken(ctx, token)
+		}
+		if err != nil || info.Active {
+			return info, err
+		}
+	}
+	return &TokenIntrospection{}, nil
+}
+
+// requireIntrospect เช็คว่าผู้เรียกตรวจ token ของผู้อื่นได้
+func (s *AuthService) requireIntrospect(ctx context.Context) error {
+	p, err := principalFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Delegated() {
+		if !p.HasScope(domain.ScopeIntrospect) {
+			return ErrInsufficientScope
+		}
+		return nil
+	}
+	if !domain.HasPermission(p.Roles, domain.PermIntrospect) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (s *AuthService) introspectAccessToken(ctx context.Context, token string) (*TokenIntrospection, error) {
+	p, err := s.Authenticate(ctx, token)
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) {
+		return &TokenIntrospection{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &TokenIntrospection{
+		Active:    true,
+		TokenType: TokenTypeAccess,
+		Subject:   p.UserID,
+		ClientID:  p.ClientID,
+		Scopes:    p.Scopes,
+		Roles:     p.Roles,
+		IssuedAt:  p.IssuedAt,
+		ExpiresAt: p.ExpiresAt,
+	}, nil
+}
+
+// introspectRefreshToken ใช้เงื่อนไขเดียวกับ RefreshToken: ยังไม่ถูกใช้ ไม่ถูกเพิกถอน (รวม LogoutAll) ไม่หมดอายุ และผู้ใช้ยังอยู่
+// role คือ role ปัจจุบันของผู้ใช้ ซึ่งจะอยู่ใน access token ถัดไป
+func (s *AuthService) introspectRefreshToken(ctx context.Context, token string) (*TokenIntrospection, error) {
+	rec, err := s.refreshRepo.FindByHash(ctx, hashToken(token))
+	if err != nil {
+		if interrupted(err) {
+			return nil, err
+		}
+		return &TokenIntrospection{}, nil
+	}
+	if rec.RotatedAt != nil || rec.RevokedAt != nil || !time.Now().Before(rec.ExpiresAt) {
+		return &TokenIntrospection{}, nil
+	}
+	revoked, err := s.revokedByLogoutAll(ctx, rec.UserID, rec.CreatedAt)
+	if err != nil || revoked {
+		return &TokenIntrospection{}, err
+	}
+	u, err := s.repo.FindByID(ctx, rec.UserID)
+	if err != nil {
+		if interrupted(err) {
+			return nil, err
+		}
+		return &TokenIntrospection{}, nil
+	}
+	return &TokenIntrospection{
+		Active:    true,
+		TokenType: TokenTypeRefresh,
+		Subject:   u.ID,
+		Roles:     rolesOf(u),
+		IssuedAt:  rec.CreatedAt,
+		ExpiresAt: rec.ExpiresAt,
+	}, nil
+}
+
+// RevokeToken เพิกถอน access token หรือ refresh token (RFC 7009) ผู้ถือ token เพิกถอนได้โดยไม่ต้อง login
+// refresh token จะเพิกถอนทั้ง family ส่วน access token ถูก blacklist จนหมดอายุ
+// token ที่ไม่รู้จักหรือใช้ไม่ได้แล้วถือว่าสำเร็จ (§2.2) แต่ audit log บันทึกตามจริง
+func (s *AuthService) RevokeToken(ctx context.Context, token, hint string) (err error) {
+	ev := domain.AuditEvent{Type: domain.AuditTokenRevoke}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	for _, typ := range tokenTypes(hint) {
+		if typ == TokenTypeAccess {
+			claims, err := s.parseToken(token)
+			if err != nil {
+				continue
+			}
+			ev.ActorID, ev.SubjectID = claims.Subject, claims.Subject
+			return s.tokenRepo.Blacklist(ctx, claims.ID, claims.ExpiresAt.Time)
+		}
+		rec, err := s.refreshRepo.FindByHash(ctx, hashToken(token))
+		if err != nil {
+			if interrupted(err) {
+				return err
+			}
+			continue
+		}
+		ev.ActorID, ev.SubjectID = rec.UserID, rec.UserID
+		return s.refreshRepo.RevokeFamily(ctx, rec.FamilyID)
+	}
+	ev.Outcome, ev.Reason = domain.AuditFailure, ErrInvalidToken.Reason
+	return nil
+}
+
+// tokenTypes คืนลำดับชนิด token ที่จะลอง: ตาม hint ก่อนแล้วจึงอีกชนิด (hint เป็นแค่ตัวช่วย ค่าที่ไม่รู้จักไม่สนใจ)
+func tokenTypes(hint string) []string {
+	if hint == TokenTypeRefresh {
+		return []string{TokenTypeRefresh, TokenTypeAccess}
+	}
+	return []string{TokenTypeAccess, TokenTypeRefresh}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// introspector คือ ctx ของผู้เรียกที่มีสิทธิ์ tokens:introspect
+var introspector = WithPrincipal(context.Background(), &Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+
+func TestIntrospectToken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u, pair := loginPair(t, s, "introspect@example.com")
+
+	expiredAccess := resign(t, s, pair.AccessToken, func(c jwt.MapClaims) {
+		c["exp"] = time.Now().Add(-time.Minute).Unix()
+	})
+	loggedOut := relogin(t, s, u.Email)
+	if err := s.Logout(ctx, loggedOut.AccessToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	rotated := relogin(t, s, u.Email)
+	if _, err := s.RefreshToken(ctx, rotated.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	expiredRefresh, _ := newOpaqueToken()
+	s.refreshRepo.Create(ctx, &domain.RefreshToken{
+		TokenHash: hashToken(expiredRefresh), UserID: u.ID, FamilyID: "expired", ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	for _, tc := range []struct {
+		name, token, hint string
+		wantType          string // ว่าง = active:false
+	}{
+		{"access token", pair.AccessToken, "", TokenTypeAccess},
+		{"access token, refresh hint", pair.AccessToken, TokenTypeRefresh, TokenTypeAccess},
+		{"refresh token", pair.RefreshToken, "", TokenTypeRefresh},
+		{"refresh token, refresh hint", pair.RefreshToken, TokenTypeRefresh, TokenTypeRefresh},
+		{"expired access token", expiredAccess, "", ""},
+		{"revoked access token", loggedOut.AccessToken, "", ""},
+		{"rotated refresh token", rotated.RefreshToken, "", ""},
+		{"expired refresh token", expiredRefresh, "", ""},
+		{"garbage", "not-a-token", "", ""},
+		{"empty", "", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := s.IntrospectToken(introspector, tc.token, tc.hint)
+			if err != nil {
+				t.Fatalf("IntrospectToken: %v", err)
+			}
+			if tc.wantType == "" {
+				if info.Active || info.Subject != "" || info.TokenType != "" {
+					t.Fatalf("IntrospectToken = %+v, want only active=false", info)
+				}
+				return
+			}
+			if !info.Active || info.TokenType != tc.wantType || info.Subject != u.ID ||
+				len(info.Roles) != 1 || info.Roles[0] != domain.RoleUser || !info.ExpiresAt.After(time.Now()) {
+				t.Fatalf("IntrospectToken = %+v, want an active %s of %s", info, tc.wantType, u.ID)
+			}
+		})
+	}
+}
+
+// relogin login ผู้ใช้ที่มีอยู่แล้วอีกครั้ง ได้ refresh token family ใหม่
+func relogin(t *testing.T, s *AuthService, email string) *TokenPair {
+	t.Helper()
+	pair, err := s.Login(context.Background(), email, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return pair
+}
+
+func TestIntrospectTokenRequiresPermission(t *testing.T) {
+	s := newTestService(t)
+	u, pair := loginPair(t, s, "caller@example.com")
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"anonymous", context.Background(), ErrUnauthenticated},
+		{"plain user", WithPrincipal(context.Background(), &Principal{UserID: u.ID, Roles: []string{domain.RoleUser}}), ErrPermissionDenied},
+		{"OAuth client without the scope", WithPrincipal(context.Background(), &Principal{UserID: u.ID, ClientID: "app", Scopes: []string{domain.ScopeProfile}}), ErrInsufficientScope},
+		{"machine client with the scope", WithPrincipal(context.Background(), &Principal{UserID: "m", ClientID: "m", Scopes: []string{domain.ScopeIntrospect}}), nil},
+		{"admin", introspector, nil},
+	} {
+		if _, err := s.IntrospectToken(tc.ctx, pair.AccessToken, ""); !errors.Is(err, tc.want) {
+			t.Errorf("%s: IntrospectToken = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u, _ := loginPair(t, s, "revoke@example.com")
+
+	t.Run("UnknownToken", func(t *testing.T) {
+		for _, hint := range []string{"", TokenTypeAccess, TokenTypeRefresh, "bogus"} {
+			if err := s.RevokeToken(ctx, "not-a-token", hint); err != nil {
+				t.Fatalf("RevokeToken(unknown, %q) = %v, want nil", hint, err)
+			}
+		}
+		if ev := lastAudit(t, s, domain.AuditTokenRevoke); ev.Outcome != domain.AuditFailure || ev.Reason != ErrInvalidToken.Reason {
+			t.Fatalf("revoke of an unknown token event = %+v", ev)
+		}
+	})
+
+	t.Run("AccessToken", func(t *testing.T) {
+		pair := relogin(t, s, u.Email)
+		// hint ผิดชนิดก็ยังเพิกถอนได้
+		if err := s.RevokeToken(ctx, pair.AccessToken, TokenTypeRefresh); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+		if _, err := s.Authenticate(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+			t.Fatalf("Authenticate(revoked) = %v, want ErrTokenRevoked", err)
+		}
+		// refresh token ของ login เดียวกันไม่ถูกเพิกถอนไปด้วย
+		if _, err := s.RefreshToken(ctx, pair.RefreshToken); err != nil {
+			t.Fatalf("RefreshToken after revoking the access token: %v", err)
+		}
+	})
+
+	t.Run("RefreshTokenRevokesFamily", func(t *testing.T) {
+		first := relogin(t, s, u.Email)
+		second, err := s.RefreshToken(ctx, first.RefreshToken)
+		if err != nil {
+			t.Fatalf("RefreshToken: %v", err)
+		}
+		other := relogin(t, s, u.Email)
+
+		// เพิกถอนด้วย token ตัวเก่าที่ rotate ไปแล้ว: ทั้ง family รวมตัวล่าสุดต้องใช้ไม่ได้
+		if err := s.RevokeToken(ctx, first.RefreshToken, TokenTypeRefresh); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+		if info, _ := s.IntrospectToken(introspector, second.RefreshToken, TokenTypeRefresh); info.Active {
+			t.Fatal("newest refresh token of the family is still active")
+		}
+		if _, err := s.RefreshToken(ctx, second.RefreshToken); err == nil {
+			t.Fatal("RefreshToken with a revoked family succeeded")
+		}
+		if info, _ := s.IntrospectToken(introspector, other.RefreshToken, ""); !info.Active {
+			t.Fatal("another family of the same user was revoked")
+		}
+		if ev := lastAudit(t, s, domain.AuditTokenRevoke); ev.Outcome != domain.AuditSuccess || ev.SubjectID != u.ID {
+			t.Fatalf("revoke event = %+v", ev)
+		}
+	})
+}
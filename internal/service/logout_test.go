@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestLogoutRevokesOnlyThatJTI(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	_, pair := loginPair(t, s, "logout@example.com")
+	other := relogin(t, s, "logout@example.com")
+
+	if err := s.Logout(ctx, pair.AccessToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := s.Authenticate(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("Authenticate(logged out) = %v, want ErrTokenRevoked", err)
+	}
+	// blacklist ผูกกับ jti ไม่ใช่ token ดิบ: เซ็นใหม่ด้วย jti เดิมก็ยังถูกเพิกถอน
+	sameJTI := resign(t, s, pair.AccessToken, func(c jwt.MapClaims) {
+		c["exp"] = time.Now().Add(2 * time.Minute).Unix()
+	})
+	if _, err := s.Authenticate(ctx, sameJTI); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("Authenticate(same jti) = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := s.Authenticate(ctx, other.AccessToken); err != nil {
+		t.Fatalf("Authenticate(other session): %v", err)
+	}
+	if _, err := s.RefreshToken(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken after Logout: %v", err)
+	}
+}
+
+func TestAccessTokenWithoutJTIIsRejected(t *testing.T) {
+	// token ก่อนมี jti เพิกถอนไม่ได้ migration 0006 จึงลบ blacklist เดิมทิ้งได้โดยไม่คืนชีพ token ที่ logout แล้ว
+	ctx := context.Background()
+	s := newTestService(t)
+	_, pair := loginPair(t, s, "legacy@example.com")
+	legacy := resign(t, s, pair.AccessToken, func(c jwt.MapClaims) { delete(c, "jti") })
+
+	if _, err := s.Authenticate(ctx, legacy); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Authenticate(no jti) = %v, want ErrInvalidToken", err)
+	}
+	if err := s.Logout(ctx, legacy); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Logout(no jti) = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLogoutAllWatermark(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u, pair := loginPair(t, s, "everywhere@example.com")
+	other := relogin(t, s, "everywhere@example.com")
+	p, err := s.Authenticate(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if err := s.LogoutAll(WithPrincipal(ctx, p)); err != nil {
+		t.Fatalf("LogoutAll: %v", err)
+	}
+	cutoff, err := s.tokenRepo.RevokedBefore(ctx, u.ID)
+	if err != nil || cutoff.IsZero() {
+		t.Fatalf("RevokedBefore = %v, %v", cutoff, err)
+	}
+	for name, raw := range map[string]string{"caller": pair.AccessToken, "other session": other.AccessToken} {
+		if _, err := s.Authenticate(ctx, raw); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("Authenticate(%s) = %v, want ErrTokenRevoked", name, err)
+		}
+	}
+	for name, raw := range map[string]string{"caller": pair.RefreshToken, "other session": other.RefreshToken} {
+		if _, err := s.RefreshToken(ctx, raw); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("RefreshToken(%s) = %v, want ErrInvalidRefreshToken", name, err)
+		}
+	}
+
+	// iat ละเอียดแค่วินาที: token ที่ออกในวินาทีเดียวกับ LogoutAll ถูกเพิกถอนด้วย
+	second := cutoff.Truncate(time.Second)
+	sameSecond := resign(t, s, pair.AccessToken, func(c jwt.MapClaims) {
+		c["iat"] = second.Unix()
+		c["jti"] = "same-second"
+	})
+	if _, err := s.Authenticate(ctx, sameSecond); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("Authenticate(same second) = %v, want ErrTokenRevoked", err)
+	}
+	// login ใหม่ในวินาทีถัดไปใช้ได้
+	time.Sleep(time.Until(second.Add(time.Second)))
+	fresh := relogin(t, s, "everywhere@example.com")
+	if _, err := s.Authenticate(ctx, fresh.AccessToken); err != nil {
+		t.Fatalf("Authenticate(after LogoutAll): %v", err)
+	}
+	if _, err := s.RefreshToken(ctx, fresh.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken(after LogoutAll): %v", err)
+	}
+
+	// ของผู้ใช้คนอื่นไม่โดนไปด้วย
+	_, bystander := loginPair(t, s, "bystander@example.com")
+	if _, err := s.Authenticate(ctx, bystander.AccessToken); err != nil {
+		t.Fatalf("Authenticate(other user): %v", err)
+	}
+}
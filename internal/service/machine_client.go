@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+)
+
+// WithMachineClients เปิด client credentials grant สำหรับ service-to-service ด้วย repository ของ machine client
+func WithMachineClients(r repo.MachineClientRepository) Option {
+	return func(s *AuthService) {
+		s.machineClients = r
+	}
+}
+
+// CreateMachineClient สร้าง machine client ใหม่ (ต้องมี permission clients:write)
+// คืน client secret ซึ่งแสดงครั้งเดียว ในระบบเก็บเป็น hash
+func (s *AuthService) CreateMachineClient(ctx context.Context, name string, scopes []string) (_ *domain.MachineClient, _ string, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditMachineClientCreate}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	if _, err := s.requirePermission(ctx, domain.PermClientsWrite); err != nil {
+		return nil, "", err
+	}
+	if s.machineClients == nil {
+		return nil, "", ErrOAuthDisabled
+	}
+	if vs := validateMachineClient(name, scopes); len(vs) > 0 {
+		return nil, "", withViolations(ErrInvalidClientMetadata, vs)
+	}
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	c := &domain.MachineClient{
+		ID:         uuid.NewString(),
+		Name:       strings.TrimSpace(name),
+		SecretHash: hashToken(secret),
+		Scopes:     parseScope(strings.Join(scopes, " ")),
+	}
+	if err := s.machineClients.Create(ctx, c); err != nil {
+		return nil, "", err
+	}
+	ev.SubjectID = c.ID
+	return c, secret, nil
+}
+
+// RotateMachineClientSecret ออก secret ใหม่ให้ client (ต้องมี permission clients:write)
+// secret เดิมใช้ขอ token ไม่ได้ทันที แต่ token ที่ออกไปแล้วยังใช้ได้จนหมดอายุ
+func (s *AuthService) RotateMachineClientSecret(ctx context.Context, clientID string) (_ string, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditMachineClientRotate, SubjectID: clientID}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	if _, err := s.requirePermission(ctx, domain.PermClientsWrite); err != nil {
+		return "", err
+	}
+	if s.machineClients == nil {
+		return "", ErrOAuthDisabled
+	}
+	c, err := s.machineClients.FindByID(ctx, clientID)
+	if err != nil {
+		return "", machineClientErr(err)
+	}
+	if c.Disabled() {
+		return "", ErrMachineClientDisabled
+	}
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.machineClients.UpdateSecret(ctx, c.ID, hashToken(secret)); err != nil {
+		return "", machineClientErr(err)
+	}
+	return secret, nil
+}
+
+// DisableMachineClient ปิด client ถาวร (ต้องมี permission clients:write) เรียกซ้ำได้
+// token ที่ออกไปแล้วถูกเพิกถอนทันทีด้วย watermark แบบเดียวกับ LogoutAll (sub ของ token คือ ID ของ client)
+func (s *AuthService) DisableMachineClient(ctx context.Context, clientID string) (err error) {
+	ev := domain.AuditEvent{Type: domain.AuditMachineClientDisable, SubjectID: clientID}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	if _, err := s.requirePermission(ctx, domain.PermClientsWrite); err != nil {
+		return err
+	}
+	if s.machineClients == nil {
+		return ErrOAuthDisabled
+	}
+	if err := s.machineClients.Disable(ctx, clientID); err != nil {
+		return machineClientErr(err)
+	}
+	now := time.Now()
+	return s.tokenRepo.RevokeIssuedBefore(ctx, clientID, now, now.Add(s.accessTTL))
+}
+
+// ExchangeClientCredentials ออก access token ให้ machine client (client credentials grant, RFC 6749 §4.4)
+// token มี sub และ client_id เป็น ID ของ client และมีแค่ scope ที่ขอ (ว่าง = ทุก scope ของ client)
+// client ที่ไม่มีอยู่ ถูกปิด หรือ secret ผิด ได้ ErrInvalidOAuthClient เหมือนกันหมด
+func (s *AuthService) ExchangeClientCredentials(ctx context.Context, clientID, secret, scope string) (_ *OAuthToken, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditClientCredentials, ActorID: clientID, SubjectID: clientID}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	if s.machineClients == nil {
+		return nil, ErrOAuthDisabled
+	}
+	if clientID == "" || secret == "" {
+		return nil, ErrInvalidOAuthClient
+	}
+	c, err := s.machineClients.FindByID(ctx, clientID)
+	if errors.Is(err, repo.ErrMachineClientNotFound) {
+		return nil, ErrInvalidOAuthClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Disabled() || subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, ErrInvalidOAuthClient
+	}
+	scopes := parseScope(scope)
+	if len(scopes) == 0 {
+		scopes = c.Scopes
+	}
+	if !c.AllowsScopes(scopes) {
+		return nil, ErrInvalidScope
+	}
+	access, err := s.signAccessToken(c.ID, accessClaims{ClientID: c.ID, Scope: strings.Join(scopes, " ")})
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: access, ExpiresIn: s.accessTTL, Scopes: scopes}, nil
+}
+
+// validateMachineClient ตรวจชื่อและ scope ของ machine client
+// scope ต้องเป็น scope-token ตาม RFC 6749 §3.3 และต้องไม่ใช่ scope ที่ผูกกับผู้ใช้ (เช่น openid, profile)
+func validateMachineClient(name string, scopes []string) []FieldViolation {
+	var vs []FieldViolation
+	if strings.TrimSpace(name) == "" {
+		vs = append(vs, FieldViolation{Field: "name", Reason: "REQUIRED", Description: "name is required"})
+	}
+	if len(scopes) == 0 {
+		vs = append(vs, FieldViolation{Field: "scopes", Reason: "REQUIRED", Description: "at least one scope is required"})
+	}
+	for _, sc := range scopes {
+		switch {
+		case !validScopeToken(sc):
+			vs = append(vs, FieldViolation{Field: "scopes", Reason: "INVALID_SCOPE", Description: "invalid scope " + sc})
+		case supportedScope(sc):
+			vs = append(vs, FieldViolation{Field: "scopes", Reason: "USER_SCOPE",
+				Description: "scope " + sc + " needs a user and cannot be granted to a machine client"})
+		}
+	}
+	return vs
+}
+
+// validScopeToken ตรวจ scope-token: ตัวอักษร ASCII ที่พิมพ์ได้ ยกเว้นช่องว่าง " และ \
+func validScopeToken(sc string) bool {
+	if sc == "" {
+		return false
+	}
+	for i := 0; i < len(sc); i++ {
+		if c := sc[i]; c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// machineClientErr แปลง error ของ MachineClientRepository เป็น error ของ service
+func machineClientErr(err error) error {
+	if errors.Is(err, repo.ErrMachineClientNotFound) {
+		return ErrMachineClientNotFound
+	}
+	return err
+}
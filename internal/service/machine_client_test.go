@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+)
+
+func TestDisableMachineClientRevokesIssuedTokens(t *testing.T) {
+	s := newTestService(t, WithMachineClients(memory.NewMachineClientRepository()))
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+
+	exchange := func(name string) (*domain.MachineClient, string) {
+		c, secret, err := s.CreateMachineClient(ctx, name, []string{"reports:read"})
+		if err != nil {
+			t.Fatalf("CreateMachineClient: %v", err)
+		}
+		tok, err := s.ExchangeClientCredentials(context.Background(), c.ID, secret, "")
+		if err != nil {
+			t.Fatalf("ExchangeClientCredentials: %v", err)
+		}
+		return c, tok.AccessToken
+	}
+	disabled, token := exchange("nightly-report")
+	other, otherToken := exchange("billing")
+
+	if _, err := s.Authenticate(context.Background(), token); err != nil {
+		t.Fatalf("Authenticate before disable: %v", err)
+	}
+	if err := s.DisableMachineClient(ctx, disabled.ID); err != nil {
+		t.Fatalf("DisableMachineClient: %v", err)
+	}
+	if _, err := s.Authenticate(context.Background(), token); err != ErrTokenRevoked {
+		t.Fatalf("Authenticate after disable = %v, want ErrTokenRevoked", err)
+	}
+	introspector := WithPrincipal(context.Background(), &Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+	if info, err := s.IntrospectToken(introspector, token, ""); err != nil || info.Active {
+		t.Fatalf("IntrospectToken after disable = %+v, %v; want inactive", info, err)
+	}
+
+	// client อื่นไม่ได้รับผลกระทบ และเรียกซ้ำได้
+	if p, err := s.Authenticate(context.Background(), otherToken); err != nil || p.ClientID != other.ID {
+		t.Fatalf("Authenticate other client = %+v, %v", p, err)
+	}
+	if err := s.DisableMachineClient(ctx, disabled.ID); err != nil {
+		t.Fatalf("DisableMachineClient again: %v", err)
+	}
+	if err := s.DisableMachineClient(ctx, "missing"); err != ErrMachineClientNotFound {
+		t.Fatalf("DisableMachineClient(missing) = %v, want ErrMachineClientNotFound", err)
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/totp"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	// mfaAudience ใช้แยก challenge token ออกจาก access token
+	mfaAudience = "mfa-challenge"
+	// mfaChallengeTTL อายุของ challenge token หลังผ่านขั้นรหัสผ่าน
+	mfaChallengeTTL = 5 * time.Minute
+	// recoveryCodeCount จำนวน recovery code ที่ออกให้ตอนเปิด MFA
+	recoveryCodeCount = 10
+)
+
+// WithTOTPIssuer กำหนดชื่อ issuer ที่แสดงใน authenticator app
+func WithTOTPIssuer(issuer string) Option {
+	return func(s *AuthService) {
+		if issuer != "" {
+			s.totpIssuer = issuer
+		}
+	}
+}
+
+// EnrollTOTP สร้าง secret ใหม่ (ยังไม่เปิดใช้จนกว่าจะ ConfirmTOTP) คืน secret และ otpauth URI
+func (s *AuthService) EnrollTOTP(ctx context.Context) (secret, uri string, err error) {
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if u.MFAEnabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	u.TOTPPendingSecret = secret
+	if err := s.repo.Update(ctx, u); err != nil {
+		return "", "", err
+	}
+	return secret, totp.URI(s.totpIssuer, u.Email, secret), nil
+}
+
+// ConfirmTOTP ยืนยัน secret ด้วยรหัสแรกจาก authenticator แล้วเปิดใช้ MFA
+// คืน recovery code (แสดงครั้งเดียว ในระบบเก็บเป็น hash)
+func (s *AuthService) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPPendingSecret == "" {
+		return nil, ErrMFANotPending
+	}
+	step, ok := totp.Validate(u.TOTPPendingSecret, code, time.Now())
+	if !ok {
+		return nil, ErrMFACodeMismatch
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetMFA(ctx, u.ID, u.TOTPPendingSecret, step, hashes); err != nil {
+		return nil, userErr(err)
+	}
+	return codes, nil
+}
+
+// DisableTOTP ปิด MFA โดยต้องยืนยันด้วยรหัส TOTP หรือ recovery code
+func (s *AuthService) DisableTOTP(ctx context.Context, code string) error {
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if !u.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	ok, err := s.checkSecondFactor(ctx, u, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMFACodeMismatch
+	}
+	if err := s.repo.SetMFA(ctx, u.ID, "", 0, nil); err != nil {
+		return userErr(err)
+	}
+	return nil
+}
+
+// VerifyMFA ขั้นที่สองของ Login: ตรวจ challenge token กับรหัส TOTP หรือ recovery code
+// challenge token ใช้ได้ครั้งเดียว: หลังรหัสถูกต้องจะ claim jti แบบ insert-if-absent ก่อนออก token
+// คำขอพร้อมกันที่ใช้ challenge เดียวกัน (แม้ใช้คนละรหัส) จึงได้ token แค่คำขอเดียว
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (_ *TokenPair, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditMFAVerify}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	claims, err := s.parseChallenge(mfaToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	ev.SubjectID = claims.Subject
+	key := s.mfaKey(claims.Subject)
+	if err := s.checkLimit(ctx, ErrTooManyMFAAttempts, key); err != nil {
+		return nil, err
+	}
+	used, err := s.tokenRepo.IsBlacklisted(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if used {
+		return nil, ErrInvalidMFAToken
+	}
+	u, err := s.repo.FindByID(ctx, claims.Subject)
+	if err != nil || !u.MFAEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+	ok, err := s.checkSecondFactor(ctx, u, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.recordFailure(ctx, ErrTooManyMFAAttempts, key); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMFACode
+	}
+	claimed, err := s.tokenRepo.Claim(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrInvalidMFAToken
+	}
+	if err := s.limiter.Reset(ctx, key.key); err != nil {
+		return nil, err
+	}
+	ev.ActorID, ev.Email = u.ID, u.Email
+	return s.issueTokens(ctx, u, "")
+}
+
+// checkSecondFactor ตรวจรหัส TOTP (กันใช้ step เดิมซ้ำ) หรือ recovery code (ใช้แล้วลบทิ้ง)
+// การใช้ step หรือ code เป็น conditional update ใน repository คำขอพร้อมกันจึงผ่านได้แค่คำขอเดียว
+func (s *AuthService) checkSecondFactor(ctx context.Context, u *domain.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(u.TOTPSecret, code, time.Now()); ok {
+		if step <= u.TOTPLastStep {
+			return false, nil
+		}
+		return s.repo.ConsumeTOTPStep(ctx, u.ID, step)
+	}
+	h := hashToken(normalizeRecoveryCode(code))
+	for _, stored := range u.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(h)) == 1 {
+			return s.repo.ConsumeRecoveryCode(ctx, u.ID, stored)
+		}
+	}
+	return false, nil
+}
+
+// generateChallenge ออก challenge token อายุสั้นสำหรับขั้นตอน MFA
+func (s *AuthService) generateChallenge(userID string) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   userID,
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+	}
+	key := s.keyRing.Active()
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.SigningKey())
+}
+
+// parseChallenge ตรวจ challenge token (ต้องมี audience ของ MFA และ jti)
+func (s *AuthService) parseChallenge(raw string) (*jwt.RegisteredClaims, error) {
+	tok, err := jwt.ParseWithClaims(raw, &jwt.RegisteredClaims{}, s.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := tok.Claims.(*jwt.RegisteredClaims)
+	if !ok || !tok.Valid || !claims.VerifyAudience(mfaAudience, true) || claims.ID == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// currentUser ดึงผู้ใช้ที่เป็นเจ้าของ token ใน ctx
+func (s *AuthService) currentUser(ctx context.Context) (*domain.User, error) {
+	p, err := principalFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.repo.FindByID(ctx, p.UserID)
+	if err != nil {
+		return nil, userErr(err)
+	}
+	return u, nil
+}
+
+// newRecoveryCodes สุ่ม recovery code รูปแบบ xxxxx-xxxxx คืนทั้งค่าจริงและ hash
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ตัดขีดและช่องว่าง เพื่อให้พิมพ์ได้หลายรูปแบบ
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
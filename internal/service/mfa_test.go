@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/totp"
+)
+
+// enrollMFA เปิด MFA ให้ผู้ใช้ผ่าน EnrollTOTP/ConfirmTOTP คืน secret กับ recovery code
+func enrollMFA(t *testing.T, s *AuthService, u *domain.User) (string, []string) {
+	t.Helper()
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: u.ID})
+	secret, uri, err := s.EnrollTOTP(ctx)
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+	if uri != totp.URI(s.totpIssuer, u.Email, secret) {
+		t.Fatalf("EnrollTOTP uri = %q", uri)
+	}
+	// step ก่อนหน้าก็ยังอยู่ในช่วง Skew ใช้ step นี้ยืนยัน เพื่อให้รหัสของ step ปัจจุบันยังใช้ login ได้
+	code, _ := totp.Code(secret, totp.Step(time.Now())-1)
+	codes, err := s.ConfirmTOTP(ctx, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	return secret, codes
+}
+
+func TestTOTPEnrollConfirmDisable(t *testing.T) {
+	s := newTestService(t)
+	u := mustRegister(t, s, "enroll@example.com", true)
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: u.ID})
+
+	if _, err := s.ConfirmTOTP(ctx, "123456"); err != ErrMFANotPending {
+		t.Fatalf("ConfirmTOTP before enrolling = %v, want ErrMFANotPending", err)
+	}
+	if err := s.DisableTOTP(ctx, "123456"); err != ErrMFANotEnabled {
+		t.Fatalf("DisableTOTP before enrolling = %v, want ErrMFANotEnabled", err)
+	}
+	secret, _, err := s.EnrollTOTP(ctx)
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+	if got, _ := s.repo.FindByID(ctx, u.ID); got.MFAEnabled || got.TOTPPendingSecret != secret {
+		t.Fatalf("after EnrollTOTP: %+v", got)
+	}
+	if _, err := s.ConfirmTOTP(ctx, "000000"); err != ErrMFACodeMismatch {
+		t.Fatalf("ConfirmTOTP(wrong code) = %v, want ErrMFACodeMismatch", err)
+	}
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	codes, err := s.ConfirmTOTP(ctx, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	got, _ := s.repo.FindByID(ctx, u.ID)
+	if !got.MFAEnabled || got.TOTPSecret != secret || got.TOTPPendingSecret != "" || len(got.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("after ConfirmTOTP: %+v", got)
+	}
+	for _, h := range got.RecoveryCodes {
+		for _, c := range codes {
+			if h == c || h == normalizeRecoveryCode(c) {
+				t.Fatal("recovery code stored in plain text")
+			}
+		}
+	}
+	if _, _, err := s.EnrollTOTP(ctx); err != ErrMFAAlreadyEnabled {
+		t.Fatalf("EnrollTOTP when enabled = %v, want ErrMFAAlreadyEnabled", err)
+	}
+
+	// step ที่ใช้ยืนยันไปแล้วใช้ปิด MFA ซ้ำไม่ได้
+	if err := s.DisableTOTP(ctx, code); err != ErrMFACodeMismatch {
+		t.Fatalf("DisableTOTP(reused code) = %v, want ErrMFACodeMismatch", err)
+	}
+	if err := s.DisableTOTP(ctx, codes[0]); err != nil {
+		t.Fatalf("DisableTOTP(recovery code): %v", err)
+	}
+	got, _ = s.repo.FindByID(ctx, u.ID)
+	if got.MFAEnabled || got.TOTPSecret != "" || got.TOTPLastStep != 0 || len(got.RecoveryCodes) != 0 {
+		t.Fatalf("after DisableTOTP: %+v", got)
+	}
+	pair, err := s.Login(context.Background(), u.Email, testPassword)
+	if err != nil || pair.AccessToken == "" || pair.MFAToken != "" {
+		t.Fatalf("Login after DisableTOTP = %+v, %v; want tokens without a challenge", pair, err)
+	}
+}
+
+func TestVerifyMFA(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u := mustRegister(t, s, "verify@example.com", true)
+	secret, codes := enrollMFA(t, s, u)
+
+	login := func() string {
+		t.Helper()
+		pair, err := s.Login(ctx, u.Email, testPassword)
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if pair.MFAToken == "" || pair.AccessToken != "" {
+			t.Fatalf("Login with MFA = %+v, want only a challenge", pair)
+		}
+		return pair.MFAToken
+	}
+
+	t.Run("TOTP", func(t *testing.T) {
+		challenge := login()
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		pair, err := s.VerifyMFA(ctx, challenge, code)
+		if err != nil || pair.AccessToken == "" || pair.RefreshToken == "" {
+			t.Fatalf("VerifyMFA = %+v, %v", pair, err)
+		}
+		// challenge ใช้ได้ครั้งเดียว
+		if _, err := s.VerifyMFA(ctx, challenge, codes[0]); err != ErrInvalidMFAToken {
+			t.Fatalf("VerifyMFA(reused challenge) = %v, want ErrInvalidMFAToken", err)
+		}
+		// step เดิมใช้กับ challenge ใหม่ไม่ได้
+		if _, err := s.VerifyMFA(ctx, login(), code); err != ErrInvalidMFACode {
+			t.Fatalf("VerifyMFA(reused step) = %v, want ErrInvalidMFACode", err)
+		}
+	})
+
+	t.Run("RecoveryCode", func(t *testing.T) {
+		if _, err := s.VerifyMFA(ctx, login(), " "+codes[1]+" "); err != nil {
+			t.Fatalf("VerifyMFA(recovery code): %v", err)
+		}
+		if _, err := s.VerifyMFA(ctx, login(), codes[1]); err != ErrInvalidMFACode {
+			t.Fatalf("VerifyMFA(used recovery code) = %v, want ErrInvalidMFACode", err)
+		}
+	})
+
+	t.Run("AccessTokenIsNotAChallenge", func(t *testing.T) {
+		pair, err := s.VerifyMFA(ctx, login(), codes[2])
+		if err != nil {
+			t.Fatalf("VerifyMFA: %v", err)
+		}
+		if _, err := s.VerifyMFA(ctx, pair.AccessToken, codes[3]); err != ErrInvalidMFAToken {
+			t.Fatalf("VerifyMFA(access token) = %v, want ErrInvalidMFAToken", err)
+		}
+	})
+
+	t.Run("StaleUpdateKeepsCodesUsed", func(t *testing.T) {
+		// เขียนข้อมูลผู้ใช้ที่อ่านไว้ก่อนใช้ recovery code (เช่น upgradeHash ที่ทำพร้อมกัน) ต้องไม่คืนรหัสนั้นกลับมา
+		stale, _ := s.repo.FindByID(ctx, u.ID)
+		if _, err := s.VerifyMFA(ctx, login(), codes[4]); err != nil {
+			t.Fatalf("VerifyMFA: %v", err)
+		}
+		stale.Name = "Stale"
+		if err := s.repo.Update(ctx, stale); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if _, err := s.VerifyMFA(ctx, login(), codes[4]); err != ErrInvalidMFACode {
+			t.Fatalf("VerifyMFA(code restored by stale Update) = %v, want ErrInvalidMFACode", err)
+		}
+	})
+}
+
+func TestVerifyMFAConcurrentChallenge(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u := mustRegister(t, s, "race@example.com", true)
+	secret, codes := enrollMFA(t, s, u)
+	pair, err := s.Login(ctx, u.Email, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// challenge เดียวกันกับรหัสคนละตัว: รหัสทุกตัวถูกต้อง แต่ต้องได้ token แค่คำขอเดียว
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	attempts := append([]string{code}, codes[:5]...)
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for _, c := range attempts {
+		wg.Add(1)
+		go func(c string) {
+			defer wg.Done()
+			_, err := s.VerifyMFA(ctx, pair.MFAToken, c)
+			if err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			} else if err != ErrInvalidMFAToken {
+				t.Errorf("VerifyMFA = %v, want nil or ErrInvalidMFAToken", err)
+			}
+		}(c)
+	}
+	wg.Wait()
+	if successes != 1 {
+		t.Fatalf("%d concurrent VerifyMFA calls with one challenge succeeded, want 1", successes)
+	}
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// authorizationCodeTTL อายุของ authorization code (RFC 6749 แนะนำไม่เกิน 10 นาที)
+	authorizationCodeTTL = 5 * time.Minute
+	// pkceMethodS256 คือ code_challenge_method เดียวที่รับ ("plain" ไม่ปลอดภัยพอ)
+	pkceMethodS256 = "S256"
+)
+
+// การตัดสินใจของผู้ใช้ในหน้า consent
+const (
+	ConsentAllow = "allow"
+	ConsentDeny  = "deny"
+)
+
+// WithOAuth เปิด authorization server (authorization code + PKCE) ด้วย repository ของ client, code และ consent
+func WithOAuth(clients repo.OAuthClientRepository, codes repo.AuthorizationCodeRepository, consents repo.ConsentRepository) Option {
+	return func(s *AuthService) {
+		s.oauthClients = clients
+		s.authCodes = codes
+		s.consents = consents
+	}
+}
+
+// AuthorizeRequest คือ parameter ของ /authorize (RFC 6749 §4.1.1 และ PKCE RFC 7636)
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string // คั่นด้วยช่องว่าง ว่าง = ทุก scope ที่ client ขอได้
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string // OpenID Connect: ส่งกลับใน ID token เพื่อกัน replay
+}
+
+// Authorization คือผลของการตรวจ/อนุญาต authorization request
+type Authorization struct {
+	Client *domain.OAuthClient
+	Scopes []string
+	// ConsentRequired เป็น true เมื่อผู้ใช้ยังไม่เคยอนุญาต scope เหล่านี้ (Code จะว่าง)
+	ConsentRequired bool
+	Code            string
+}
+
+// OAuthToken คือ access token ที่ออกให้ client ผ่าน /token
+// IDToken มีค่าเมื่อผู้ใช้อนุญาต scope openid
+type OAuthToken struct {
+	AccessToken string
+	IDToken     string
+	ExpiresIn   time.Duration
+	Scopes      []string
+}
+
+// RegisterOAuthClient ลงทะเบียน client ใหม่ (ต้องมี permission clients:write)
+// confidential = true จะได้ client secret ซึ่งแสดงครั้งเดียว ในระบบเก็บเป็น hash
+func (s *AuthService) RegisterOAuthClient(ctx context.Context, name string, redirectURIs, scopes []string, confidential bool) (*domain.OAuthClient, string, error) {
+	if _, err := s.requirePermission(ctx, domain.PermClientsWrite); err != nil {
+		return nil, "", err
+	}
+	if s.oauthClients == nil {
+		return nil, "", ErrOAuthDisabled
+	}
+	if vs := validateClientMetadata(name, redirectURIs, scopes); len(vs) > 0 {
+		return nil, "", withViolations(ErrInvalidClientMetadata, vs)
+	}
+	c := &domain.OAuthClient{
+		ID:           uuid.NewString(),
+		Name:         strings.TrimSpace(name),
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+	}
+	var secret string
+	if confidential {
+		var err error
+		if secret, err = newOpaqueToken(); err != nil {
+			return nil, "", err
+		}
+		c.SecretHash = hashToken(secret)
+	}
+	if err := s.oauthClients.Create(ctx, c); err != nil {
+		return nil, "", err
+	}
+	return c, secret, nil
+}
+
+// validateClientMetadata ตรวจชื่อ redirect URI (ต้องเป็น absolute URI ไม่มี fragment) และ scope
+func validateClientMetadata(name string, redirectURIs, scopes []string) []FieldViolation {
+	var vs []FieldViolation
+	if strings.TrimSpace(name) == "" {
+		vs = append(vs, FieldViolation{Field: "name", Reason: "REQUIRED", Description: "name is required"})
+	}
+	if len(redirectURIs) == 0 {
+		vs = append(vs, FieldViolation{Field: "redirect_uris", Reason: "REQUIRED", Description: "at least one redirect URI is required"})
+	}
+	for _, raw := range redirectURIs {
+		u, err := url.Parse(raw)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			vs = append(vs, FieldViolation{Field: "redirect_uris", Reason: "INVALID_REDIRECT_URI",
+				Description: raw + " must be an absolute URI without a fragment"})
+		}
+	}
+	for _, sc := range scopes {
+		if !supportedScope(sc) {
+			vs = append(vs, FieldViolation{Field: "scopes", Reason: "UNKNOWN_SCOPE", Description: "unknown scope " + sc})
+		}
+	}
+	return vs
+}
+
+// ValidateAuthorizeRequest ตรวจ authorization request โดยยังไม่ต้องรู้ว่าผู้ใช้เป็นใคร
+// ErrInvalidOAuthClient และ ErrInvalidRedirectURI ต้องไม่ redirect กลับไปหา client
+// (redirect_uri ยังเชื่อไม่ได้) error อื่นส่งกลับทาง redirect_uri ได้
+func (s *AuthService) ValidateAuthorizeRequest(ctx context.Context, req AuthorizeRequest) (*Authorization, error) {
+	if s.oauthClients == nil {
+		return nil, ErrOAuthDisabled
+	}
+	c, err := s.oauthClients.FindByID(ctx, req.ClientID)
+	if err != nil {
+		return nil, clientErr(err)
+	}
+	if !c.AllowsRedirect(req.RedirectURI) {
+		return nil, ErrInvalidRedirectURI
+	}
+	if req.ResponseType != "code" {
+		return nil, ErrUnsupportedResponseType
+	}
+	if req.CodeChallengeMethod != pkceMethodS256 || !validPKCEValue(req.CodeChallenge) {
+		return nil, ErrPKCERequired
+	}
+	scopes := parseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = c.Scopes
+	}
+	if len(scopes) == 0 || !c.AllowsScopes(scopes) {
+		return nil, ErrInvalidScope
+	}
+	if containsScope(scopes, domain.ScopeOpenID) && !s.OIDCEnabled() {
+		return nil, withMessage(ErrInvalidScope, "openid is not available: the server does not sign with an asymmetric key")
+	}
+	return &Authorization{Client: c, Scopes: scopes}, nil
+}
+
+// Authorize ออก authorization code ให้ผู้ใช้ที่ login อยู่ (principal ใน ctx)
+// ถ้ายังไม่เคยอนุญาต scope ที่ขอและ decision ว่าง จะคืน ConsentRequired ให้หน้าเว็บถามผู้ใช้ก่อน
+// decision = ConsentAllow บันทึก consent ไว้ ConsentDeny คืน ErrAccessDenied
+func (s *AuthService) Authorize(ctx context.Context, req AuthorizeRequest, decision string) (*Authorization, error) {
+	p, err := principalFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// token ที่ client ได้ไปจาก OAuth ใช้ขอ code ต่อเองไม่ได้
+	if p.Delegated() {
+		return nil, ErrInsufficientScope
+	}
+	a, err := s.ValidateAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	switch decision {
+	case ConsentDeny:
+		return nil, ErrAccessDenied
+	case ConsentAllow:
+		if err := s.consents.Grant(ctx, p.UserID, a.Client.ID, a.Scopes); err != nil {
+			return nil, err
+		}
+	default:
+		consent, err := s.consents.Find(ctx, p.UserID, a.Client.ID)
+		if err != nil && !errors.Is(err, repo.ErrConsentNotFound) {
+			return nil, err
+		}
+		if consent == nil || !consent.Covers(a.Scopes) {
+			a.ConsentRequired = true
+			return a, nil
+		}
+	}
+
+	code, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.authCodes.Create(ctx, &domain.AuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      a.Client.ID,
+		UserID:        p.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        a.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		ExpiresAt:     time.Now().Add(authorizationCodeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.Code = code
+	return a, nil
+}
+
+// ExchangeAuthorizationCode แลก authorization code เป็น access token ที่มีแค่ scope ที่ผู้ใช้อนุญาต
+// confidential client ต้องส่ง secret ส่วน public client พิสูจน์ตัวด้วย PKCE verifier อย่างเดียว
+func (s *AuthService) ExchangeAuthorizationCode(ctx context.Context, clientID, clientSecret, code, redirectURI, verifier string) (*OAuthToken, error) {
+	if s.oauthClients == nil {
+		return nil, ErrOAuthDisabled
+	}
+	if code == "" || redirectURI == "" || verifier == "" {
+		return nil, withMessage(ErrInvalidOAuthRequest, "code, redirect_uri and code_verifier are required")
+	}
+	c, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	// ใช้ code ได้เฉพาะ client ที่ได้ code ไป: client อื่นส่ง code มาจะไม่ทำให้ code ถูกลบ
+	ac, err := s.authCodes.Consume(ctx, hashToken(code), c.ID)
+	if err != nil {
+		return nil, lookupErr(err, ErrInvalidGrant)
+	}
+	// redirect_uri หรือ verifier ผิด: code ถูกใช้ไปแล้ว (ลองซ้ำไม่ได้ กันการเดา verifier)
+	if ac.RedirectURI != redirectURI || !verifyPKCE(ac.CodeChallenge, verifier) {
+		return nil, ErrInvalidGrant
+	}
+	// ผู้ใช้ที่ถูกลบระหว่างนั้นต้องไม่ได้ token
+	u, err := s.repo.FindByID(ctx, ac.UserID)
+	if err != nil {
+		return nil, lookupErr(err, ErrInvalidGrant)
+	}
+	access, err := s.signAccessToken(u.ID, accessClaims{ClientID: c.ID, Scope: strings.Join(ac.Scopes, " ")})
+	if err != nil {
+		return nil, err
+	}
+	tok := &OAuthToken{AccessToken: access, ExpiresIn: s.accessTTL, Scopes: ac.Scopes}
+	if containsScope(ac.Scopes, domain.ScopeOpenID) {
+		if tok.IDToken, err = s.signIDToken(u, c.ID, ac.Nonce, ac.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	return tok, nil
+}
+
+// authenticateClient ตรวจ client และ secret (confidential client เท่านั้นที่ต้องมี secret)
+func (s *AuthService) authenticateClient(ctx context.Context, clientID, secret string) (*domain.OAuthClient, error) {
+	c, err := s.oauthClients.FindByID(ctx, clientID)
+	if err != nil {
+		return nil, clientErr(err)
+	}
+	if c.Confidential() && subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, ErrInvalidOAuthClient
+	}
+	return c, nil
+}
+
+// AuthorizePageURL คืน URL ของหน้า login/consent บนเว็บ พร้อม parameter ของ authorization request เดิม
+func (s *AuthService) AuthorizePageURL(query url.Values) string {
+	return s.linkBaseURL + "/authorize?" + query.Encode()
+}
+
+// clientErr แปลง error ของ OAuthClientRepository เป็น error ของ service
+func clientErr(err error) error {
+	if errors.Is(err, repo.ErrOAuthClientNotFound) {
+		return ErrInvalidOAuthClient
+	}
+	return err
+}
+
+// parseScope แยก scope ที่คั่นด้วยช่องว่างและตัดตัวซ้ำ
+func parseScope(scope string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, sc := range strings.Fields(scope) {
+		if !seen[sc] {
+			seen[sc] = true
+			out = append(out, sc)
+		}
+	}
+	return out
+}
+
+func supportedScope(scope string) bool {
+	return containsScope(domain.SupportedScopes, scope)
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, sc := range scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// validPKCEValue ตรวจ code_challenge / code_verifier: 43-128 ตัวจาก [A-Z a-z 0-9 - . _ ~] (RFC 7636 §4.1)
+func validPKCEValue(v string) bool {
+	if len(v) < 43 || len(v) > 128 {
+		return false
+	}
+	for _, r := range v {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9',
+			r == '-', r == '.', r == '_', r == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// verifyPKCE เช็คว่า BASE64URL(SHA256(verifier)) ตรงกับ challenge ที่ได้ตอน /authorize
+func verifyPKCE(challenge, verifier string) bool {
+	if !validPKCEValue(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
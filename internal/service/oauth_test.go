@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+)
+
+const (
+	oauthRedirect = "https://app.example.com/cb"
+	// oauthVerifier คือ code_verifier ที่ยาวพอตาม RFC 7636 (43 ตัวขึ้นไป)
+	oauthVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// s256 คำนวณ code_challenge แบบ S256 จาก verifier
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// oauthFixture คือ AuthService ที่เปิด OAuth แล้ว ผู้ใช้ที่ login อยู่ และ client สามตัว
+type oauthFixture struct {
+	s            *AuthService
+	user         context.Context // ctx ที่มี principal ของผู้ใช้
+	public       *domain.OAuthClient
+	other        *domain.OAuthClient
+	confidential *domain.OAuthClient
+	secret       string
+}
+
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+	s := newTestService(t, WithOAuth(memory.NewOAuthClientRepository(), memory.NewAuthorizationCodeRepository(), memory.NewConsentRepository()))
+	u := mustRegister(t, s, "oauth@example.com", true)
+	admin := WithPrincipal(context.Background(), &Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+	register := func(name string, scopes []string, confidential bool) (*domain.OAuthClient, string) {
+		c, secret, err := s.RegisterOAuthClient(admin, name, []string{oauthRedirect}, scopes, confidential)
+		if err != nil {
+			t.Fatalf("RegisterOAuthClient(%s): %v", name, err)
+		}
+		return c, secret
+	}
+	f := &oauthFixture{s: s, user: WithPrincipal(context.Background(), &Principal{UserID: u.ID})}
+	f.public, _ = register("public", []string{domain.ScopeProfile}, false)
+	f.other, _ = register("other", []string{domain.ScopeProfile}, false)
+	f.confidential, f.secret = register("confidential", []string{domain.ScopeProfile, domain.ScopeEmail}, true)
+	return f
+}
+
+// request คืน authorization request ที่ถูกต้องสำหรับ client
+func (f *oauthFixture) request(c *domain.OAuthClient) AuthorizeRequest {
+	return AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            c.ID,
+		RedirectURI:         oauthRedirect,
+		Scope:               domain.ScopeProfile,
+		State:               "xyz",
+		CodeChallenge:       s256(oauthVerifier),
+		CodeChallengeMethod: pkceMethodS256,
+	}
+}
+
+// code ขอ authorization code ใหม่ (ผู้ใช้กดอนุญาต)
+func (f *oauthFixture) code(t *testing.T, c *domain.OAuthClient) string {
+	t.Helper()
+	a, err := f.s.Authorize(f.user, f.request(c), ConsentAllow)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return a.Code
+}
+
+func TestAuthorizeRequestValidation(t *testing.T) {
+	f := newOAuthFixture(t)
+	for _, tc := range []struct {
+		name   string
+		modify func(r *AuthorizeRequest)
+		want   error
+	}{
+		{"valid", func(r *AuthorizeRequest) {}, nil},
+		{"default scopes", func(r *AuthorizeRequest) { r.Scope = "" }, nil},
+		{"unknown client", func(r *AuthorizeRequest) { r.ClientID = "nope" }, ErrInvalidOAuthClient},
+		{"unregistered redirect_uri", func(r *AuthorizeRequest) { r.RedirectURI = "https://evil.example.com/cb" }, ErrInvalidRedirectURI},
+		{"response_type token", func(r *AuthorizeRequest) { r.ResponseType = "token" }, ErrUnsupportedResponseType},
+		{"missing code_challenge", func(r *AuthorizeRequest) { r.CodeChallenge = "" }, ErrPKCERequired},
+		{"missing code_challenge_method", func(r *AuthorizeRequest) { r.CodeChallengeMethod = "" }, ErrPKCERequired},
+		{"plain method", func(r *AuthorizeRequest) { r.CodeChallengeMethod = "plain" }, ErrPKCERequired},
+		{"short code_challenge", func(r *AuthorizeRequest) { r.CodeChallenge = "abc" }, ErrPKCERequired},
+		{"scope not allowed for the client", func(r *AuthorizeRequest) { r.Scope = domain.ScopeProfile + " " + domain.ScopeEmail }, ErrInvalidScope},
+		{"unknown scope", func(r *AuthorizeRequest) { r.Scope = "admin" }, ErrInvalidScope},
+		// ring ของ test เป็น HMAC จึงออก ID token ไม่ได้
+		{"openid with a symmetric key", func(r *AuthorizeRequest) { r.Scope = domain.ScopeOpenID }, ErrInvalidScope},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := f.request(f.public)
+			tc.modify(&req)
+			a, err := f.s.ValidateAuthorizeRequest(context.Background(), req)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("ValidateAuthorizeRequest = %v, want %v", err, tc.want)
+			}
+			if tc.want == nil && (a.Client.ID != f.public.ID || strings.Join(a.Scopes, " ") != domain.ScopeProfile) {
+				t.Fatalf("authorization = %+v", a)
+			}
+		})
+	}
+}
+
+func TestAuthorizeConsent(t *testing.T) {
+	f := newOAuthFixture(t)
+	req := f.request(f.public)
+
+	if _, err := f.s.Authorize(context.Background(), req, ConsentAllow); err == nil {
+		t.Fatal("Authorize without a signed-in user succeeded")
+	}
+	delegated := WithPrincipal(context.Background(), &Principal{UserID: "u", ClientID: f.other.ID})
+	if _, err := f.s.Authorize(delegated, req, ConsentAllow); !errors.Is(err, ErrInsufficientScope) {
+		t.Fatalf("Authorize with an OAuth token = %v, want ErrInsufficientScope", err)
+	}
+
+	a, err := f.s.Authorize(f.user, req, "")
+	if err != nil || !a.ConsentRequired || a.Code != "" {
+		t.Fatalf("Authorize before consent = %+v, %v; want ConsentRequired without a code", a, err)
+	}
+	if _, err := f.s.Authorize(f.user, req, ConsentDeny); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("Authorize(deny) = %v, want ErrAccessDenied", err)
+	}
+	if a, err := f.s.Authorize(f.user, req, ConsentAllow); err != nil || a.Code == "" {
+		t.Fatalf("Authorize(allow) = %+v, %v", a, err)
+	}
+	// อนุญาตแล้วครั้งต่อไปไม่ต้องถามซ้ำ
+	if a, err := f.s.Authorize(f.user, req, ""); err != nil || a.ConsentRequired || a.Code == "" {
+		t.Fatalf("Authorize after consent = %+v, %v; want a code", a, err)
+	}
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	f := newOAuthFixture(t)
+	for _, tc := range []struct {
+		name     string
+		client   *domain.OAuthClient // client ที่ได้ code
+		exchange func(code string) (*OAuthToken, error)
+		want     error
+	}{
+		{"public client, verifier matches", f.public, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, oauthVerifier)
+		}, nil},
+		{"confidential client with its secret", f.confidential, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.confidential.ID, f.secret, code, oauthRedirect, oauthVerifier)
+		}, nil},
+		{"verifier mismatch", f.public, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, strings.Repeat("a", 43))
+		}, ErrInvalidGrant},
+		{"malformed verifier", f.public, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, "short")
+		}, ErrInvalidGrant},
+		{"redirect_uri mismatch", f.public, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect+"/other", oauthVerifier)
+		}, ErrInvalidGrant},
+		{"code issued to another client", f.public, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.other.ID, "", code, oauthRedirect, oauthVerifier)
+		}, ErrInvalidGrant},
+		{"confidential client, wrong secret", f.confidential, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.confidential.ID, "wrong", code, oauthRedirect, oauthVerifier)
+		}, ErrInvalidOAuthClient},
+		{"missing verifier", f.public, func(code string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, "")
+		}, ErrInvalidOAuthRequest},
+		{"unknown code", f.public, func(string) (*OAuthToken, error) {
+			return f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", "no-such-code", oauthRedirect, oauthVerifier)
+		}, ErrInvalidGrant},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tok, err := tc.exchange(f.code(t, tc.client))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("ExchangeAuthorizationCode = %v, want %v", err, tc.want)
+			}
+			if tc.want != nil {
+				return
+			}
+			p, err := f.s.Authenticate(context.Background(), tok.AccessToken)
+			if err != nil {
+				t.Fatalf("Authenticate(access token): %v", err)
+			}
+			if p.ClientID != tc.client.ID || !p.HasScope(domain.ScopeProfile) || p.HasScope(domain.ScopeEmail) || tok.IDToken != "" {
+				t.Fatalf("principal = %+v, token = %+v; want only the profile scope for %s", p, tok, tc.client.ID)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeReplay(t *testing.T) {
+	f := newOAuthFixture(t)
+	code := f.code(t, f.public)
+	exchange := func() error {
+		_, err := f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, oauthVerifier)
+		return err
+	}
+	if err := exchange(); err != nil {
+		t.Fatalf("first exchange: %v", err)
+	}
+	if err := exchange(); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("replayed code = %v, want ErrInvalidGrant", err)
+	}
+}
+
+func TestAuthorizationCodeSurvivesOtherClient(t *testing.T) {
+	// client อื่นส่ง code ที่ไม่ใช่ของตัวเองมา ต้องไม่ทำให้ client เจ้าของแลก code ไม่ได้
+	f := newOAuthFixture(t)
+	code := f.code(t, f.public)
+	if _, err := f.s.ExchangeAuthorizationCode(context.Background(), f.other.ID, "", code, oauthRedirect, oauthVerifier); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("exchange by another client = %v, want ErrInvalidGrant", err)
+	}
+	if _, err := f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, oauthVerifier); err != nil {
+		t.Fatalf("exchange by the owning client: %v", err)
+	}
+}
+
+func TestAuthorizationCodeBurnedOnVerifierMismatch(t *testing.T) {
+	// verifier ผิดครั้งเดียวก็ใช้ code ไม่ได้อีก ผู้ที่ขโมย code ไปจึงเดา verifier หลายครั้งไม่ได้
+	f := newOAuthFixture(t)
+	code := f.code(t, f.public)
+	if _, err := f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, strings.Repeat("b", 43)); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("exchange with a wrong verifier = %v, want ErrInvalidGrant", err)
+	}
+	if _, err := f.s.ExchangeAuthorizationCode(context.Background(), f.public.ID, "", code, oauthRedirect, oauthVerifier); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("exchange after a wrong verifier = %v, want ErrInvalidGrant", err)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// WithIssuer กำหนด issuer ของ OpenID Connect (URL ภายนอกของ HTTP server เช่น https://auth.example.com)
+// ค่านี้อยู่ใน claim iss ของ ID token และเป็นฐานของ URL ใน discovery document
+func WithIssuer(issuer string) Option {
+	return func(s *AuthService) {
+		if issuer != "" {
+			s.issuer = strings.TrimRight(issuer, "/")
+		}
+	}
+}
+
+// UserInfo คือ claim ของผู้ใช้ที่ /userinfo คืนให้ (OpenID Connect Core §5.3)
+// มีเฉพาะ claim ที่ scope ของ token อนุญาต
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// idTokenClaims คือ claims ของ ID token: registered claims (aud = client_id) + nonce + claim ของผู้ใช้
+type idTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// OpenIDConfiguration คือ discovery document ที่ /.well-known/openid-configuration (OpenID Connect Discovery §3)
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OIDCEnabled บอกว่าเปิด OpenID Connect ได้หรือไม่: ต้องเปิด OAuth และ key ที่เซ็นอยู่ต้องเป็นแบบ asymmetric
+// ID token ที่เซ็นด้วย HS256 client ตรวจเองไม่ได้ (secret ไม่ถูกเผยแพร่ใน JWKS)
+// และ client ที่ถือ secret ร่วมกันก็ปลอม ID token ของผู้ใช้คนใดก็ได้
+func (s *AuthService) OIDCEnabled() bool {
+	return s.oauthClients != nil && !s.keyRing.Active().Symmetric()
+}
+
+// idTokenKey คืน key ที่ใช้เซ็น ID token: key ที่ active อยู่ ซึ่งอยู่ใน JWKS ด้วย ไม่ยอมใช้ HS256
+func (s *AuthService) idTokenKey() (*keys.Key, error) {
+	key := s.keyRing.Active()
+	if key.Symmetric() {
+		return nil, ErrOIDCDisabled
+	}
+	return key, nil
+}
+
+// OpenIDConfiguration คืน discovery document ตาม issuer และ key ที่ใช้เซ็นอยู่
+// คืน ErrOIDCDisabled ถ้าเปิด OpenID Connect ไม่ได้ (ดู OIDCEnabled)
+func (s *AuthService) OpenIDConfiguration() (*OpenIDConfiguration, error) {
+	if s.oauthClients == nil {
+		return nil, ErrOAuthDisabled
+	}
+	key, err := s.idTokenKey()
+	if err != nil {
+		return nil, err
+	}
+	grantTypes := []string{"authorization_code"}
+	if s.machineClients != nil {
+		grantTypes = append(grantTypes, "client_credentials")
+	}
+	return &OpenIDConfiguration{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth/token",
+		UserInfoEndpoint:                  s.issuer + "/oauth/userinfo",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             s.issuer + "/oauth/introspect",
+		RevocationEndpoint:                s.issuer + "/oauth/revoke",
+		ScopesSupported:                   domain.SupportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               grantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{key.Algorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{pkceMethodS256},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "email", "email_verified"},
+	}, nil
+}
+
+// UserInfo คืน claim ของเจ้าของ access token (ต้องเป็น token ที่ได้ scope openid)
+func (s *AuthService) UserInfo(ctx context.Context) (*UserInfo, error) {
+	p, err := principalFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.HasScope(domain.ScopeOpenID) {
+		return nil, ErrInsufficientScope
+	}
+	u, err := s.repo.FindByID(ctx, p.UserID)
+	if err != nil {
+		return nil, lookupErr(err, ErrInvalidToken)
+	}
+	info := userInfo(u, p.Scopes)
+	return &info, nil
+}
+
+// signIDToken ออก ID token ให้ client ที่ได้ scope openid อายุเท่ากับ access token
+func (s *AuthService) signIDToken(u *domain.User, clientID, nonce string, scopes []string) (string, error) {
+	info := userInfo(u, scopes)
+	now := time.Now()
+	claims := idTokenClaims{
+		Nonce:         nonce,
+		Name:          info.Name,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   u.ID,
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+	}
+	key, err := s.idTokenKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.SigningKey())
+}
+
+// userInfo เลือก claim ของผู้ใช้ตาม scope: profile ได้ name, email ได้ email และ email_verified
+func userInfo(u *domain.User, scopes []string) UserInfo {
+	info := UserInfo{Subject: u.ID}
+	for _, sc := range scopes {
+		switch sc {
+		case domain.ScopeProfile:
+			info.Name = u.Name
+		case domain.ScopeEmail:
+			verified := u.EmailVerified
+			info.Email, info.EmailVerified = u.Email, &verified
+		}
+	}
+	return info
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/LengLKR/auth-microservice/internal/password"
+	"github.com/google/uuid"
+)
+
+// WithPasswordHasher กำหนด algorithm ที่ใช้ hash รหัสผ่านใหม่ (ค่าเริ่มต้น argon2id)
+// hash เดิมของ algorithm อื่นยังตรวจได้ และจะถูก hash ใหม่ตอน login สำเร็จ
+func WithPasswordHasher(h password.Hasher) Option {
+	return func(s *AuthService) {
+		if h != nil {
+			s.hasher = h
+		}
+	}
+}
+
+// WithPasswordPolicy กำหนดเงื่อนไขของรหัสผ่านใหม่ตอน Register และ ResetPassword
+// (ค่าเริ่มต้นคือ password.DefaultPolicy)
+func WithPasswordPolicy(p *password.Policy) Option {
+	return func(s *AuthService) {
+		if p != nil {
+			s.passwordPolicy = p
+		}
+	}
+}
+
+// checkPassword ตรวจรหัสผ่านใหม่ตาม policy คืน ErrWeakPassword พร้อมทุก rule ที่ไม่ผ่านของ field
+func (s *AuthService) checkPassword(ctx context.Context, field, plain, email string) error {
+	vs, err := s.passwordPolicy.Validate(ctx, plain, email)
+	if err != nil {
+		return err
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	fvs := make([]FieldViolation, len(vs))
+	for i, v := range vs {
+		fvs[i] = FieldViolation{Field: field, Reason: v.Rule, Description: v.Description}
+	}
+	return withViolations(ErrWeakPassword, fvs)
+}
+
+// verifyDummy ตรวจรหัสผ่านกับ hash ที่สร้างไว้ล่วงหน้าด้วย hasher ปัจจุบัน ใช้เมื่อไม่พบผู้ใช้
+// ให้ Login ใช้เวลาพอ ๆ กันไม่ว่าจะมีบัญชีหรือไม่ (ไม่รั่วว่ามีอีเมลนี้ผ่านเวลาตอบ)
+func (s *AuthService) verifyDummy(plain string) {
+	s.dummyOnce.Do(func() {
+		var err error
+		if s.dummyHash, err = s.hasher.Hash(uuid.NewString()); err != nil {
+			log.Printf("failed to create dummy password hash: %v", err)
+		}
+	})
+	s.hasher.Verify(plain, s.dummyHash)
+}
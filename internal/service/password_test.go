@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/password"
+)
+
+// countingHasher นับจำนวนครั้งที่ Verify ถูกเรียก
+type countingHasher struct {
+	password.Hasher
+	verifies int
+}
+
+func (h *countingHasher) Verify(plain, encoded string) (bool, error) {
+	h.verifies++
+	return h.Hasher.Verify(plain, encoded)
+}
+
+func fastArgon2id() *password.Argon2id {
+	return &password.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestLoginUpgradesLegacyHash(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithPasswordHasher(password.NewBcrypt(4)))
+	u := mustRegister(t, s, "legacy@example.com", true)
+	if !strings.HasPrefix(u.PasswordHash, "$2") {
+		t.Fatalf("registered hash %q is not bcrypt", u.PasswordHash)
+	}
+
+	// เปลี่ยน hasher เป็น argon2id: hash เดิมยัง login ได้และถูก hash ใหม่
+	s.hasher = fastArgon2id()
+	if _, err := s.Login(ctx, "legacy@example.com", testPassword); err != nil {
+		t.Fatalf("Login with bcrypt hash: %v", err)
+	}
+	u, _ = s.repo.FindByEmail(ctx, "legacy@example.com")
+	if !strings.HasPrefix(u.PasswordHash, "$argon2id$") || s.hasher.NeedsRehash(u.PasswordHash) {
+		t.Fatalf("hash after login = %q, want an argon2id hash with the current parameters", u.PasswordHash)
+	}
+	upgraded := u.PasswordHash
+	if _, err := s.Login(ctx, "legacy@example.com", testPassword); err != nil {
+		t.Fatalf("Login with upgraded hash: %v", err)
+	}
+	if u, _ = s.repo.FindByEmail(ctx, "legacy@example.com"); u.PasswordHash != upgraded {
+		t.Fatal("an up-to-date hash was rehashed again")
+	}
+
+	// login ผิดไม่ upgrade
+	s.hasher = password.NewBcrypt(5)
+	if _, err := s.Login(ctx, "legacy@example.com", "wrong-password"); err != ErrInvalidCredentials {
+		t.Fatalf("Login with wrong password = %v, want ErrInvalidCredentials", err)
+	}
+	if u, _ = s.repo.FindByEmail(ctx, "legacy@example.com"); u.PasswordHash != upgraded {
+		t.Fatal("a failed login rehashed the password")
+	}
+}
+
+func TestLoginVerifiesDummyHashForUnknownEmail(t *testing.T) {
+	h := &countingHasher{Hasher: fastArgon2id()}
+	s := newTestService(t, WithPasswordHasher(h))
+	if _, err := s.Login(context.Background(), "nobody@example.com", testPassword); err != ErrInvalidCredentials {
+		t.Fatalf("Login = %v, want ErrInvalidCredentials", err)
+	}
+	if h.verifies != 1 {
+		t.Fatalf("hasher.Verify called %d times for an unknown email, want 1", h.verifies)
+	}
+	if !strings.HasPrefix(s.dummyHash, "$argon2id$") {
+		t.Fatalf("dummy hash %q was not made by the configured hasher", s.dummyHash)
+	}
+}
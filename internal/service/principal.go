@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Principal คือผู้เรียกที่ยืนยันตัวตนแล้ว (ได้จาก access token)
+type Principal struct {
+	UserID    string
+	Roles     []string
+	TokenID   string // jti ของ access token
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Token     string // access token ดิบ (ใช้ตอน logout)
+
+	// ClientID และ Scopes มีค่าเมื่อ token ออกให้ OAuth client แทนผู้ใช้
+	ClientID string
+	Scopes   []string
+}
+
+// Delegated บอกว่า token ออกให้ OAuth client (เรียกได้เฉพาะ RPC ที่ scope อนุญาต)
+func (p *Principal) Delegated() bool {
+	return p.ClientID != ""
+}
+
+// HasScope เช็คว่า token มี scope นี้หรือไม่
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal แนบ principal ไว้ใน ctx (เรียกจาก auth interceptor ของ transport)
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext ดึง principal ที่ interceptor แนบไว้
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticate ตรวจลายเซ็น อายุ การเพิกถอนด้วย jti และ LogoutAll ของ access token แล้วคืน principal
+func (s *AuthService) Authenticate(ctx context.Context, rawToken string) (*Principal, error) {
+	claims, err := s.parseToken(rawToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	revoked, err := s.tokenRepo.IsBlacklisted(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	p := &Principal{
+		UserID:    claims.Subject,
+		TokenID:   claims.ID,
+		Roles:     claims.Roles,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Token:     rawToken,
+		ClientID:  claims.ClientID,
+		Scopes:    strings.Fields(claims.Scope),
+	}
+	if claims.IssuedAt != nil {
+		p.IssuedAt = claims.IssuedAt.Time
+	}
+	if revoked, err = s.revokedByLogoutAll(ctx, p.UserID, p.IssuedAt); err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return p, nil
+}
+
+// principalFromCtx คืน principal ของ request หรือ error ถ้าไม่ได้ยืนยันตัวตน
+func principalFromCtx(ctx context.Context) (*Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return p, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/ratelimit"
+)
+
+// defaultIPPolicy ผ่อนกว่าของบัญชี เพราะผู้ใช้หลายคนอาจอยู่หลัง NAT เดียวกัน
+var defaultIPPolicy = ratelimit.Policy{
+	MaxAttempts: 20,
+	Window:      time.Minute,
+	Lockout:     time.Minute,
+	MaxLockout:  time.Hour,
+	ResetAfter:  24 * time.Hour,
+}
+
+// WithRateLimiter กำหนดที่เก็บตัวนับความล้มเหลว และ policy ต่อบัญชี (รวม MFA) กับต่อ IP
+// ค่าเริ่มต้นคือ ratelimit.MemoryLimiter ซึ่งใช้ได้กับ instance เดียว
+func WithRateLimiter(l ratelimit.RateLimiter, account, ip ratelimit.Policy) Option {
+	return func(s *AuthService) {
+		if l != nil {
+			s.limiter = l
+		}
+		s.accountPolicy = account
+		s.ipPolicy = ip
+	}
+}
+
+// limitKey คือ key ของ rate limiter พร้อม policy ที่ใช้กับ key นั้น
+type limitKey struct {
+	key    string
+	policy ratelimit.Policy
+}
+
+// loginKeys คืน key ของบัญชี (ตัวแรกเสมอ) และของ IP ถ้ารู้
+func (s *AuthService) loginKeys(ctx context.Context, email string) []limitKey {
+	return append([]limitKey{{ratelimit.EmailKey("login", email), s.accountPolicy}}, s.ipKeys(ctx)...)
+}
+
+// ipKeys คืน key ของ IP ผู้เรียก (ว่างถ้าไม่รู้ IP)
+func (s *AuthService) ipKeys(ctx context.Context) []limitKey {
+	if ip := ClientFromContext(ctx).IP; ip != "" {
+		return []limitKey{{"ip:" + ip, s.ipPolicy}}
+	}
+	return nil
+}
+
+func (s *AuthService) mfaKey(userID string) limitKey {
+	return limitKey{"mfa:" + userID, s.accountPolicy}
+}
+
+// checkLimit คืน limited พร้อม RetryAfter ถ้า key ใดถูกล็อกอยู่ (ใช้เวลารอที่นานที่สุด)
+func (s *AuthService) checkLimit(ctx context.Context, limited *Error, keys ...limitKey) error {
+	var wait time.Duration
+	for _, k := range keys {
+		d, err := s.limiter.Check(ctx, k.key)
+		if err != nil {
+			return err
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return retryLater(limited, wait)
+	}
+	return nil
+}
+
+// recordFailure บันทึกความล้มเหลวของทุก key
+// คืน limited ถ้าครั้งนี้ทำให้ key ใดถูกล็อก เพื่อให้ผู้เรียกรู้เวลารอทันที
+func (s *AuthService) recordFailure(ctx context.Context, limited *Error, keys ...limitKey) error {
+	var wait time.Duration
+	for _, k := range keys {
+		d, err := s.limiter.Fail(ctx, k.key, k.policy)
+		if err != nil {
+			return err
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return retryLater(limited, wait)
+	}
+	return nil
+}
+
+// UnlockAccount ล้างการล็อกของบัญชีทั้งฝั่ง login และ MFA (ต้องมี permission users:unlock)
+// ตัวนับของ IP ไม่ถูกแตะ เพราะ IP หนึ่งอาจใช้เดารหัสหลายบัญชี
+func (s *AuthService) UnlockAccount(ctx context.Context, userID string) error {
+	if _, err := s.requirePermission(ctx, domain.PermUsersUnlock); err != nil {
+		return err
+	}
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return userErr(err)
+	}
+	for _, k := range []limitKey{s.loginKeys(ctx, u.Email)[0], s.mfaKey(u.ID)} {
+		if err := s.limiter.Reset(ctx, k.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
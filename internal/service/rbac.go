@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// accessClaims คือ claims ของ access token: registered claims + role ของผู้ใช้
+// token ที่ออกให้ OAuth client มี client_id และ scope แทน role
+type accessClaims struct {
+	Roles    []string `json:"roles,omitempty"`
+	ClientID string   `json:"client_id,omitempty"`
+	Scope    string   `json:"scope,omitempty"` // คั่นด้วยช่องว่าง (RFC 9068)
+	jwt.RegisteredClaims
+}
+
+// rolesOf คืน role ของผู้ใช้ (บัญชีเก่าที่ยังไม่มี role ถือเป็น user)
+func rolesOf(u *domain.User) []string {
+	if len(u.Roles) == 0 {
+		return []string{domain.RoleUser}
+	}
+	return u.Roles
+}
+
+// requirePermission เช็คว่า role ของ principal ใน ctx มี permission ที่ต้องการ
+func (s *AuthService) requirePermission(ctx context.Context, perm string) (*Principal, error) {
+	p, err := principalFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.HasPermission(p.Roles, perm) {
+		return nil, ErrPermissionDenied
+	}
+	return p, nil
+}
+
+// AssignRole เพิ่ม role ให้ผู้ใช้ (ต้องมี permission roles:write)
+// role ใหม่มีผลกับ access token ที่ออกหลังจากนี้ (login หรือ refresh ครั้งถัดไป)
+func (s *AuthService) AssignRole(ctx context.Context, userID, role string) (_ domain.User, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditRoleAssign, SubjectID: userID, Reason: role}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	if _, err := s.requirePermission(ctx, domain.PermRolesWrite); err != nil {
+		return domain.User{}, err
+	}
+	if !domain.ValidRole(role) {
+		return domain.User{}, ErrUnknownRole
+	}
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, userErr(err)
+	}
+	if !u.HasRole(role) {
+		u.Roles = append(rolesOf(u), role)
+		if err := s.repo.Update(ctx, u); err != nil {
+			return domain.User{}, err
+		}
+	}
+	return *u, nil
+}
+
+// RevokeRole ถอน role ออกจากผู้ใช้ (ต้องมี permission roles:write)
+// admin ถอน role admin ของตัวเองไม่ได้ เพื่อไม่ให้ระบบไม่มี admin เหลือโดยไม่ตั้งใจ
+func (s *AuthService) RevokeRole(ctx context.Context, userID, role string) (_ domain.User, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditRoleRevoke, SubjectID: userID, Reason: role}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	p, err := s.requirePermission(ctx, domain.PermRolesWrite)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if !domain.ValidRole(role) {
+		return domain.User{}, ErrUnknownRole
+	}
+	if role == domain.RoleAdmin && p.UserID == userID {
+		return domain.User{}, withMessage(ErrPermissionDenied, "cannot revoke your own admin role")
+	}
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, userErr(err)
+	}
+	if u.HasRole(role) {
+		var kept []string
+		for _, r := range u.Roles {
+			if r != role {
+				kept = append(kept, r)
+			}
+		}
+		u.Roles = kept
+		if err := s.repo.Update(ctx, u); err != nil {
+			return domain.User{}, err
+		}
+	}
+	return *u, nil
+}
+
+// EnsureAdmins ให้ role admin กับบัญชีตามอีเมลที่กำหนด (ใช้ตอนเริ่มระบบเพื่อสร้าง admin คนแรก)
+// ข้ามบัญชีที่ยังไม่ยืนยันอีเมล ไม่อย่างนั้นใครก็ได้ที่สมัครด้วยอีเมลนั้นก่อนจะได้สิทธิ์ admin
+func (s *AuthService) EnsureAdmins(ctx context.Context, emails []string) error {
+	for _, email := range emails {
+		u, err := s.repo.FindByEmail(ctx, email)
+		if err != nil {
+			log.Printf("admin %s is not registered yet; skipping", email)
+			continue
+		}
+		if u.HasRole(domain.RoleAdmin) {
+			continue
+		}
+		if !u.EmailVerified {
+			log.Printf("admin %s has not verified their email yet; skipping", email)
+			continue
+		}
+		u.Roles = append(rolesOf(u), domain.RoleAdmin)
+		if err := s.repo.Update(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+)
+
+func TestEnsureAdminsRequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	verified := mustRegister(t, s, "admin@example.com", true)
+	unverified := mustRegister(t, s, "squatter@example.com", false)
+
+	if err := s.EnsureAdmins(ctx, []string{verified.Email, unverified.Email, "missing@example.com"}); err != nil {
+		t.Fatalf("EnsureAdmins: %v", err)
+	}
+	for _, tc := range []struct {
+		u    *domain.User
+		want bool
+	}{{verified, true}, {unverified, false}} {
+		got, err := s.repo.FindByID(ctx, tc.u.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.HasRole(domain.RoleAdmin) != tc.want {
+			t.Errorf("%s admin = %v, want %v", got.Email, !tc.want, tc.want)
+		}
+	}
+}
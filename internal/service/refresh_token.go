@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrRefreshTokenReused ถูกคืนเมื่อมีการนำ refresh token ที่ rotate ไปแล้วกลับมาใช้ซ้ำ
+// ซึ่ง token ทั้ง family จะถูกเพิกถอนทันที
+var ErrRefreshTokenReused = Unauthenticated("REFRESH_TOKEN_REUSED", "refresh token reuse detected")
+
+// TokenPair คือชุด token ที่ออกให้หลัง register/login/refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // อายุของ access token
+
+	// MFAToken ไม่ว่างเมื่อผู้ใช้เปิด MFA: ต้องเรียก VerifyMFA ก่อนจึงจะได้ token จริง
+	MFAToken string
+	// VerificationRequired เป็น true เมื่อสมัครสำเร็จแต่ต้องยืนยันอีเมลก่อน login
+	VerificationRequired bool
+}
+
+// RefreshToken แลก refresh token เป็นชุด token ใหม่ (rotation)
+// ถ้า token เคยถูกใช้แล้ว ถือว่าถูกขโมย และเพิกถอนทั้ง family
+func (s *AuthService) RefreshToken(ctx context.Context, rawRefresh string) (_ *TokenPair, err error) {
+	ev := domain.AuditEvent{Type: domain.AuditTokenRefresh}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	hash := hashToken(rawRefresh)
+	rec, err := s.refreshRepo.FindByHash(ctx, hash)
+	if err != nil {
+		return nil, lookupErr(err, ErrInvalidRefreshToken)
+	}
+	ev.SubjectID = rec.UserID
+	if rec.RotatedAt != nil || rec.RevokedAt != nil {
+		if err := s.refreshRepo.RevokeFamily(ctx, rec.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(rec.ExpiresAt) {
+		return nil, withMessage(ErrInvalidRefreshToken, "refresh token expired")
+	}
+	revoked, err := s.revokedByLogoutAll(ctx, rec.UserID, rec.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, withMessage(ErrInvalidRefreshToken, "refresh token revoked")
+	}
+
+	// มี request อื่นใช้ token นี้ไปพร้อมกัน -> ถือเป็น reuse เช่นกัน
+	ok, err := s.refreshRepo.MarkRotated(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.refreshRepo.RevokeFamily(ctx, rec.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	// ผู้ใช้ที่ถูกลบแล้วต้องไม่ได้ token ใหม่ และ role ใน access token ใหม่ต้องเป็นค่าล่าสุด
+	u, err := s.repo.FindByID(ctx, rec.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	ev.ActorID, ev.Email = u.ID, u.Email
+	return s.issueTokens(ctx, u, rec.FamilyID)
+}
+
+// issueTokens ออก access token และ refresh token ใหม่
+// familyID ว่างหมายถึงเริ่ม family ใหม่ (login/register)
+func (s *AuthService) issueTokens(ctx context.Context, u *domain.User, familyID string) (*TokenPair, error) {
+	access, err := s.generateToken(u)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+	rec := &domain.RefreshToken{
+		TokenHash: hashToken(refresh),
+		UserID:    u.ID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.refreshRepo.Create(ctx, rec); err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    s.accessTTL,
+	}, nil
+}
+
+// newOpaqueToken สุ่ม token 256 บิตในรูป base64url
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken คืน SHA-256 (hex) ของ token เพื่อไม่ต้องเก็บค่าจริงใน DB
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
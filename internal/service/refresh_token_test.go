@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+)
+
+// loginPair login ผู้ใช้ใหม่แล้วคืนชุด token แรกของ family
+func loginPair(t *testing.T, s *AuthService, email string) (*domain.User, *TokenPair) {
+	t.Helper()
+	u := mustRegister(t, s, email, true)
+	pair, err := s.Login(context.Background(), email, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return u, pair
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	_, first := loginPair(t, s, "rotate@example.com")
+
+	second, err := s.RefreshToken(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if second.AccessToken == "" || second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("RefreshToken = %+v, want a new pair", second)
+	}
+	old, err := s.refreshRepo.FindByHash(ctx, hashToken(first.RefreshToken))
+	if err != nil {
+		t.Fatalf("FindByHash(old): %v", err)
+	}
+	if old.RotatedAt == nil || old.RevokedAt != nil {
+		t.Fatalf("old token = %+v, want rotated but not revoked", old)
+	}
+	cur, err := s.refreshRepo.FindByHash(ctx, hashToken(second.RefreshToken))
+	if err != nil {
+		t.Fatalf("FindByHash(new): %v", err)
+	}
+	if cur.FamilyID != old.FamilyID || cur.RotatedAt != nil {
+		t.Fatalf("new token = %+v, want an unused token in family %s", cur, old.FamilyID)
+	}
+	if _, err := s.Authenticate(ctx, second.AccessToken); err != nil {
+		t.Fatalf("Authenticate(new access token): %v", err)
+	}
+	// token ใหม่ rotate ต่อได้
+	if _, err := s.RefreshToken(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken(new): %v", err)
+	}
+}
+
+func TestRefreshTokenReplayRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	_, first := loginPair(t, s, "replay@example.com")
+	second, err := s.RefreshToken(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	third, err := s.RefreshToken(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	// family อื่นของผู้ใช้คนเดิมต้องไม่โดนไปด้วย
+	other, err := s.Login(ctx, "replay@example.com", testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if _, err := s.RefreshToken(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken(replayed) = %v, want ErrRefreshTokenReused", err)
+	}
+	newest, _ := s.refreshRepo.FindByHash(ctx, hashToken(third.RefreshToken))
+	if newest.RevokedAt == nil {
+		t.Fatalf("newest token in the family = %+v, want revoked", newest)
+	}
+	if _, err := s.RefreshToken(ctx, third.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken(newest after replay) = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.RefreshToken(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken(other family): %v", err)
+	}
+}
+
+func TestRefreshTokenConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	_, pair := loginPair(t, s, "race@example.com")
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.RefreshToken(ctx, pair.RefreshToken); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrRefreshTokenReused) {
+				t.Errorf("RefreshToken = %v, want nil or ErrRefreshTokenReused", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if successes != 1 {
+		t.Fatalf("%d concurrent refreshes with one token succeeded, want 1", successes)
+	}
+}
+
+func TestRefreshTokenRejectsInvalid(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u, _ := loginPair(t, s, "invalid@example.com")
+
+	expired, err := newOpaqueToken()
+	if err != nil {
+		t.Fatalf("newOpaqueToken: %v", err)
+	}
+	if err := s.refreshRepo.Create(ctx, &domain.RefreshToken{
+		TokenHash: hashToken(expired),
+		UserID:    u.ID,
+		FamilyID:  "expired-family",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for name, raw := range map[string]string{
+		"unknown": "not-a-refresh-token",
+		"empty":   "",
+		"expired": expired,
+	} {
+		if _, err := s.RefreshToken(ctx, raw); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("RefreshToken(%s) = %v, want ErrInvalidRefreshToken", name, err)
+		}
+	}
+	if ev := lastAudit(t, s, domain.AuditTokenRefresh); ev.Outcome != domain.AuditFailure || ev.Reason != ErrInvalidRefreshToken.Reason {
+		t.Fatalf("failed refresh event = %+v", ev)
+	}
+}
+
+func TestRefreshTokenOfDeletedUser(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	u, pair := loginPair(t, s, "deleted@example.com")
+	if err := s.repo.SoftDelete(ctx, u.ID); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	if _, err := s.RefreshToken(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RefreshToken(deleted user) = %v, want ErrInvalidRefreshToken", err)
+	}
+}
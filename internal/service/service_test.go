@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// testPassword ผ่าน password policy ค่าเริ่มต้น
+const testPassword = "correct-horse-battery-9"
+
+// newTestService สร้าง AuthService บน memory repository พร้อม audit log
+func newTestService(t *testing.T, opts ...Option) *AuthService {
+	t.Helper()
+	k, err := keys.NewHMACKey("test", strings.Repeat("k", 40))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+	ring, err := keys.NewRing(k, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	opts = append([]Option{WithAuditLog(memory.NewAuditRepository())}, opts...)
+	return NewAuthService(memory.NewUserRepository(), memory.NewTokenRepository(), memory.NewPasswordResetRepository(),
+		memory.NewEmailVerificationRepository(), memory.NewRefreshTokenRepository(), ring, mail.NewMemoryMailer(), opts...)
+}
+
+// mustRegister สร้างผู้ใช้ที่ยืนยันอีเมลแล้ว (หรือยัง ตาม verified)
+func mustRegister(t *testing.T, s *AuthService, email string, verified bool) *domain.User {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.Register(ctx, email, testPassword); err != nil {
+		t.Fatalf("Register(%s): %v", email, err)
+	}
+	u, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("FindByEmail(%s): %v", email, err)
+	}
+	if verified {
+		u.EmailVerified = true
+		if err := s.repo.Update(ctx, u); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	return u
+}
+
+// auditEvents คืน event ทั้งหมดของชนิด typ (ใหม่สุดก่อน)
+func auditEvents(t *testing.T, s *AuthService, typ string) []*domain.AuditEvent {
+	t.Helper()
+	events, _, err := s.auditRepo.List(context.Background(), domain.AuditFilter{Type: typ}, 1, 100)
+	if err != nil {
+		t.Fatalf("audit List: %v", err)
+	}
+	return events
+}
+
+// resign เซ็น claims ของ token เดิมใหม่ด้วย active key หลังให้ mutate แก้ claims (เช่นทำให้หมดอายุ)
+func resign(t *testing.T, s *AuthService, raw string, mutate func(jwt.MapClaims)) string {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(raw, claims); err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	mutate(claims)
+	key := s.keyRing.Active()
+	tok := jwt.NewWithClaims(key.Method(), claims)
+	tok.Header["kid"] = key.ID
+	out, err := tok.SignedString(key.SigningKey())
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	repo "github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+// passkeySessionTTL อายุของ session ระหว่าง begin และ finish
+const passkeySessionTTL = 5 * time.Minute
+
+// ErrPasskeysDisabled ถูกคืนเมื่อไม่ได้ตั้งค่า WebAuthn ให้ service
+var ErrPasskeysDisabled = FailedPrecondition("PASSKEYS_DISABLED", "passkeys are not configured")
+
+// WithWebAuthn เปิดใช้ passkey (WebAuthn) พร้อม repository ของ credential และ session
+func WithWebAuthn(w *webauthn.WebAuthn, creds repo.WebAuthnCredentialRepository, sessions repo.WebAuthnSessionRepository) Option {
+	return func(s *AuthService) {
+		s.webauthn = w
+		s.credRepo = creds
+		s.passkeySessions = sessions
+	}
+}
+
+// BeginPasskeyRegistration เริ่มลงทะเบียน passkey ให้ผู้ใช้ที่ login อยู่
+// คืน options (JSON สำหรับ navigator.credentials.create) และ session ID
+func (s *AuthService) BeginPasskeyRegistration(ctx context.Context) (options []byte, sessionID string, err error) {
+	if s.webauthn == nil {
+		return nil, "", ErrPasskeysDisabled
+	}
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	wu, err := s.loadWebAuthnUser(ctx, u)
+	if err != nil {
+		return nil, "", err
+	}
+	creation, session, err := s.webauthn.BeginRegistration(wu,
+		webauthn.WithExclusions(webauthn.Credentials(wu.creds).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.savePasskeySession(ctx, creation, session)
+}
+
+// FinishPasskeyRegistration ตรวจ attestation จาก authenticator แล้วบันทึก credential
+func (s *AuthService) FinishPasskeyRegistration(ctx context.Context, sessionID string, response []byte, name string) error {
+	if s.webauthn == nil {
+		return ErrPasskeysDisabled
+	}
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	session, err := s.loadPasskeySession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(session.UserID, []byte(u.ID)) {
+		return withMessage(ErrInvalidPasskeySession, "passkey session does not belong to this user")
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return ErrInvalidPasskeyResponse
+	}
+	wu, err := s.loadWebAuthnUser(ctx, u)
+	if err != nil {
+		return err
+	}
+	cred, err := s.webauthn.CreateCredential(wu, *session, parsed)
+	if err != nil {
+		return withMessage(ErrInvalidPasskeyResponse, err.Error())
+	}
+	transports := make([]string, len(cred.Transport))
+	for i, t := range cred.Transport {
+		transports[i] = string(t)
+	}
+	return s.credRepo.Create(ctx, &domain.WebAuthnCredential{
+		ID:              base64.RawURLEncoding.EncodeToString(cred.ID),
+		UserID:          u.ID,
+		Name:            name,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Transports:      transports,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+	})
+}
+
+// BeginPasskeyLogin เริ่ม login ด้วย passkey
+// ถ้าไม่ระบุ email จะเป็น discoverable login (authenticator เลือกบัญชีเอง)
+func (s *AuthService) BeginPasskeyLogin(ctx context.Context, email string) (options []byte, sessionID string, err error) {
+	if s.webauthn == nil {
+		return nil, "", ErrPasskeysDisabled
+	}
+	uv := webauthn.WithUserVerification(protocol.VerificationRequired)
+	if email == "" {
+		assertion, session, err := s.webauthn.BeginDiscoverableLogin(uv)
+		if err != nil {
+			return nil, "", err
+		}
+		return s.savePasskeySession(ctx, assertion, session)
+	}
+	u, err := s.repo.FindByEmail(ctx, email)
+	if err != nil || u.DeletedAt != nil {
+		return nil, "", ErrNoPasskeys
+	}
+	wu, err := s.loadWebAuthnUser(ctx, u)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(wu.creds) == 0 {
+		return nil, "", ErrNoPasskeys
+	}
+	assertion, session, err := s.webauthn.BeginLogin(wu, uv)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.savePasskeySession(ctx, assertion, session)
+}
+
+// FinishPasskeyLogin ตรวจ assertion, อัปเดต sign counter แล้วออก token
+// ใช้ rate limit, การบังคับยืนยันอีเมล และ audit เหมือน Login ด้วยรหัสผ่าน
+// ตัวนับของบัญชีใช้ร่วมกับ Login บัญชีที่ถูกล็อกจึง login ด้วย passkey ไม่ได้เช่นกัน
+func (s *AuthService) FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte) (_ *TokenPair, err error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysDisabled
+	}
+	ev := domain.AuditEvent{Type: domain.AuditPasskeyLogin}
+	defer func() { s.audit(ctx, &ev, err) }()
+
+	limits := s.ipKeys(ctx)
+	if err := s.checkLimit(ctx, ErrTooManyLoginAttempts, limits...); err != nil {
+		return nil, err
+	}
+	session, err := s.loadPasskeySession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPasskeySession) {
+			return nil, withMessage(ErrInvalidPasskeyAssertion, err.Error())
+		}
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, ErrInvalidPasskeyAssertion
+	}
+
+	var wu *webauthnUser
+	var cred *webauthn.Credential
+	if len(session.UserID) == 0 {
+		// discoverable login: หาเจ้าของจาก userHandle
+		cred, err = s.webauthn.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+			u, err := s.repo.FindByID(ctx, string(userHandle))
+			if err != nil {
+				return nil, err
+			}
+			wu, err = s.loadWebAuthnUser(ctx, u)
+			return wu, err
+		}, *session, parsed)
+	} else {
+		var u *domain.User
+		u, err = s.repo.FindByID(ctx, string(session.UserID))
+		if err != nil {
+			return nil, ErrInvalidPasskeyAssertion
+		}
+		if wu, err = s.loadWebAuthnUser(ctx, u); err != nil {
+			return nil, err
+		}
+		cred, err = s.webauthn.ValidateLogin(wu, *session, parsed)
+	}
+	if wu != nil {
+		ev.SubjectID, ev.Email = wu.u.ID, wu.u.Email
+		limits = s.loginKeys(ctx, wu.u.Email)
+		if err := s.checkLimit(ctx, ErrTooManyLoginAttempts, limits...); err != nil {
+			return nil, err
+		}
+	}
+	// sign counter ไม่เพิ่มขึ้น: อาจมีการ clone authenticator
+	if err != nil || cred.Authenticator.CloneWarning {
+		if err := s.recordFailure(ctx, ErrTooManyLoginAttempts, limits...); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			return nil, ErrInvalidPasskeyAssertion
+		}
+		return nil, ErrPasskeyCloned
+	}
+	id := base64.RawURLEncoding.EncodeToString(cred.ID)
+	if err := s.credRepo.UpdateSignCount(ctx, id, cred.Authenticator.SignCount, cred.Flags.BackupState, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.limiter.Reset(ctx, limits[0].key); err != nil {
+		return nil, err
+	}
+	if s.requireVerifiedEmail && !wu.u.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	ev.ActorID = wu.u.ID
+	return s.issueTokens(ctx, wu.u, "")
+}
+
+// savePasskeySession เก็บ session data แล้วคืน options ที่ต้องส่งให้ client
+func (s *AuthService) savePasskeySession(ctx context.Context, options interface{}, session *webauthn.SessionData) ([]byte, string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, "", err
+	}
+	id := uuid.NewString()
+	if err := s.passkeySessions.Create(ctx, id, data, time.Now().Add(passkeySessionTTL)); err != nil {
+		return nil, "", err
+	}
+	out, err := json.Marshal(options)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, id, nil
+}
+
+// loadPasskeySession ดึง session ออกมา (ใช้ได้ครั้งเดียว)
+func (s *AuthService) loadPasskeySession(ctx context.Context, id string) (*webauthn.SessionData, error) {
+	data, err := s.passkeySessions.Consume(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, ErrInvalidPasskeySession)
+	}
+	var session webauthn.SessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// webauthnUser ห่อ domain.User ให้ตรงกับ webauthn.User
+type webauthnUser struct {
+	u     *domain.User
+	creds []webauthn.Credential
+}
+
+func (w *webauthnUser) WebAuthnID() []byte                         { return []byte(w.u.ID) }
+func (w *webauthnUser) WebAuthnName() string                       { return w.u.Email }
+func (w *webauthnUser) WebAuthnCredentials() []webauthn.Credential { return w.creds }
+
+func (w *webauthnUser) WebAuthnDisplayName() string {
+	if w.u.Name != "" {
+		return w.u.Name
+	}
+	return w.u.Email
+}
+
+// loadWebAuthnUser โหลด credential ของผู้ใช้จาก repository
+func (s *AuthService) loadWebAuthnUser(ctx context.Context, u *domain.User) (*webauthnUser, error) {
+	stored, err := s.credRepo.FindByUserID(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	wu := &webauthnUser{u: u}
+	for _, c := range stored {
+		id, err := base64.RawURLEncoding.DecodeString(c.ID)
+		if err != nil {
+			continue
+		}
+		transports := make([]protocol.AuthenticatorTransport, len(c.Transports))
+		for i, t := range c.Transports {
+			transports[i] = protocol.AuthenticatorTransport(t)
+		}
+		wu.creds = append(wu.creds, webauthn.Credential{
+			ID:              id,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return wu, nil
+}
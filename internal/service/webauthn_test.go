@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	testRPID   = "auth.example.com"
+	testOrigin = "https://auth.example.com"
+)
+
+// softAuthenticator คือ authenticator แบบ software (attestation "none", ES256)
+// ใช้ทดสอบ ceremony ของ WebAuthn ทั้งสองขั้นโดยไม่ต้องมีอุปกรณ์จริง
+type softAuthenticator struct {
+	t          *testing.T
+	origin     string
+	key        *ecdsa.PrivateKey
+	credID     []byte
+	userHandle []byte
+	signCount  uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	credID := make([]byte, 16)
+	rand.Read(credID)
+	return &softAuthenticator{t: t, origin: testOrigin, key: key, credID: credID}
+}
+
+// create ตอบ options ของ navigator.credentials.create
+func (a *softAuthenticator) create(options []byte) []byte {
+	a.t.Helper()
+	var opts struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			User      struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &opts); err != nil {
+		a.t.Fatalf("creation options: %v", err)
+	}
+	a.userHandle = mustB64(a.t, opts.PublicKey.User.ID)
+
+	pub, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatalf("marshal COSE key: %v", err)
+	}
+	attested := make([]byte, 16, 16+2+len(a.credID)+len(pub)) // AAGUID ศูนย์ทั้งหมด
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credID)))
+	attested = append(attested, a.credID...)
+	attested = append(attested, pub...)
+	authData := append(a.authData(0x40), attested...)
+
+	attObj, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		a.t.Fatalf("marshal attestation object: %v", err)
+	}
+	resp := protocol.CredentialCreationResponse{
+		PublicKeyCredential: a.credential(),
+		AttestationResponse: protocol.AuthenticatorAttestationResponse{
+			AuthenticatorResponse: protocol.AuthenticatorResponse{
+				ClientDataJSON: a.clientData(protocol.CreateCeremony, opts.PublicKey.Challenge),
+			},
+			AttestationObject: attObj,
+			Transports:        []string{"internal"},
+		},
+	}
+	out, _ := json.Marshal(resp)
+	return out
+}
+
+// get ตอบ options ของ navigator.credentials.get
+func (a *softAuthenticator) get(options []byte) []byte {
+	a.t.Helper()
+	var opts struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &opts); err != nil {
+		a.t.Fatalf("request options: %v", err)
+	}
+	a.signCount++
+	authData := a.authData(0)
+	clientData := a.clientData(protocol.AssertCeremony, opts.PublicKey.Challenge)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), sha256Sum(clientData)...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatalf("sign assertion: %v", err)
+	}
+	resp := protocol.CredentialAssertionResponse{
+		PublicKeyCredential: a.credential(),
+		AssertionResponse: protocol.AuthenticatorAssertionResponse{
+			AuthenticatorResponse: protocol.AuthenticatorResponse{ClientDataJSON: clientData},
+			AuthenticatorData:     authData,
+			Signature:             sig,
+			UserHandle:            a.userHandle,
+		},
+	}
+	out, _ := json.Marshal(resp)
+	return out
+}
+
+// authData ประกอบ rpIdHash, flags (UP, UV และ extra) และ sign counter
+func (a *softAuthenticator) authData(extra byte) []byte {
+	rpHash := sha256.Sum256([]byte(testRPID))
+	out := append(rpHash[:], 0x01|0x04|extra)
+	return binary.BigEndian.AppendUint32(out, a.signCount)
+}
+
+func (a *softAuthenticator) clientData(typ protocol.CeremonyType, challenge string) []byte {
+	out, _ := json.Marshal(protocol.CollectedClientData{Type: typ, Challenge: challenge, Origin: a.origin})
+	return out
+}
+
+func (a *softAuthenticator) credential() protocol.PublicKeyCredential {
+	return protocol.PublicKeyCredential{
+		Credential: protocol.Credential{ID: base64.RawURLEncoding.EncodeToString(a.credID), Type: "public-key"},
+		RawID:      a.credID,
+	}
+}
+
+func sha256Sum(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+func mustB64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return b
+}
+
+func newPasskeyService(t *testing.T, opts ...Option) *AuthService {
+	t.Helper()
+	wa, err := webauthn.New(&webauthn.Config{RPID: testRPID, RPDisplayName: "Test", RPOrigins: []string{testOrigin}})
+	if err != nil {
+		t.Fatalf("webauthn.New: %v", err)
+	}
+	opts = append(opts, WithWebAuthn(wa, memory.NewWebAuthnCredentialRepository(), memory.NewWebAuthnSessionRepository()))
+	return newTestService(t, opts...)
+}
+
+// registerPasskey ลงทะเบียน authenticator ให้ผู้ใช้ u
+func registerPasskey(t *testing.T, s *AuthService, u *domain.User, a *softAuthenticator) {
+	t.Helper()
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: u.ID, Roles: u.Roles})
+	options, sessionID, err := s.BeginPasskeyRegistration(ctx)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	if err := s.FinishPasskeyRegistration(ctx, sessionID, a.create(options), "laptop"); err != nil {
+		t.Fatalf("FinishPasskeyRegistration: %v", err)
+	}
+}
+
+// passkeyLogin ทำ login ด้วย passkey ครบทั้งสองขั้น (email ว่าง = discoverable)
+func passkeyLogin(t *testing.T, s *AuthService, a *softAuthenticator, email string) (*TokenPair, error) {
+	t.Helper()
+	ctx := context.Background()
+	options, sessionID, err := s.BeginPasskeyLogin(ctx, email)
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	return s.FinishPasskeyLogin(ctx, sessionID, a.get(options))
+}
+
+func TestPasskeyCeremonies(t *testing.T) {
+	s := newPasskeyService(t)
+	u := mustRegister(t, s, "passkey@example.com", true)
+	a := newSoftAuthenticator(t)
+	registerPasskey(t, s, u, a)
+
+	for _, email := range []string{u.Email, ""} {
+		pair, err := passkeyLogin(t, s, a, email)
+		if err != nil {
+			t.Fatalf("passkey login (email %q): %v", email, err)
+		}
+		p, err := s.Authenticate(context.Background(), pair.AccessToken)
+		if err != nil || p.UserID != u.ID {
+			t.Fatalf("Authenticate = %+v, %v; want user %s", p, err, u.ID)
+		}
+	}
+
+	creds, _ := s.credRepo.FindByUserID(context.Background(), u.ID)
+	if len(creds) != 1 || creds[0].SignCount != 2 {
+		t.Fatalf("stored credentials = %+v, want one with sign count 2", creds)
+	}
+	events := auditEvents(t, s, domain.AuditPasskeyLogin)
+	if len(events) != 2 || events[0].Outcome != domain.AuditSuccess || events[0].ActorID != u.ID {
+		t.Fatalf("passkey login audit events = %+v", events)
+	}
+}
+
+func TestFinishPasskeyRegistrationRejectsInvalidAttestation(t *testing.T) {
+	s := newPasskeyService(t)
+	u := mustRegister(t, s, "origin@example.com", true)
+	a := newSoftAuthenticator(t)
+	a.origin = "https://evil.example.com"
+
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: u.ID, Roles: u.Roles})
+	options, sessionID, err := s.BeginPasskeyRegistration(ctx)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	err = s.FinishPasskeyRegistration(ctx, sessionID, a.create(options), "laptop")
+	if !errors.Is(err, ErrInvalidPasskeyResponse) {
+		t.Fatalf("FinishPasskeyRegistration from wrong origin = %v, want ErrInvalidPasskeyResponse", err)
+	}
+}
+
+func TestFinishPasskeyLoginRequiresVerifiedEmail(t *testing.T) {
+	s := newPasskeyService(t, WithRequireVerifiedEmail(true))
+	u := mustRegister(t, s, "unverified@example.com", false)
+	a := newSoftAuthenticator(t)
+	registerPasskey(t, s, u, a)
+
+	if _, err := passkeyLogin(t, s, a, u.Email); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("passkey login with unverified email = %v, want ErrEmailNotVerified", err)
+	}
+}
+
+func TestFinishPasskeyLoginLockout(t *testing.T) {
+	s := newPasskeyService(t)
+	u := mustRegister(t, s, "lockout@example.com", true)
+	a := newSoftAuthenticator(t)
+	registerPasskey(t, s, u, a)
+
+	// key อื่นที่ไม่ได้ลงทะเบียนไว้: ลายเซ็นไม่ผ่าน
+	forged := newSoftAuthenticator(t)
+	forged.credID, forged.userHandle = a.credID, a.userHandle
+
+	var err error
+	for i := 0; i < 20 && !errors.Is(err, ErrTooManyLoginAttempts); i++ {
+		_, err = passkeyLogin(t, s, forged, u.Email)
+		if err != nil && !errors.Is(err, ErrInvalidPasskeyAssertion) && !errors.Is(err, ErrTooManyLoginAttempts) {
+			t.Fatalf("forged assertion = %v", err)
+		}
+	}
+	if !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("forged assertions never locked the account: %v", err)
+	}
+	// บัญชีถูกล็อกแล้ว passkey ที่ถูกต้องก็ใช้ไม่ได้ (เหมือนรหัสผ่านที่ถูก)
+	if _, err := passkeyLogin(t, s, a, u.Email); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("valid passkey on a locked account = %v, want ErrTooManyLoginAttempts", err)
+	}
+	if _, err := s.Login(context.Background(), u.Email, testPassword); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("password login on a locked account = %v, want ErrTooManyLoginAttempts", err)
+	}
+	for _, e := range auditEvents(t, s, domain.AuditPasskeyLogin) {
+		if e.Outcome != domain.AuditFailure || e.SubjectID != u.ID {
+			t.Fatalf("audit event for a failed passkey login = %+v", e)
+		}
+	}
+}
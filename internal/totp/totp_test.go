@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret คือ seed SHA1 "12345678901234567890" จาก RFC 6238 appendix B ในรูป base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeMatchesRFC6238(t *testing.T) {
+	// RFC ให้รหัส 8 หลัก รหัส 6 หลักคือ 6 หลักท้าย
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		got, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("Code at %d = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+	// secret ตัวพิมพ์เล็กหรือมีช่องว่างก็ใช้ได้
+	if got, _ := Code(" "+strings.ToLower(rfcSecret)+" ", Step(time.Unix(59, 0))); got != "287082" {
+		t.Fatalf("Code(lowercase secret) = %s", got)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("Code accepted an invalid secret")
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	for _, tc := range []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	} {
+		code, _ := Code(rfcSecret, step+tc.offset)
+		got, ok := Validate(rfcSecret, code, now)
+		if ok != tc.ok {
+			t.Errorf("%s: Validate = %v, want %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if ok && got != step+tc.offset {
+			t.Errorf("%s: matched step %d, want %d", tc.name, got, step+tc.offset)
+		}
+	}
+	code, _ := Code(rfcSecret, step)
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := Validate(rfcSecret, bad, now); ok {
+			t.Errorf("Validate(%q) accepted", bad)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " "+code+" ", now); !ok {
+		t.Error("Validate rejected a code with surrounding spaces")
+	}
+	if _, ok := Validate("not base32!", code, now); ok {
+		t.Error("Validate accepted an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Fatal("two secrets are equal")
+	}
+	raw, err := b32.DecodeString(a)
+	if err != nil || len(raw) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes (%v), want 20", a, len(raw), err)
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Fatalf("Code(generated secret): %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	raw := URI("Auth Service", "alice@example.com", rfcSecret)
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", raw, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Auth Service:alice@example.com" {
+		t.Fatalf("URI = %q", raw)
+	}
+	q := u.Query()
+	for k, want := range map[string]string{
+		"secret": rfcSecret, "issuer": "Auth Service", "algorithm": "SHA1", "digits": "6", "period": "30",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// GatewayKeyHeader คือ metadata ที่ REST gateway แนบไปกับทุก call เพื่อบอกว่า x-forwarded-for มาจากตัวมันเอง
+const GatewayKeyHeader = "x-auth-gateway-key"
+
+// NewGatewayKey สุ่ม key ที่ gateway กับ gRPC server ของ process เดียวกันใช้ร่วมกัน
+func NewGatewayKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ClientInterceptor แนบ IP และ user agent ของผู้เรียกไว้ใน ctx ให้ rate limiter และ audit log ของ service ใช้
+type ClientInterceptor struct {
+	gatewayKey string
+}
+
+// NewClientInterceptor สร้าง interceptor ที่เชื่อ x-forwarded-for เฉพาะ call ที่มี gatewayKey ใน GatewayKeyHeader
+// gatewayKey ว่างคือไม่มี gateway: ใช้ IP ของ peer เสมอ
+func NewClientInterceptor(gatewayKey string) *ClientInterceptor {
+	return &ClientInterceptor{gatewayKey: gatewayKey}
+}
+
+// Unary คืน grpc.UnaryServerInterceptor
+func (c *ClientInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(service.WithClient(ctx, c.clientInfo(ctx)), req)
+	}
+}
+
+// Stream คืน grpc.StreamServerInterceptor
+func (c *ClientInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := service.WithClient(ss.Context(), c.clientInfo(ss.Context()))
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// clientInfo คืน IP ของ peer ที่ต่อเข้ามาและ user agent ของมัน
+// ถ้า call มาจาก REST gateway (มี gateway key) จะใช้ x-forwarded-for ตัวสุดท้าย
+// ซึ่ง gateway ต่อท้ายด้วย IP ของ HTTP client เสมอ (ตัวก่อนหน้า client ปลอมได้)
+// และใช้ User-Agent ของ HTTP request ที่ gateway ส่งต่อมาเป็น grpcgateway-user-agent
+func (c *ClientInterceptor) clientInfo(ctx context.Context) service.Client {
+	md, _ := metadata.FromIncomingContext(ctx)
+	client := service.Client{UserAgent: lastValue(md, "user-agent")}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		client.IP = host
+	}
+	if !c.fromGateway(md) {
+		return client
+	}
+	if xff := lastValue(md, "x-forwarded-for"); xff != "" {
+		hops := strings.Split(xff, ",")
+		if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+			client.IP = last
+		}
+	}
+	if ua := lastValue(md, "grpcgateway-user-agent"); ua != "" {
+		client.UserAgent = ua
+	}
+	return client
+}
+
+// fromGateway เช็คว่า md มี gateway key ที่ถูกต้อง
+// HTTP client ส่ง Grpc-Metadata-X-Auth-Gateway-Key มาเองได้ จึงดูทุกค่า ไม่ใช่แค่ตัวสุดท้าย
+func (c *ClientInterceptor) fromGateway(md metadata.MD) bool {
+	if c.gatewayKey == "" {
+		return false
+	}
+	for _, v := range md.Get(GatewayKeyHeader) {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(c.gatewayKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func lastValue(md metadata.MD, key string) string {
+	if vs := md.Get(key); len(vs) > 0 {
+		return vs[len(vs)-1]
+	}
+	return ""
+}
+
+// httpClient คืน IP และ user agent ของ HTTP request ที่ต่อตรงเข้ามา (endpoint ที่ไม่ผ่าน gateway)
+func httpClient(r *http.Request) service.Client {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return service.Client{IP: host, UserAgent: r.UserAgent()}
+}
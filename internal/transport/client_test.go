@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientInfoTrustsForwardedForOnlyFromGateway(t *testing.T) {
+	const key = "gateway-key"
+	forwarded := metadata.Pairs(
+		"x-forwarded-for", "6.6.6.6, 203.0.113.7",
+		"grpcgateway-user-agent", "browser/1.0",
+		"user-agent", "grpc-go/1.0",
+	)
+	loopback := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 4000}
+	remote := &net.TCPAddr{IP: net.IPv4(198, 51, 100, 2), Port: 4000}
+
+	for _, tc := range []struct {
+		name          string
+		serverKey     string
+		addr          net.Addr
+		sentKeys      []string
+		wantIP, wantU string
+	}{
+		{"gateway with key", key, loopback, []string{key}, "203.0.113.7", "browser/1.0"},
+		{"key forged before the gateway's", key, loopback, []string{"guess", key}, "203.0.113.7", "browser/1.0"},
+		{"loopback without key", key, loopback, nil, "127.0.0.1", "grpc-go/1.0"},
+		{"loopback with wrong key", key, loopback, []string{"guess"}, "127.0.0.1", "grpc-go/1.0"},
+		{"remote without key", key, remote, nil, "198.51.100.2", "grpc-go/1.0"},
+		{"server without gateway", "", loopback, []string{""}, "127.0.0.1", "grpc-go/1.0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			md := forwarded.Copy()
+			for _, k := range tc.sentKeys {
+				md.Append(GatewayKeyHeader, k)
+			}
+			ctx := peer.NewContext(metadata.NewIncomingContext(context.Background(), md), &peer.Peer{Addr: tc.addr})
+			got := NewClientInterceptor(tc.serverKey).clientInfo(ctx)
+			if got.IP != tc.wantIP || got.UserAgent != tc.wantU {
+				t.Fatalf("clientInfo = %+v, want IP %s and user agent %s", got, tc.wantIP, tc.wantU)
+			}
+		})
+	}
+}
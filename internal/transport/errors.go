@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain คือค่า domain ใน google.rpc.ErrorInfo
+const errorDomain = "auth-microservice"
+
+// grpcCodes จับคู่ service.Code กับ gRPC status code
+var grpcCodes = map[service.Code]codes.Code{
+	service.CodeInvalidArgument:    codes.InvalidArgument,
+	service.CodeNotFound:           codes.NotFound,
+	service.CodeAlreadyExists:      codes.AlreadyExists,
+	service.CodeUnauthenticated:    codes.Unauthenticated,
+	service.CodePermissionDenied:   codes.PermissionDenied,
+	service.CodeFailedPrecondition: codes.FailedPrecondition,
+	service.CodeResourceExhausted:  codes.ResourceExhausted,
+}
+
+// toStatus แปลง error จาก service เป็น gRPC status พร้อม ErrorInfo (และ RetryInfo / BadRequest ถ้ามี)
+// error ที่ไม่รู้จักจะถูก log ไว้และคืน Internal โดยไม่เปิดเผยรายละเอียด
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var svcErr *service.Error
+	if !errors.As(err, &svcErr) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		log.Printf("internal error: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	code, ok := grpcCodes[svcErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	info := &errdetails.ErrorInfo{Reason: svcErr.Reason, Domain: errorDomain}
+	details := []protoadapt.MessageV1{info}
+	if svcErr.RetryAfter > 0 {
+		secs := int64(math.Ceil(svcErr.RetryAfter.Seconds()))
+		info.Metadata = map[string]string{"retry_after_seconds": strconv.FormatInt(secs, 10)}
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(svcErr.RetryAfter)})
+	}
+	if len(svcErr.Violations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range svcErr.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Reason:      v.Reason,
+				Description: v.Description,
+			})
+		}
+		details = append(details, br)
+	}
+
+	st := status.New(code, svcErr.Message)
+	withDetails, derr := st.WithDetails(details...)
+	if derr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// UnaryErrorInterceptor แปลง error ที่ handler คืนเป็น gRPC status
+func UnaryErrorInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, callStatus(ctx, err)
+}
+
+// StreamErrorInterceptor แปลง error ที่ stream handler คืนเป็น gRPC status
+func StreamErrorInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return callStatus(ss.Context(), handler(srv, ss))
+}
+
+// callStatus ให้ Canceled / DeadlineExceeded มาก่อน ถ้า call ถูกยกเลิกระหว่างทำงาน
+// error ที่ service คืนตอนนั้นเป็นผลของการยกเลิก ไม่ใช่สาเหตุจริง
+func callStatus(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return toStatus(err)
+}
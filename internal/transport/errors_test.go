@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	violations := []service.FieldViolation{
+		{Field: "password", Reason: "TOO_SHORT", Description: "at least 8 characters"},
+		{Field: "email", Reason: "INVALID_FORMAT", Description: "not an email address"},
+	}
+	passthrough := status.Error(codes.Aborted, "already a status")
+
+	for _, tc := range []struct {
+		name       string
+		err        error
+		code       codes.Code
+		msg        string
+		reason     string        // ว่าง = ไม่มี ErrorInfo
+		retryAfter time.Duration // 0 = ไม่มี RetryInfo
+		retryMeta  string
+		violations []service.FieldViolation
+	}{
+		{name: "invalid argument", err: service.InvalidArgument("BAD", "bad"), code: codes.InvalidArgument, msg: "bad", reason: "BAD"},
+		{name: "not found", err: service.NotFound("NOPE", "nope"), code: codes.NotFound, msg: "nope", reason: "NOPE"},
+		{name: "already exists", err: service.AlreadyExists("DUP", "dup"), code: codes.AlreadyExists, msg: "dup", reason: "DUP"},
+		{name: "unauthenticated", err: service.ErrInvalidToken, code: codes.Unauthenticated, msg: service.ErrInvalidToken.Message, reason: service.ErrInvalidToken.Reason},
+		{name: "permission denied", err: service.PermissionDenied("NO", "no"), code: codes.PermissionDenied, msg: "no", reason: "NO"},
+		{name: "failed precondition", err: service.FailedPrecondition("NOT_YET", "not yet"), code: codes.FailedPrecondition, msg: "not yet", reason: "NOT_YET"},
+		{name: "resource exhausted", err: &service.Error{Code: service.CodeResourceExhausted, Reason: "SLOW_DOWN", Message: "slow down"},
+			code: codes.ResourceExhausted, msg: "slow down", reason: "SLOW_DOWN"},
+		{name: "unknown code", err: &service.Error{Code: service.Code(99), Reason: "ODD", Message: "odd"}, code: codes.Unknown, msg: "odd", reason: "ODD"},
+		{name: "retry after rounds up", err: &service.Error{Code: service.CodeResourceExhausted, Reason: "SLOW_DOWN", Message: "slow down", RetryAfter: 1500 * time.Millisecond},
+			code: codes.ResourceExhausted, msg: "slow down", reason: "SLOW_DOWN", retryAfter: 1500 * time.Millisecond, retryMeta: "2"},
+		{name: "violations", err: &service.Error{Code: service.CodeInvalidArgument, Reason: "WEAK_PASSWORD", Message: "weak", Violations: violations},
+			code: codes.InvalidArgument, msg: "weak", reason: "WEAK_PASSWORD", violations: violations},
+		{name: "wrapped service error", err: fmt.Errorf("login: %w", service.ErrInvalidToken), code: codes.Unauthenticated, msg: service.ErrInvalidToken.Message, reason: service.ErrInvalidToken.Reason},
+		{name: "canceled", err: context.Canceled, code: codes.Canceled, msg: context.Canceled.Error()},
+		{name: "deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), code: codes.DeadlineExceeded, msg: "query: " + context.DeadlineExceeded.Error()},
+		{name: "unknown error is hidden", err: errors.New("db password is hunter2"), code: codes.Internal, msg: "internal error"},
+		{name: "status passes through", err: passthrough, code: codes.Aborted, msg: "already a status"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st, ok := status.FromError(toStatus(tc.err))
+			if !ok {
+				t.Fatalf("toStatus(%v) is not a status", tc.err)
+			}
+			if st.Code() != tc.code || st.Message() != tc.msg {
+				t.Fatalf("status = %s %q, want %s %q", st.Code(), st.Message(), tc.code, tc.msg)
+			}
+
+			var (
+				info  *errdetails.ErrorInfo
+				retry *errdetails.RetryInfo
+				br    *errdetails.BadRequest
+			)
+			for _, d := range st.Details() {
+				switch d := d.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.RetryInfo:
+					retry = d
+				case *errdetails.BadRequest:
+					br = d
+				}
+			}
+
+			if tc.reason == "" {
+				if len(st.Details()) > 0 {
+					t.Fatalf("details = %v, want none", st.Details())
+				}
+				return
+			}
+			if info == nil || info.Reason != tc.reason || info.Domain != errorDomain {
+				t.Fatalf("ErrorInfo = %v, want reason %s in domain %s", info, tc.reason, errorDomain)
+			}
+			if got := info.Metadata["retry_after_seconds"]; got != tc.retryMeta {
+				t.Fatalf("retry_after_seconds = %q, want %q", got, tc.retryMeta)
+			}
+
+			if tc.retryAfter == 0 && retry != nil {
+				t.Fatalf("RetryInfo = %v, want none", retry)
+			}
+			if tc.retryAfter > 0 && (retry == nil || retry.RetryDelay.AsDuration() != tc.retryAfter) {
+				t.Fatalf("RetryInfo = %v, want %s", retry, tc.retryAfter)
+			}
+
+			if len(tc.violations) == 0 {
+				if br != nil {
+					t.Fatalf("BadRequest = %v, want none", br)
+				}
+				return
+			}
+			if br == nil || len(br.FieldViolations) != len(tc.violations) {
+				t.Fatalf("BadRequest = %v, want %d violations", br, len(tc.violations))
+			}
+			for i, v := range tc.violations {
+				got := br.FieldViolations[i]
+				if got.Field != v.Field || got.Reason != v.Reason || got.Description != v.Description {
+					t.Errorf("violation %d = %v, want %+v", i, got, v)
+				}
+			}
+		})
+	}
+
+	if err := toStatus(nil); err != nil {
+		t.Fatalf("toStatus(nil) = %v", err)
+	}
+}
+
+func TestCallStatusPrefersContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// service คืน error อื่นเพราะ query ถูกยกเลิก: client ต้องเห็น Canceled ไม่ใช่ Internal
+	if code := status.Code(callStatus(ctx, errors.New("driver: connection closed"))); code != codes.Canceled {
+		t.Fatalf("callStatus(cancelled call) = %s, want Canceled", code)
+	}
+	if err := callStatus(ctx, nil); err != nil {
+		t.Fatalf("callStatus(cancelled call, nil) = %v, want nil", err)
+	}
+
+	expired, stop := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer stop()
+	if code := status.Code(callStatus(expired, service.ErrInvalidToken)); code != codes.DeadlineExceeded {
+		t.Fatalf("callStatus(expired call) = %s, want DeadlineExceeded", code)
+	}
+}
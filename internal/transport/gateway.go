@@ -0,0 +1,29 @@
+package transport
+
+import (
+	"context"
+
+	pb "github.com/LengLKR/auth-microservice/internal/transport/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// NewGateway สร้าง REST/JSON gateway ที่แปลง HTTP request เป็น gRPC call ไปที่ grpcEndpoint
+// request จึงผ่าน interceptor (auth, error mapping) ชุดเดียวกับ client gRPC
+// header Authorization ถูก grpc-gateway ส่งต่อเป็น metadata "authorization" อยู่แล้ว
+// gatewayKey ถูกแนบไปกับทุก call ให้ ClientInterceptor เชื่อ x-forwarded-for ที่ gateway ใส่
+func NewGateway(ctx context.Context, grpcEndpoint, gatewayKey string) (*runtime.ServeMux, error) {
+	mux := runtime.NewServeMux()
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, GatewayKeyHeader, gatewayKey), method, req, reply, cc, opts...)
+		}),
+	}
+	if err := pb.RegisterAuthServiceHandlerFromEndpoint(ctx, mux, grpcEndpoint, opts); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
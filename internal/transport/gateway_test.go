@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/LengLKR/auth-microservice/internal/service"
+	"google.golang.org/grpc"
+)
+
+// newGatewayServer เปิด gRPC server บน TCP ด้วย interceptor ชุดเดียวกับ main
+// แล้วคืน HTTP server ที่มี REST gateway ซึ่ง dial กลับเข้า gRPC server ตัวนั้น
+func newGatewayServer(t *testing.T, svc *service.AuthService) *httptest.Server {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	key, err := NewGatewayKey()
+	if err != nil {
+		t.Fatalf("NewGatewayKey: %v", err)
+	}
+	auth := NewAuthInterceptor(svc, PublicMethods, ScopedMethods)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(UnaryErrorInterceptor, NewClientInterceptor(key).Unary(), auth.Unary()))
+	RegisterAuthServiceServer(srv, NewServer(svc))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	gateway, err := NewGateway(ctx, lis.Addr().String(), key)
+	if err != nil {
+		t.Fatalf("NewGateway: %v", err)
+	}
+	hs := httptest.NewServer(NewHTTPHandler(svc, gateway))
+	t.Cleanup(hs.Close)
+	return hs
+}
+
+// do ส่ง request ไปที่ gateway แล้ว decode body ที่เป็น JSON ลง out (ถ้ามี)
+func do(t *testing.T, req *http.Request, out interface{}) *http.Response {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s: %v", req.URL.Path, err)
+		}
+	}
+	return resp
+}
+
+func TestGatewayRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	svc := newInterceptorService(t, service.WithAuditLog(memory.NewAuditRepository()))
+	hs := newGatewayServer(t, svc)
+	const email, password = "rest@example.com", "correct-horse-battery-9"
+	if _, err := svc.Register(ctx, email, password); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// route สาธารณะ: login ผ่าน REST ได้ token
+	login, _ := http.NewRequest(http.MethodPost, hs.URL+"/v1/auth/login",
+		strings.NewReader(`{"email":"`+email+`","password":"`+password+`"}`))
+	login.Header.Set("Content-Type", "application/json")
+	login.Header.Set("X-Forwarded-For", "6.6.6.6")
+	login.Header.Set("User-Agent", "rest-test/1.0")
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	if resp := do(t, login, &tokens); resp.StatusCode != http.StatusOK || tokens.Token == "" {
+		t.Fatalf("POST /v1/auth/login = %d, token %q", resp.StatusCode, tokens.Token)
+	}
+	p, err := svc.Authenticate(ctx, tokens.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	// IP ของ client คือ hop ที่ gateway ต่อท้ายเอง ไม่ใช่ค่าที่ client ส่งมา
+	// และ user agent มาจาก HTTP request (ใช้ได้เฉพาะ call ที่มี gateway key)
+	admin := service.WithPrincipal(ctx, &service.Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+	events, _, err := svc.ListAuditEvents(admin, domain.AuditFilter{Type: domain.AuditLogin}, 1, 10)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("login audit events = %+v, %v", events, err)
+	}
+	if events[0].IP != "127.0.0.1" || events[0].UserAgent != "rest-test/1.0" {
+		t.Fatalf("login audit client = %s %q, want 127.0.0.1 and the HTTP User-Agent", events[0].IP, events[0].UserAgent)
+	}
+
+	// route ที่ต้อง login: Authorization ถูกส่งต่อเป็น metadata ของ gRPC
+	profile := func(authorization string) (*http.Response, map[string]interface{}) {
+		req, _ := http.NewRequest(http.MethodGet, hs.URL+"/v1/users/"+p.UserID, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		var body map[string]interface{}
+		return do(t, req, &body), body
+	}
+	if resp, body := profile("Bearer " + tokens.Token); resp.StatusCode != http.StatusOK || body["email"] != email {
+		t.Fatalf("GET /v1/users/{id} with a token = %d %v", resp.StatusCode, body)
+	}
+	for name, authorization := range map[string]string{
+		"no header":     "",
+		"invalid token": "Bearer not-a-token",
+		"wrong scheme":  "Basic " + tokens.Token,
+	} {
+		if resp, body := profile(authorization); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET /v1/users/{id} with %s = %d %v, want 401", name, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestOpenAPISpecIsServed(t *testing.T) {
+	hs := newGatewayServer(t, newInterceptorService(t))
+	req, _ := http.NewRequest(http.MethodGet, hs.URL+"/openapi.json", nil)
+	var spec struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	resp := do(t, req, &spec)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /openapi.json = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	for _, path := range []string{"/v1/auth/login", "/v1/users/{id}"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("spec has no path %s (paths: %d)", path, len(spec.Paths))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+	"github.com/LengLKR/auth-microservice/internal/transport/openapi"
+)
+
+// NewHTTPHandler คืน http.Handler สำหรับ endpoint ที่ต้องเป็น HTTP ธรรมดา (เช่น JWKS, OAuth 2.0 และ OpenID Connect)
+// และ REST/JSON gateway ใต้ /v1/ พร้อม OpenAPI ที่ /openapi.json
+func NewHTTPHandler(svc *service.AuthService, gateway http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		writeJSON(w, http.StatusOK, svc.JWKS())
+	})
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		cfg, err := svc.OpenIDConfiguration()
+		if err != nil {
+			// ไม่ได้เปิด OpenID Connect: ไม่มี discovery document ให้ client ค้นพบ
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		writeJSON(w, http.StatusOK, cfg)
+	})
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openapi.Spec)
+	})
+	mux.HandleFunc("GET /oauth/authorize", handleAuthorize(svc))
+	mux.HandleFunc("POST /oauth/authorize", handleAuthorizeDecision(svc))
+	mux.HandleFunc("POST /oauth/token", handleToken(svc))
+	mux.HandleFunc("POST /oauth/introspect", handleIntrospect(svc))
+	mux.HandleFunc("POST /oauth/revoke", handleRevoke(svc))
+	mux.HandleFunc("GET /oauth/userinfo", handleUserInfo(svc))
+	mux.HandleFunc("POST /oauth/userinfo", handleUserInfo(svc))
+	mux.Handle("/v1/", gateway)
+	return mux
+}
+
+// writeJSON เขียน response เป็น JSON พร้อม status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,135 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/service"
+	pb "github.com/LengLKR/auth-microservice/internal/transport/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// PublicMethods คือ RPC ที่เรียกได้โดยไม่ต้องมี access token
+var PublicMethods = []string{
+	pb.AuthService_Register_FullMethodName,
+	pb.AuthService_Login_FullMethodName,
+	pb.AuthService_Logout_FullMethodName, // token อยู่ใน request body และถูกตรวจใน service
+	pb.AuthService_RefreshToken_FullMethodName,
+	pb.AuthService_VerifyMFA_FullMethodName,
+	pb.AuthService_BeginPasskeyLogin_FullMethodName,
+	pb.AuthService_FinishPasskeyLogin_FullMethodName,
+	pb.AuthService_RequestPasswordReset_FullMethodName,
+	pb.AuthService_ResetPassword_FullMethodName,
+	pb.AuthService_SendVerificationEmail_FullMethodName,
+	pb.AuthService_VerifyEmail_FullMethodName,
+	pb.AuthService_GetJWKS_FullMethodName,
+	pb.AuthService_ExchangeClientCredentials_FullMethodName, // client ยืนยันตัวด้วย secret ใน request body
+	pb.AuthService_RevokeToken_FullMethodName,               // ผู้ถือ token เพิกถอนเองได้เหมือน Logout
+}
+
+// ScopedMethods คือ RPC ที่ access token ของ OAuth client หรือ machine client เรียกได้ และ scope ที่ token ต้องมี
+// RPC อื่นทั้งหมดต้องใช้ token ที่ผู้ใช้ได้จากการ login เอง
+var ScopedMethods = map[string]string{
+	pb.AuthService_GetProfile_FullMethodName:      domain.ScopeProfile,
+	pb.AuthService_IntrospectToken_FullMethodName: domain.ScopeIntrospect,
+}
+
+// AuthInterceptor ตรวจ bearer token ของทุก RPC ที่ไม่อยู่ใน public allowlist
+// แล้วแนบ service.Principal ไว้ใน context ให้ service layer ใช้
+type AuthInterceptor struct {
+	authSvc *service.AuthService
+	public  map[string]bool
+	scoped  map[string]string
+}
+
+// NewAuthInterceptor สร้าง interceptor โดย publicMethods คือ full method name ที่ไม่ต้อง login
+// และ scopedMethods คือ method ที่ token ของ OAuth client เรียกได้ พร้อม scope ที่ต้องมี
+func NewAuthInterceptor(svc *service.AuthService, publicMethods []string, scopedMethods map[string]string) *AuthInterceptor {
+	public := make(map[string]bool, len(publicMethods))
+	for _, m := range publicMethods {
+		public[m] = true
+	}
+	return &AuthInterceptor{authSvc: svc, public: public, scoped: scopedMethods}
+}
+
+// Unary คืน grpc.UnaryServerInterceptor
+func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream คืน grpc.StreamServerInterceptor
+func (a *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate คืน ctx ที่มี principal หรือ Unauthenticated ถ้า token ไม่ถูกต้อง/ถูก revoke
+// และ PermissionDenied ถ้า token ของ OAuth client ไม่มี scope ของ method นี้
+func (a *AuthInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if a.public[method] {
+		return ctx, nil
+	}
+	raw, err := bearerToken(ctx)
+	if err != nil {
+		return nil, toStatus(service.Unauthenticated("MISSING_CREDENTIALS", err.Error()))
+	}
+	p, err := a.authSvc.Authenticate(ctx, raw)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if p.Delegated() {
+		if scope, ok := a.scoped[method]; !ok || !p.HasScope(scope) {
+			return nil, toStatus(service.ErrInsufficientScope)
+		}
+	}
+	return service.WithPrincipal(ctx, p), nil
+}
+
+// bearerToken ดึง token จาก metadata: "authorization: Bearer <token>"
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errors.New("missing metadata")
+	}
+	auth := md.Get("authorization")
+	if len(auth) == 0 {
+		return "", errors.New("missing authorization header")
+	}
+	return parseBearer(auth[0])
+}
+
+// parseBearer ดึง token จากค่า "Bearer <token>" ของ authorization header
+func parseBearer(header string) (string, error) {
+	if header == "" {
+		return "", errors.New("missing authorization header")
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || parts[1] == "" {
+		return "", errors.New("invalid authorization format")
+	}
+	return parts[1], nil
+}
+
+// authStream ส่ง ctx ที่ interceptor แก้แล้ว (principal, client IP) ต่อให้ stream handler
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/LengLKR/auth-microservice/internal/service"
+	pb "github.com/LengLKR/auth-microservice/internal/transport/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newInterceptorService สร้าง service บน memory repository ที่มี machine client
+func newInterceptorService(t *testing.T, opts ...service.Option) *service.AuthService {
+	t.Helper()
+	key, err := keys.NewHMACKey("test", strings.Repeat("k", 40))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+	ring, err := keys.NewRing(key, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	return service.NewAuthService(memory.NewUserRepository(), memory.NewTokenRepository(), memory.NewPasswordResetRepository(),
+		memory.NewEmailVerificationRepository(), memory.NewRefreshTokenRepository(), ring, mail.NewMemoryMailer(),
+		append(opts, service.WithMachineClients(memory.NewMachineClientRepository()))...)
+}
+
+// fullMethodNames คืน full method name ของทุก RPC ใน AuthService
+func fullMethodNames() []string {
+	desc := pb.AuthService_ServiceDesc
+	var out []string
+	for _, m := range desc.Methods {
+		out = append(out, "/"+desc.ServiceName+"/"+m.MethodName)
+	}
+	for _, s := range desc.Streams {
+		out = append(out, "/"+desc.ServiceName+"/"+s.StreamName)
+	}
+	return out
+}
+
+func TestEveryMethodIsPublicOrAuthenticated(t *testing.T) {
+	// เพิ่ม RPC ใน allowlist ต้องแก้รายการนี้ด้วย เพื่อให้การเปิด method โดยไม่ต้อง login ผ่านตาคนรีวิวเสมอ
+	wantPublic := map[string]bool{
+		"Register": true, "Login": true, "Logout": true, "RefreshToken": true, "VerifyMFA": true,
+		"BeginPasskeyLogin": true, "FinishPasskeyLogin": true, "RequestPasswordReset": true, "ResetPassword": true,
+		"SendVerificationEmail": true, "VerifyEmail": true, "GetJWKS": true,
+		"ExchangeClientCredentials": true, "RevokeToken": true,
+	}
+	a := NewAuthInterceptor(newInterceptorService(t), PublicMethods, ScopedMethods)
+	known := make(map[string]bool)
+	for _, m := range fullMethodNames() {
+		known[m] = true
+		name := m[strings.LastIndex(m, "/")+1:]
+		_, err := a.authenticate(context.Background(), m)
+		switch {
+		case wantPublic[name] && err != nil:
+			t.Errorf("%s: public method rejected an anonymous call: %v", name, err)
+		case !wantPublic[name] && status.Code(err) != codes.Unauthenticated:
+			t.Errorf("%s: anonymous call = %v, want Unauthenticated", name, err)
+		}
+	}
+	for _, m := range PublicMethods {
+		if !known[m] {
+			t.Errorf("PublicMethods lists unknown method %s", m)
+		}
+	}
+	for m := range ScopedMethods {
+		if !known[m] {
+			t.Errorf("ScopedMethods lists unknown method %s", m)
+		}
+		if a.public[m] {
+			t.Errorf("%s is both public and scoped", m)
+		}
+	}
+}
+
+func TestScopedTokenOutsideItsScope(t *testing.T) {
+	svc := newInterceptorService(t)
+	admin := service.WithPrincipal(context.Background(), &service.Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+	c, secret, err := svc.CreateMachineClient(admin, "introspector", []string{domain.ScopeIntrospect})
+	if err != nil {
+		t.Fatalf("CreateMachineClient: %v", err)
+	}
+	tok, err := svc.ExchangeClientCredentials(context.Background(), c.ID, secret, "")
+	if err != nil {
+		t.Fatalf("ExchangeClientCredentials: %v", err)
+	}
+	client := newBufconnClient(t, svc, make(chan codes.Code, 8))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tok.AccessToken)
+
+	if _, err := client.IntrospectToken(ctx, &pb.IntrospectTokenRequest{Token: tok.AccessToken}); err != nil {
+		t.Fatalf("IntrospectToken (in scope): %v", err)
+	}
+	for name, call := range map[string]func() error{
+		"GetProfile": func() error {
+			_, err := client.GetProfile(ctx, &pb.GetProfileRequest{Id: c.ID})
+			return err
+		},
+		"ListUsers": func() error {
+			_, err := client.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, Size: 10})
+			return err
+		},
+		"LogoutAll": func() error {
+			_, err := client.LogoutAll(ctx, &pb.Empty{})
+			return err
+		},
+	} {
+		if err := call(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s with an introspect-only token = %v, want PermissionDenied", name, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+)
+
+// introspectionResponse คือ body ของ /oauth/introspect (RFC 7662 §2.2) token ที่ใช้ไม่ได้มีแค่ active=false
+// token_type เป็น access_token หรือ refresh_token: resource server ต้องรับเฉพาะ access_token
+type introspectionResponse struct {
+	Active    bool     `json:"active"`
+	TokenType string   `json:"token_type,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+}
+
+// handleIntrospect (POST /oauth/introspect) ตรวจสถานะของ token ใน form (token, token_type_hint)
+// ผู้เรียกยืนยันตัวด้วย bearer token ของตัวเอง (RFC 7662 §2.1) ซึ่งต้องมีสิทธิ์ tokens:introspect
+func handleIntrospect(svc *service.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		ctx := service.WithClient(r.Context(), httpClient(r))
+		raw, err := parseBearer(r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="introspect"`)
+			writeJSON(w, http.StatusUnauthorized, oauthError{Error: "invalid_token"})
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+			writeJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_request", Description: "token is required"})
+			return
+		}
+		p, err := svc.Authenticate(ctx, raw)
+		if err == nil {
+			var info *service.TokenIntrospection
+			info, err = svc.IntrospectToken(service.WithPrincipal(ctx, p), r.PostForm.Get("token"), r.PostForm.Get("token_type_hint"))
+			if err == nil {
+				writeJSON(w, http.StatusOK, toIntrospectionResponse(info))
+				return
+			}
+		}
+		status, e := toOAuthError(err)
+		switch status {
+		case http.StatusUnauthorized:
+			e.Error = "invalid_token"
+		case http.StatusForbidden:
+			e.Error = "insufficient_scope"
+		}
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="introspect", error=%q`, e.Error))
+		}
+		writeJSON(w, status, e)
+	}
+}
+
+func toIntrospectionResponse(info *service.TokenIntrospection) introspectionResponse {
+	if !info.Active {
+		return introspectionResponse{}
+	}
+	out := introspectionResponse{
+		Active:    true,
+		TokenType: info.TokenType,
+		Subject:   info.Subject,
+		ClientID:  info.ClientID,
+		Scope:     strings.Join(info.Scopes, " "),
+		Roles:     info.Roles,
+		ExpiresAt: info.ExpiresAt.Unix(),
+	}
+	if !info.IssuedAt.IsZero() {
+		out.IssuedAt = info.IssuedAt.Unix()
+	}
+	return out
+}
+
+// handleRevoke (POST /oauth/revoke) เพิกถอน token ใน form (token, token_type_hint) ตาม RFC 7009
+// การถือ token ถือเป็นสิทธิ์เพิกถอน จึงไม่ต้องยืนยันตัว client และตอบ 200 แม้ token จะไม่รู้จัก (§2.2)
+func handleRevoke(svc *service.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+			writeJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_request", Description: "token is required"})
+			return
+		}
+		ctx := service.WithClient(r.Context(), httpClient(r))
+		if err := svc.RevokeToken(ctx, r.PostForm.Get("token"), r.PostForm.Get("token_type_hint")); err != nil {
+			status, e := toOAuthError(err)
+			writeJSON(w, status, e)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
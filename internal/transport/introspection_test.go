@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/LengLKR/auth-microservice/internal/service"
+)
+
+// tokenEndpoints คือ handler ของ /oauth/introspect และ /oauth/revoke กับ token ของผู้ใช้ธรรมดาและ admin
+type tokenEndpoints struct {
+	svc     *service.AuthService
+	handler http.Handler
+	user    *service.TokenPair
+	admin   *service.TokenPair
+}
+
+func newTokenEndpoints(t *testing.T) *tokenEndpoints {
+	t.Helper()
+	ctx := context.Background()
+	key, err := keys.NewHMACKey("test", strings.Repeat("k", 40))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+	ring, err := keys.NewRing(key, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	users := memory.NewUserRepository()
+	svc := service.NewAuthService(users, memory.NewTokenRepository(), memory.NewPasswordResetRepository(),
+		memory.NewEmailVerificationRepository(), memory.NewRefreshTokenRepository(), ring, mail.NewMemoryMailer())
+	login := func(email string, roles ...string) *service.TokenPair {
+		if _, err := svc.Register(ctx, email, "correct-horse-battery-9"); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if len(roles) > 0 {
+			u, _ := users.FindByEmail(ctx, email)
+			u.Roles = roles
+			if err := users.Update(ctx, u); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+		}
+		pair, err := svc.Login(ctx, email, "correct-horse-battery-9")
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		return pair
+	}
+	return &tokenEndpoints{
+		svc:     svc,
+		handler: NewHTTPHandler(svc, http.NotFoundHandler()),
+		user:    login("user@example.com"),
+		admin:   login("admin@example.com", domain.RoleAdmin),
+	}
+}
+
+// post ส่ง form ไปที่ path พร้อม bearer token (ถ้ามี)
+func (f *tokenEndpoints) post(path, bearer string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (f *tokenEndpoints) introspect(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	rec := f.post("/oauth/introspect", f.admin.AccessToken, url.Values{"token": {token}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("introspect: status %d, body %s", rec.Code, rec.Body)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	return body
+}
+
+func TestIntrospectEndpoint(t *testing.T) {
+	f := newTokenEndpoints(t)
+
+	body := f.introspect(t, f.user.AccessToken)
+	if body["active"] != true || body["token_type"] != service.TokenTypeAccess || body["sub"] == "" || body["exp"] == nil {
+		t.Fatalf("introspect(access token) = %v", body)
+	}
+	if body := f.introspect(t, f.user.RefreshToken); body["active"] != true || body["token_type"] != service.TokenTypeRefresh {
+		t.Fatalf("introspect(refresh token) = %v", body)
+	}
+	// token ที่ใช้ไม่ได้มีแค่ active:false
+	for name, token := range map[string]string{"garbage": "not-a-token", "truncated": f.user.AccessToken[:len(f.user.AccessToken)-4]} {
+		if body := f.introspect(t, token); len(body) != 1 || body["active"] != false {
+			t.Errorf("introspect(%s) = %v, want {\"active\":false}", name, body)
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		bearer string
+		form   url.Values
+		status int
+		errStr string
+	}{
+		{"no bearer token", "", url.Values{"token": {f.user.AccessToken}}, http.StatusUnauthorized, "invalid_token"},
+		{"invalid bearer token", "garbage", url.Values{"token": {f.user.AccessToken}}, http.StatusUnauthorized, "invalid_token"},
+		{"caller without permission", f.user.AccessToken, url.Values{"token": {f.admin.AccessToken}}, http.StatusForbidden, "insufficient_scope"},
+		{"missing token", f.admin.AccessToken, url.Values{}, http.StatusBadRequest, "invalid_request"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.post("/oauth/introspect", tc.bearer, tc.form)
+			if rec.Code != tc.status || !strings.Contains(rec.Body.String(), `"error":"`+tc.errStr+`"`) {
+				t.Fatalf("status %d, body %s; want %d %s", rec.Code, rec.Body, tc.status, tc.errStr)
+			}
+			if tc.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestRevokeEndpoint(t *testing.T) {
+	f := newTokenEndpoints(t)
+
+	// token ที่ไม่รู้จักตอบ 200 (RFC 7009 §2.2)
+	if rec := f.post("/oauth/revoke", "", url.Values{"token": {"not-a-token"}}); rec.Code != http.StatusOK {
+		t.Fatalf("revoke(unknown): status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := f.post("/oauth/revoke", "", url.Values{}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("revoke(no token): status %d, want 400", rec.Code)
+	}
+
+	// ผู้ถือ token เพิกถอนได้โดยไม่ต้องยืนยันตัว
+	if rec := f.post("/oauth/revoke", "", url.Values{"token": {f.user.AccessToken}, "token_type_hint": {service.TokenTypeAccess}}); rec.Code != http.StatusOK {
+		t.Fatalf("revoke(access token): status %d, body %s", rec.Code, rec.Body)
+	}
+	if body := f.introspect(t, f.user.AccessToken); body["active"] != false {
+		t.Fatalf("revoked access token introspects as %v", body)
+	}
+
+	// refresh token: ทั้ง family ถูกเพิกถอน รวมตัวที่ rotate ออกมาทีหลัง
+	next, err := f.svc.RefreshToken(context.Background(), f.user.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if rec := f.post("/oauth/revoke", "", url.Values{"token": {f.user.RefreshToken}}); rec.Code != http.StatusOK {
+		t.Fatalf("revoke(refresh token): status %d, body %s", rec.Code, rec.Body)
+	}
+	if body := f.introspect(t, next.RefreshToken); body["active"] != false {
+		t.Fatalf("newest refresh token of a revoked family introspects as %v", body)
+	}
+}
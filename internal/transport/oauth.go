@@ -0,0 +1,245 @@
+package transport
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+)
+
+// oauthErrorCodes จับคู่ reason ของ service กับ error code ของ OAuth 2.0 (RFC 6749 §4.1.2.1, §5.2)
+var oauthErrorCodes = map[string]string{
+	service.ErrInvalidOAuthRequest.Reason:     "invalid_request",
+	service.ErrInvalidRedirectURI.Reason:      "invalid_request",
+	service.ErrPKCERequired.Reason:            "invalid_request",
+	service.ErrInvalidOAuthClient.Reason:      "invalid_client",
+	service.ErrInvalidGrant.Reason:            "invalid_grant",
+	service.ErrInvalidScope.Reason:            "invalid_scope",
+	service.ErrUnsupportedResponseType.Reason: "unsupported_response_type",
+	service.ErrUnsupportedGrantType.Reason:    "unsupported_grant_type",
+	service.ErrAccessDenied.Reason:            "access_denied",
+	service.ErrInsufficientScope.Reason:       "access_denied",
+	service.ErrUnauthenticated.Reason:         "login_required",
+	service.ErrInvalidToken.Reason:            "login_required",
+	service.ErrTokenRevoked.Reason:            "login_required",
+	service.ErrOAuthDisabled.Reason:           "temporarily_unavailable",
+	service.ErrOIDCDisabled.Reason:            "invalid_scope",
+}
+
+// oauthError คือ body ของ error response ตาม RFC 6749 §5.2
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// toOAuthError แปลง error จาก service เป็น OAuth error พร้อม HTTP status
+// error ที่ไม่รู้จักจะถูก log ไว้และคืน server_error โดยไม่เปิดเผยรายละเอียด
+func toOAuthError(err error) (int, oauthError) {
+	var svcErr *service.Error
+	if !errors.As(err, &svcErr) {
+		log.Printf("internal error: %v", err)
+		return http.StatusInternalServerError, oauthError{Error: "server_error"}
+	}
+	code, ok := oauthErrorCodes[svcErr.Reason]
+	if !ok {
+		code = "invalid_request"
+	}
+	status := http.StatusBadRequest
+	switch svcErr.Code {
+	case service.CodeUnauthenticated:
+		status = http.StatusUnauthorized
+	case service.CodePermissionDenied:
+		status = http.StatusForbidden
+	case service.CodeFailedPrecondition:
+		status = http.StatusServiceUnavailable
+	}
+	return status, oauthError{Error: code, Description: svcErr.Message}
+}
+
+// redirectable บอกว่าส่ง error กลับทาง redirect_uri ได้หรือไม่
+// client หรือ redirect_uri ที่ไม่ถูกต้องต้องแสดง error ที่ server เอง (RFC 6749 §4.1.2.1)
+func redirectable(err error) bool {
+	var svcErr *service.Error
+	return errors.As(err, &svcErr) &&
+		!errors.Is(err, service.ErrInvalidOAuthClient) &&
+		!errors.Is(err, service.ErrInvalidRedirectURI) &&
+		!errors.Is(err, service.ErrOAuthDisabled) &&
+		!errors.Is(err, service.ErrInsufficientScope)
+}
+
+// authorizeRequest อ่าน parameter ของ /authorize จาก query string หรือ form body
+func authorizeRequest(r *http.Request) service.AuthorizeRequest {
+	return service.AuthorizeRequest{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+		Nonce:               r.Form.Get("nonce"),
+	}
+}
+
+// redirectURL ต่อ parameter เข้ากับ redirect_uri ของ client (เก็บ query เดิมของ URI ไว้)
+func redirectURL(base string, params url.Values) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	q := u.Query()
+	for k, vs := range params {
+		q[k] = vs
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// errorRedirect สร้าง redirect_uri ที่มี error ของ OAuth และ state เดิม
+func errorRedirect(req service.AuthorizeRequest, err error) string {
+	_, e := toOAuthError(err)
+	params := url.Values{"error": {e.Error}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return redirectURL(req.RedirectURI, params)
+}
+
+// handleAuthorize (GET /oauth/authorize) ตรวจ authorization request แล้วส่ง browser ไปหน้า login/consent บนเว็บ
+// หน้าเว็บ login ด้วย API เดิม แล้วส่งคำตอบของผู้ใช้กลับมาที่ POST /oauth/authorize
+func handleAuthorize(svc *service.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		req := authorizeRequest(r)
+		if _, err := svc.ValidateAuthorizeRequest(r.Context(), req); err != nil {
+			if redirectable(err) {
+				http.Redirect(w, r, errorRedirect(req, err), http.StatusFound)
+				return
+			}
+			status, e := toOAuthError(err)
+			writeJSON(w, status, e)
+			return
+		}
+		http.Redirect(w, r, svc.AuthorizePageURL(r.Form), http.StatusFound)
+	}
+}
+
+// authorizeResponse คือคำตอบของ POST /oauth/authorize ให้หน้าเว็บ
+// หน้าเว็บแสดงหน้า consent เมื่อ consent_required ไม่อย่างนั้นพา browser ไปที่ redirect_to
+type authorizeResponse struct {
+	RedirectTo      string   `json:"redirect_to,omitempty"`
+	ConsentRequired bool     `json:"consent_required,omitempty"`
+	ClientID        string   `json:"client_id,omitempty"`
+	ClientName      string   `json:"client_name,omitempty"`
+	Scopes          []string `json:"scopes,omitempty"`
+}
+
+// handleAuthorizeDecision (POST /oauth/authorize) ออก authorization code ให้ผู้ใช้เจ้าของ bearer token
+// parameter เดียวกับ GET และ decision=allow|deny (ว่าง = ถามว่าต้องขอ consent หรือไม่)
+func handleAuthorizeDecision(svc *service.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		ctx := service.WithClient(r.Context(), httpClient(r))
+		raw, err := parseBearer(r.Header.Get("Authorization"))
+		if err != nil {
+			status, e := toOAuthError(service.ErrUnauthenticated)
+			writeJSON(w, status, e)
+			return
+		}
+		p, err := svc.Authenticate(ctx, raw)
+		if err != nil {
+			status, e := toOAuthError(err)
+			writeJSON(w, status, e)
+			return
+		}
+
+		req := authorizeRequest(r)
+		a, err := svc.Authorize(service.WithPrincipal(ctx, p), req, r.Form.Get("decision"))
+		switch {
+		case err != nil && redirectable(err):
+			writeJSON(w, http.StatusOK, authorizeResponse{RedirectTo: errorRedirect(req, err)})
+		case err != nil:
+			status, e := toOAuthError(err)
+			writeJSON(w, status, e)
+		case a.ConsentRequired:
+			writeJSON(w, http.StatusOK, authorizeResponse{
+				ConsentRequired: true,
+				ClientID:        a.Client.ID,
+				ClientName:      a.Client.Name,
+				Scopes:          a.Scopes,
+			})
+		default:
+			params := url.Values{"code": {a.Code}}
+			if req.State != "" {
+				params.Set("state", req.State)
+			}
+			writeJSON(w, http.StatusOK, authorizeResponse{RedirectTo: redirectURL(req.RedirectURI, params)})
+		}
+	}
+}
+
+// tokenResponse คือ body ของ /oauth/token ที่สำเร็จ (RFC 6749 §5.1)
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// handleToken (POST /oauth/token) แลก authorization code หรือ client credentials ของ machine client เป็น access token
+// client ยืนยันตัวด้วย HTTP Basic หรือ client_id/client_secret ใน form ก็ได้ public client ส่งแค่ client_id
+func handleToken(svc *service.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// token response ห้าม cache (RFC 6749 §5.1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_request", Description: "malformed form body"})
+			return
+		}
+		ctx := service.WithClient(r.Context(), httpClient(r))
+
+		clientID, secret, basic := r.BasicAuth()
+		if basic {
+			// client_id/secret ใน Basic ถูก form-encode มาก่อน (RFC 6749 §2.3.1)
+			clientID, _ = url.QueryUnescape(clientID)
+			secret, _ = url.QueryUnescape(secret)
+		} else {
+			clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+
+		var tok *service.OAuthToken
+		var err error
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			tok, err = svc.ExchangeAuthorizationCode(ctx, clientID, secret,
+				r.PostForm.Get("code"), r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"))
+		case "client_credentials":
+			tok, err = svc.ExchangeClientCredentials(ctx, clientID, secret, r.PostForm.Get("scope"))
+		default:
+			err = service.ErrUnsupportedGrantType
+		}
+		if err != nil {
+			status, e := toOAuthError(err)
+			if status == http.StatusUnauthorized && basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			writeJSON(w, status, e)
+			return
+		}
+		writeJSON(w, http.StatusOK, tokenResponse{
+			AccessToken: tok.AccessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int64(tok.ExpiresIn.Seconds()),
+			Scope:       strings.Join(tok.Scopes, " "),
+			IDToken:     tok.IDToken,
+		})
+	}
+}
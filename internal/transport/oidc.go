@@ -0,0 +1,43 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/LengLKR/auth-microservice/internal/service"
+)
+
+// handleUserInfo (GET/POST /oauth/userinfo) คืน claim ของเจ้าของ access token ที่ได้ scope openid
+// error ตอบตาม RFC 6750 §3: 401 invalid_token หรือ 403 insufficient_scope พร้อม WWW-Authenticate
+func handleUserInfo(svc *service.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		ctx := service.WithClient(r.Context(), httpClient(r))
+		raw, err := parseBearer(r.Header.Get("Authorization"))
+		if err != nil {
+			// ไม่มี token เลยไม่ต้องบอก error code (RFC 6750 §3.1)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+			writeJSON(w, http.StatusUnauthorized, oauthError{Error: "invalid_token"})
+			return
+		}
+		p, err := svc.Authenticate(ctx, raw)
+		if err == nil {
+			var info *service.UserInfo
+			if info, err = svc.UserInfo(service.WithPrincipal(ctx, p)); err == nil {
+				writeJSON(w, http.StatusOK, info)
+				return
+			}
+		}
+		status, e := toOAuthError(err)
+		switch status {
+		case http.StatusUnauthorized:
+			e.Error = "invalid_token"
+		case http.StatusForbidden:
+			e.Error = "insufficient_scope"
+		}
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="userinfo", error=%q`, e.Error))
+		}
+		writeJSON(w, status, e)
+	}
+}
@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/LengLKR/auth-microservice/internal/service"
+	"github.com/LengLKR/auth-microservice/internal/transport/oidctest"
+)
+
+const (
+	testIssuer      = "https://auth.example.com"
+	testRedirectURI = "https://app.example.com/cb"
+)
+
+// oidcFixture คือ provider ที่เปิด OAuth บน memory repository พร้อมผู้ใช้ที่ login แล้วและ client หนึ่งตัว
+type oidcFixture struct {
+	svc     *service.AuthService
+	handler http.Handler
+	user    *domain.User
+	token   string
+	client  *domain.OAuthClient
+	secret  string
+}
+
+func newOIDCFixture(t *testing.T, key *keys.Key, confidential bool) *oidcFixture {
+	t.Helper()
+	ctx := context.Background()
+	ring, err := keys.NewRing(key, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	users := memory.NewUserRepository()
+	svc := service.NewAuthService(users, memory.NewTokenRepository(), memory.NewPasswordResetRepository(),
+		memory.NewEmailVerificationRepository(), memory.NewRefreshTokenRepository(), ring, mail.NewMemoryMailer(),
+		service.WithOAuth(memory.NewOAuthClientRepository(), memory.NewAuthorizationCodeRepository(), memory.NewConsentRepository()),
+		service.WithIssuer(testIssuer),
+	)
+
+	if _, err := svc.Register(ctx, "alice@example.com", "correct-horse-battery-9"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	u, err := users.FindByEmail(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	u.Name, u.EmailVerified = "Alice", true
+	if err := users.Update(ctx, u); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	pair, err := svc.Login(ctx, u.Email, "correct-horse-battery-9")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	admin := service.WithPrincipal(ctx, &service.Principal{UserID: "admin", Roles: []string{domain.RoleAdmin}})
+	c, secret, err := svc.RegisterOAuthClient(admin, "app", []string{testRedirectURI}, domain.SupportedScopes, confidential)
+	if err != nil {
+		t.Fatalf("RegisterOAuthClient: %v", err)
+	}
+	return &oidcFixture{
+		svc:     svc,
+		handler: NewHTTPHandler(svc, http.NotFoundHandler()),
+		user:    u,
+		token:   pair.AccessToken,
+		client:  c,
+		secret:  secret,
+	}
+}
+
+func TestOIDCConformance(t *testing.T) {
+	for _, tc := range []struct {
+		alg          string
+		confidential bool
+	}{
+		{keys.ES256, true},
+		{keys.RS256, true},
+		{keys.EdDSA, false},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			key, err := keys.Generate(tc.alg)
+			if err != nil {
+				t.Fatalf("Generate(%s): %v", tc.alg, err)
+			}
+			f := newOIDCFixture(t, key, tc.confidential)
+			oidctest.Run(t, oidctest.Provider{
+				Handler:      f.handler,
+				Issuer:       testIssuer,
+				ClientID:     f.client.ID,
+				ClientSecret: f.secret,
+				RedirectURI:  testRedirectURI,
+				UserToken:    f.token,
+				User:         oidctest.User{Subject: f.user.ID, Email: f.user.Email, Name: f.user.Name},
+			})
+		})
+	}
+}
+
+func TestOIDCDisabledWithSymmetricKey(t *testing.T) {
+	key, err := keys.NewHMACKey("test", strings.Repeat("k", 40))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+	f := newOIDCFixture(t, key, true)
+	if f.svc.OIDCEnabled() {
+		t.Fatal("OIDCEnabled with an HS256 signing key")
+	}
+
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testIssuer+"/.well-known/openid-configuration", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("discovery status = %d, body %s; want 404", rec.Code, rec.Body)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Repeat("v", 43)))
+	authorize := func(scope string) *url.URL {
+		q := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {f.client.ID},
+			"redirect_uri":          {testRedirectURI},
+			"scope":                 {scope},
+			"state":                 {"xyz"},
+			"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+			"code_challenge_method": {"S256"},
+		}
+		rec := httptest.NewRecorder()
+		f.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testIssuer+"/oauth/authorize?"+q.Encode(), nil))
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		if rec.Code != http.StatusFound || err != nil {
+			t.Fatalf("authorize(%s): status %d, Location %q", scope, rec.Code, rec.Header().Get("Location"))
+		}
+		return loc
+	}
+	// openid ถูกปฏิเสธกลับไปทาง redirect_uri ส่วน OAuth ธรรมดายังใช้ได้
+	if loc := authorize("openid profile"); loc.Query().Get("error") != "invalid_scope" {
+		t.Fatalf("authorize(openid) redirected to %s, want error=invalid_scope", loc)
+	}
+	if loc := authorize("profile"); loc.Query().Get("error") != "" {
+		t.Fatalf("authorize(profile) redirected to %s, want the authorize page", loc)
+	}
+}
@@ -0,0 +1,421 @@
+// Package oidctest คือชุดทดสอบแบบ conformance ของ OpenID Connect provider
+// ไล่ flow เดียวกับ relying party จริงผ่าน HTTP handler: discovery, authorization code + PKCE,
+// ตรวจ ID token ด้วย key จาก jwks_uri, nonce และ /userinfo
+//
+// ใช้จากไฟล์ _test.go โดยเตรียม client ที่ขอ scope openid profile email ได้ และผู้ใช้ที่ login แล้ว เช่น
+//
+//	func TestOIDC(t *testing.T) {
+//		oidctest.Run(t, oidctest.Provider{
+//			Handler:      transport.NewHTTPHandler(svc, http.NotFoundHandler()),
+//			Issuer:       "https://auth.example.com",
+//			ClientID:     client.ID,
+//			ClientSecret: secret,
+//			RedirectURI:  "https://app.example.com/cb",
+//			UserToken:    pair.AccessToken,
+//			User:         oidctest.User{Subject: user.ID, Email: user.Email, Name: user.Name},
+//		})
+//	}
+//
+// provider ต้องเซ็น token ด้วย key แบบ asymmetric (RS256, ES256 หรือ EdDSA) เพื่อให้ตรวจ ID token จาก JWKS ได้
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// clockSkew คือความคลาดเคลื่อนของนาฬิกาที่ยอมให้ตอนตรวจ iat/exp
+const clockSkew = time.Minute
+
+// User คือ claim ที่คาดว่าจะได้ของผู้ใช้ที่ใช้ทดสอบ
+type User struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+// Provider คือ OpenID provider ที่จะทดสอบ
+type Provider struct {
+	Handler http.Handler // HTTP handler ที่มี /.well-known/..., /oauth/authorize, /oauth/token และ /oauth/userinfo
+	Issuer  string       // issuer ที่ตั้งไว้ (URL ใน discovery ต้องขึ้นต้นด้วยค่านี้)
+
+	// client ที่ลงทะเบียนไว้แล้ว ขอ scope openid profile email ได้
+	ClientID     string
+	ClientSecret string // ว่าง = public client
+	RedirectURI  string
+
+	UserToken string // access token ของผู้ใช้ที่ login แล้ว ใช้ตอบหน้า consent แทน browser
+	User      User
+}
+
+// Run รันชุดทดสอบทั้งหมดกับ provider
+func Run(t *testing.T, p Provider) {
+	h := &harness{Provider: p}
+	t.Run("Discovery", h.testDiscovery)
+	t.Run("CodeFlow", h.testCodeFlow)
+	t.Run("NoNonce", h.testNoNonce)
+	t.Run("WithoutOpenIDScope", h.testWithoutOpenIDScope)
+	t.Run("CodeReplay", h.testCodeReplay)
+	t.Run("IDTokenIsNotAccessToken", h.testIDTokenIsNotAccessToken)
+	t.Run("UserInfoWithoutToken", h.testUserInfoWithoutToken)
+}
+
+type harness struct {
+	Provider
+}
+
+// discovery คือ field ของ discovery document ที่ชุดทดสอบใช้
+type discovery struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	UserInfoEndpoint                 string   `json:"userinfo_endpoint"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ScopesSupported                  []string `json:"scopes_supported"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	CodeChallengeMethodsSupported    []string `json:"code_challenge_methods_supported"`
+}
+
+// tokenResponse คือ body ของ token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+}
+
+// idClaims คือ claims ของ ID token ที่ชุดทดสอบตรวจ
+type idClaims struct {
+	Nonce         string `json:"nonce"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+func (h *harness) testDiscovery(t *testing.T) {
+	d := h.discover(t)
+	if d.Issuer != h.Issuer {
+		t.Errorf("issuer = %q, want %q", d.Issuer, h.Issuer)
+	}
+	for name, v := range map[string]string{
+		"authorization_endpoint": d.AuthorizationEndpoint,
+		"token_endpoint":         d.TokenEndpoint,
+		"userinfo_endpoint":      d.UserInfoEndpoint,
+		"jwks_uri":               d.JWKSURI,
+	} {
+		if !strings.HasPrefix(v, h.Issuer+"/") {
+			t.Errorf("%s = %q, want a URL under the issuer", name, v)
+		}
+	}
+	if !contains(d.ScopesSupported, "openid") {
+		t.Errorf("scopes_supported = %v, want openid", d.ScopesSupported)
+	}
+	if !contains(d.ResponseTypesSupported, "code") {
+		t.Errorf("response_types_supported = %v, want code", d.ResponseTypesSupported)
+	}
+	if len(d.SubjectTypesSupported) == 0 {
+		t.Error("subject_types_supported is empty")
+	}
+	if len(d.IDTokenSigningAlgValuesSupported) == 0 || contains(d.IDTokenSigningAlgValuesSupported, "none") {
+		t.Errorf("id_token_signing_alg_values_supported = %v", d.IDTokenSigningAlgValuesSupported)
+	}
+	if !contains(d.CodeChallengeMethodsSupported, "S256") {
+		t.Errorf("code_challenge_methods_supported = %v, want S256", d.CodeChallengeMethodsSupported)
+	}
+	if len(h.jwks(t, d).Keys) == 0 {
+		t.Error("jwks_uri has no keys; ID tokens cannot be verified")
+	}
+}
+
+func (h *harness) testCodeFlow(t *testing.T) {
+	d := h.discover(t)
+	nonce := randomString(t)
+	tok := h.codeFlow(t, d, "openid profile email", nonce)
+	if tok.IDToken == "" {
+		t.Fatal("token response has no id_token")
+	}
+	if !strings.EqualFold(tok.TokenType, "Bearer") {
+		t.Errorf("token_type = %q, want Bearer", tok.TokenType)
+	}
+
+	claims := h.verifyIDToken(t, d, tok.IDToken)
+	if claims.Nonce != nonce {
+		t.Errorf("nonce = %q, want %q", claims.Nonce, nonce)
+	}
+	if claims.Email != h.User.Email || claims.EmailVerified == nil {
+		t.Errorf("email = %q (verified %v), want %q with email_verified", claims.Email, claims.EmailVerified, h.User.Email)
+	}
+	if claims.Name != h.User.Name {
+		t.Errorf("name = %q, want %q", claims.Name, h.User.Name)
+	}
+
+	status, body := h.userInfo(t, d, tok.AccessToken)
+	if status != http.StatusOK {
+		t.Fatalf("userinfo status = %d, body %s", status, body)
+	}
+	var info struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+		Name    string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		t.Fatalf("decode userinfo: %v", err)
+	}
+	// sub ของ userinfo ต้องตรงกับ ID token (OpenID Connect Core §5.3.2)
+	if info.Subject != claims.Subject || info.Email != h.User.Email || info.Name != h.User.Name {
+		t.Errorf("userinfo = %+v, want sub %q email %q name %q", info, claims.Subject, h.User.Email, h.User.Name)
+	}
+}
+
+func (h *harness) testNoNonce(t *testing.T) {
+	d := h.discover(t)
+	claims := h.verifyIDToken(t, d, h.codeFlow(t, d, "openid", "").IDToken)
+	if claims.Nonce != "" {
+		t.Errorf("nonce = %q, want none when the request had no nonce", claims.Nonce)
+	}
+	// ไม่ได้ขอ profile/email ก็ต้องไม่ได้ claim เหล่านั้น
+	if claims.Email != "" || claims.Name != "" {
+		t.Errorf("openid-only ID token carries email %q / name %q", claims.Email, claims.Name)
+	}
+}
+
+func (h *harness) testWithoutOpenIDScope(t *testing.T) {
+	d := h.discover(t)
+	tok := h.codeFlow(t, d, "profile", "")
+	if tok.IDToken != "" {
+		t.Error("id_token issued without the openid scope")
+	}
+	if status, body := h.userInfo(t, d, tok.AccessToken); status != http.StatusForbidden {
+		t.Errorf("userinfo without openid scope: status = %d, body %s, want 403", status, body)
+	}
+}
+
+func (h *harness) testCodeReplay(t *testing.T) {
+	d := h.discover(t)
+	verifier := randomString(t)
+	code := h.authorize(t, d, "openid", "", verifier)
+	if tok := h.exchange(t, d, code, verifier); tok.AccessToken == "" {
+		t.Fatalf("first exchange failed: %s", tok.Error)
+	}
+	if tok := h.exchange(t, d, code, verifier); tok.Error != "invalid_grant" {
+		t.Errorf("replayed code: error = %q, want invalid_grant", tok.Error)
+	}
+}
+
+func (h *harness) testIDTokenIsNotAccessToken(t *testing.T) {
+	d := h.discover(t)
+	tok := h.codeFlow(t, d, "openid", "")
+	if status, _ := h.userInfo(t, d, tok.IDToken); status != http.StatusUnauthorized {
+		t.Errorf("userinfo with an ID token: status = %d, want 401", status)
+	}
+}
+
+func (h *harness) testUserInfoWithoutToken(t *testing.T) {
+	d := h.discover(t)
+	rec := h.do(httptest.NewRequest(http.MethodGet, d.UserInfoEndpoint, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+		t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+// codeFlow ขอ code ด้วย scope และ nonce แล้วแลกเป็น token
+func (h *harness) codeFlow(t *testing.T, d *discovery, scope, nonce string) *tokenResponse {
+	t.Helper()
+	verifier := randomString(t)
+	tok := h.exchange(t, d, h.authorize(t, d, scope, nonce, verifier), verifier)
+	if tok.AccessToken == "" {
+		t.Fatalf("token exchange failed: %s", tok.Error)
+	}
+	return tok
+}
+
+// authorize ตอบ consent แทนผู้ใช้ด้วย decision=allow แล้วคืน code จาก redirect
+func (h *harness) authorize(t *testing.T, d *discovery, scope, nonce, verifier string) string {
+	t.Helper()
+	state := randomString(t)
+	sum := sha256.Sum256([]byte(verifier))
+	form := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {h.ClientID},
+		"redirect_uri":          {h.RedirectURI},
+		"scope":                 {scope},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+		"decision":              {"allow"},
+	}
+	if nonce != "" {
+		form.Set("nonce", nonce)
+	}
+	req := httptest.NewRequest(http.MethodPost, d.AuthorizationEndpoint, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+h.UserToken)
+	rec := h.do(req)
+	var resp struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.RedirectTo == "" {
+		t.Fatalf("authorize: status %d, body %s", rec.Code, rec.Body)
+	}
+	u, err := url.Parse(resp.RedirectTo)
+	if err != nil {
+		t.Fatalf("authorize redirect %q: %v", resp.RedirectTo, err)
+	}
+	q := u.Query()
+	if q.Get("state") != state {
+		t.Fatalf("state = %q, want %q", q.Get("state"), state)
+	}
+	if q.Get("code") == "" {
+		t.Fatalf("authorize redirect has no code: %s", resp.RedirectTo)
+	}
+	return q.Get("code")
+}
+
+// exchange แลก code ที่ token endpoint (ยืนยัน client ด้วย HTTP Basic เมื่อมี secret)
+func (h *harness) exchange(t *testing.T, d *discovery, code, verifier string) *tokenResponse {
+	t.Helper()
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {h.RedirectURI},
+		"code_verifier": {verifier},
+	}
+	if h.ClientSecret == "" {
+		form.Set("client_id", h.ClientID)
+	}
+	req := httptest.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if h.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(h.ClientID), url.QueryEscape(h.ClientSecret))
+	}
+	rec := h.do(req)
+	var tok tokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tok); err != nil {
+		t.Fatalf("decode token response (status %d): %v", rec.Code, err)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("token response Cache-Control = %q, want no-store", cc)
+	}
+	return &tok
+}
+
+// verifyIDToken ตรวจลายเซ็นด้วย key จาก jwks_uri และ claim บังคับตาม OpenID Connect Core §3.1.3.7
+func (h *harness) verifyIDToken(t *testing.T, d *discovery, raw string) *idClaims {
+	t.Helper()
+	set := h.jwks(t, d)
+	claims := &idClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(tok *jwt.Token) (interface{}, error) {
+		kid, _ := tok.Header["kid"].(string)
+		for _, j := range set.Keys {
+			if j.Kid != kid {
+				continue
+			}
+			k, err := keys.ParseJWK(j)
+			if err != nil {
+				return nil, err
+			}
+			if tok.Method.Alg() != k.Algorithm {
+				t.Errorf("alg = %s, but key %s is %s", tok.Method.Alg(), kid, k.Algorithm)
+			}
+			return k.VerifyKey(), nil
+		}
+		t.Errorf("kid %q is not in jwks_uri", kid)
+		return nil, jwt.ErrTokenUnverifiable
+	})
+	if err != nil {
+		t.Fatalf("verify ID token: %v", err)
+	}
+	now := time.Now()
+	if claims.Issuer != h.Issuer {
+		t.Errorf("iss = %q, want %q", claims.Issuer, h.Issuer)
+	}
+	if !claims.VerifyAudience(h.ClientID, true) {
+		t.Errorf("aud = %v, want %q", claims.Audience, h.ClientID)
+	}
+	if claims.Subject != h.User.Subject {
+		t.Errorf("sub = %q, want %q", claims.Subject, h.User.Subject)
+	}
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Before(now) {
+		t.Errorf("exp = %v, want a future time", claims.ExpiresAt)
+	}
+	if claims.IssuedAt == nil || claims.IssuedAt.After(now.Add(clockSkew)) {
+		t.Errorf("iat = %v, want a time not in the future", claims.IssuedAt)
+	}
+	return claims
+}
+
+// userInfo เรียก userinfo endpoint ด้วย bearer token
+func (h *harness) userInfo(t *testing.T, d *discovery, token string) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, d.UserInfoEndpoint, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := h.do(req)
+	return rec.Code, rec.Body.Bytes()
+}
+
+func (h *harness) discover(t *testing.T) *discovery {
+	t.Helper()
+	var d discovery
+	h.getJSON(t, h.Issuer+"/.well-known/openid-configuration", &d)
+	return &d
+}
+
+func (h *harness) jwks(t *testing.T, d *discovery) *keys.JWKS {
+	t.Helper()
+	var set keys.JWKS
+	h.getJSON(t, d.JWKSURI, &set)
+	return &set
+}
+
+func (h *harness) getJSON(t *testing.T, target string, v interface{}) {
+	t.Helper()
+	rec := h.do(httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d, body %s", target, rec.Code, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+}
+
+func (h *harness) do(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// randomString คืนค่าสุ่มที่ใช้เป็น state, nonce หรือ PKCE verifier ได้ (43 ตัวอักษร)
+func randomString(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
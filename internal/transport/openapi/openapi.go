@@ -0,0 +1,9 @@
+// Package openapi เก็บ OpenAPI (Swagger 2.0) ที่ generate จาก proto/auth.proto
+package openapi
+
+import _ "embed"
+
+// Spec คือเนื้อหาของ auth.swagger.json
+//
+//go:embed auth.swagger.json
+var Spec []byte
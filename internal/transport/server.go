@@ -2,8 +2,10 @@ package transport
 
 import (
 	"context"
+    "strings"
     "time"
 
+    "github.com/LengLKR/auth-microservice/internal/domain"
     pb "github.com/LengLKR/auth-microservice/internal/transport/proto"
     "github.com/LengLKR/auth-microservice/internal/service"
     "google.golang.org/grpc"
@@ -21,19 +23,28 @@ func NewServer(svc *service.AuthService) pb.AuthServiceServer {
 }
 
 func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.AuthResponse, error) {
-    token, err := s.authSvc.Register(ctx, req.Email, req.Password)
+    tokens, err := s.authSvc.Register(ctx, req.Email, req.Password)
     if err != nil {
         return nil, err
     }
-    return &pb.AuthResponse{Token: token}, nil
+    return toAuthResponse(tokens), nil
 }
 
 func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.AuthResponse, error) {
-    token, err := s.authSvc.Login(ctx, req.Email, req.Password)
+    tokens, err := s.authSvc.Login(ctx, req.Email, req.Password)
     if err != nil {
         return nil, err
     }
-    return &pb.AuthResponse{Token: token}, nil
+    return toAuthResponse(tokens), nil
+}
+
+// RefreshToken แลก refresh token เป็นชุด token ใหม่
+func (s *Server) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.AuthResponse, error) {
+    tokens, err := s.authSvc.RefreshToken(ctx, req.RefreshToken)
+    if err != nil {
+        return nil, err
+    }
+    return toAuthResponse(tokens), nil
 }
 
 func (s *Server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Empty, error) {
@@ -43,6 +54,14 @@ func (s *Server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Empty,
     return &pb.Empty{}, nil
 }
 
+// LogoutAll เพิกถอน token ทุกตัวของผู้เรียก (logout ทุกเครื่อง)
+func (s *Server) LogoutAll(ctx context.Context, _ *pb.Empty) (*pb.Empty, error) {
+    if err := s.authSvc.LogoutAll(ctx); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
 //ListUsers
 func (s *Server) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error){
     users, total, err := s.authSvc.ListUsers(ctx, req.FilterName, req.FilterEmail, int(req.Page), int(req.Size))
@@ -51,11 +70,7 @@ func (s *Server) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.L
     }
     pbUsers := make([]*pb.User, len(users))
     for i, u := range users {
-        pbUsers[i] = &pb.User{
-            Id:         u.ID,
-            Email:      u.Email,
-            CreatedAt:  u.CreatedAt.Format(time.RFC3339),
-        }
+        pbUsers[i] = toPBUser(u)
     }
     return &pb.ListUsersResponse{Users: pbUsers, TotalCount: int32(total)}, nil
 }
@@ -66,11 +81,7 @@ func (s *Server) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb
     if err != nil {
         return nil, err
     }
-    return &pb.User{
-        Id:         u.ID,
-        Email:      u.Email,
-        CreatedAt:  u.CreatedAt.Format(time.RFC3339),
-    }, nil
+    return toPBUser(u), nil
 }
 
 //UpdaeProfile
@@ -79,11 +90,7 @@ func (s *Server) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest
     if err != nil {
         return nil, err
     }
-    return &pb.User{
-        Id:         u.ID,
-        Email:      u.Email,
-        CreatedAt:  u.CreatedAt.Format(time.RFC3339),
-    }, nil
+    return toPBUser(u), nil
 }
 
 //DeleteProfile
@@ -94,6 +101,184 @@ func (s *Server) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest
     return &pb.Empty{}, nil
 }
 
+// AssignRole เพิ่ม role ให้ผู้ใช้
+func (s *Server) AssignRole(ctx context.Context, req *pb.RoleRequest) (*pb.User, error) {
+    u, err := s.authSvc.AssignRole(ctx, req.UserId, req.Role)
+    if err != nil {
+        return nil, err
+    }
+    return toPBUser(u), nil
+}
+
+// RevokeRole ถอน role ของผู้ใช้
+func (s *Server) RevokeRole(ctx context.Context, req *pb.RoleRequest) (*pb.User, error) {
+    u, err := s.authSvc.RevokeRole(ctx, req.UserId, req.Role)
+    if err != nil {
+        return nil, err
+    }
+    return toPBUser(u), nil
+}
+
+// ListAuditEvents ค้น audit log ตาม filter
+func (s *Server) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+    f := domain.AuditFilter{Type: req.Type, Outcome: req.Outcome, ActorID: req.ActorId, SubjectID: req.SubjectId}
+    var err error
+    if f.Since, err = parseTimestamp(req.Since); err != nil {
+        return nil, err
+    }
+    if f.Until, err = parseTimestamp(req.Until); err != nil {
+        return nil, err
+    }
+    events, total, err := s.authSvc.ListAuditEvents(ctx, f, int(req.Page), int(req.Size))
+    if err != nil {
+        return nil, err
+    }
+    pbEvents := make([]*pb.AuditEvent, len(events))
+    for i, e := range events {
+        pbEvents[i] = &pb.AuditEvent{
+            Id:        e.ID,
+            Type:      e.Type,
+            Outcome:   e.Outcome,
+            ActorId:   e.ActorID,
+            SubjectId: e.SubjectID,
+            Email:     e.Email,
+            Ip:        e.IP,
+            UserAgent: e.UserAgent,
+            Reason:    e.Reason,
+            CreatedAt: e.CreatedAt.Format(time.RFC3339Nano),
+        }
+    }
+    return &pb.ListAuditEventsResponse{Events: pbEvents, TotalCount: int32(total)}, nil
+}
+
+// parseTimestamp แปลงเวลา RFC3339 ใน request ("" = ไม่กำหนด)
+func parseTimestamp(v string) (time.Time, error) {
+    if v == "" {
+        return time.Time{}, nil
+    }
+    t, err := time.Parse(time.RFC3339, v)
+    if err != nil {
+        return time.Time{}, service.ErrInvalidTimestamp
+    }
+    return t, nil
+}
+
+// UnlockAccount ปลดล็อกบัญชีที่ login ผิดซ้ำจนถูกล็อก
+func (s *Server) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.Empty, error) {
+    if err := s.authSvc.UnlockAccount(ctx, req.UserId); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
+// RegisterOAuthClient ลงทะเบียน OAuth client ใหม่ คืน client secret ครั้งเดียว
+func (s *Server) RegisterOAuthClient(ctx context.Context, req *pb.RegisterOAuthClientRequest) (*pb.RegisterOAuthClientResponse, error) {
+    c, secret, err := s.authSvc.RegisterOAuthClient(ctx, req.Name, req.RedirectUris, req.Scopes, req.Confidential)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.RegisterOAuthClientResponse{
+        Client: &pb.OAuthClient{
+            ClientId:     c.ID,
+            Name:         c.Name,
+            RedirectUris: c.RedirectURIs,
+            Scopes:       c.Scopes,
+            Confidential: c.Confidential(),
+            CreatedAt:    c.CreatedAt.Format(time.RFC3339),
+        },
+        ClientSecret: secret,
+    }, nil
+}
+
+// CreateMachineClient สร้าง machine client คืน client secret ครั้งเดียว
+func (s *Server) CreateMachineClient(ctx context.Context, req *pb.CreateMachineClientRequest) (*pb.CreateMachineClientResponse, error) {
+    c, secret, err := s.authSvc.CreateMachineClient(ctx, req.Name, req.Scopes)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.CreateMachineClientResponse{Client: toPBMachineClient(c), ClientSecret: secret}, nil
+}
+
+// RotateMachineClientSecret ออก secret ใหม่ให้ machine client
+func (s *Server) RotateMachineClientSecret(ctx context.Context, req *pb.RotateMachineClientSecretRequest) (*pb.RotateMachineClientSecretResponse, error) {
+    secret, err := s.authSvc.RotateMachineClientSecret(ctx, req.ClientId)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.RotateMachineClientSecretResponse{ClientSecret: secret}, nil
+}
+
+// DisableMachineClient ปิด machine client
+func (s *Server) DisableMachineClient(ctx context.Context, req *pb.DisableMachineClientRequest) (*pb.Empty, error) {
+    if err := s.authSvc.DisableMachineClient(ctx, req.ClientId); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
+// ExchangeClientCredentials แลก client credentials ของ machine client เป็น access token
+func (s *Server) ExchangeClientCredentials(ctx context.Context, req *pb.ClientCredentialsRequest) (*pb.ClientCredentialsResponse, error) {
+    tok, err := s.authSvc.ExchangeClientCredentials(ctx, req.ClientId, req.ClientSecret, req.Scope)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.ClientCredentialsResponse{
+        AccessToken: tok.AccessToken,
+        TokenType:   "Bearer",
+        ExpiresIn:   int64(tok.ExpiresIn.Seconds()),
+        Scope:       strings.Join(tok.Scopes, " "),
+    }, nil
+}
+
+// toPBMachineClient แปลง domain.MachineClient เป็น pb.MachineClient (เวลาที่ไม่มีค่าเป็น "")
+func toPBMachineClient(c *domain.MachineClient) *pb.MachineClient {
+    out := &pb.MachineClient{
+        ClientId:  c.ID,
+        Name:      c.Name,
+        Scopes:    c.Scopes,
+        CreatedAt: c.CreatedAt.Format(time.RFC3339),
+    }
+    if c.RotatedAt != nil {
+        out.RotatedAt = c.RotatedAt.Format(time.RFC3339)
+    }
+    if c.DisabledAt != nil {
+        out.DisabledAt = c.DisabledAt.Format(time.RFC3339)
+    }
+    return out
+}
+
+// IntrospectToken ตรวจสถานะของ token ให้ service ปลายทาง
+func (s *Server) IntrospectToken(ctx context.Context, req *pb.IntrospectTokenRequest) (*pb.IntrospectTokenResponse, error) {
+    info, err := s.authSvc.IntrospectToken(ctx, req.Token, req.TokenTypeHint)
+    if err != nil {
+        return nil, err
+    }
+    if !info.Active {
+        return &pb.IntrospectTokenResponse{}, nil
+    }
+    out := &pb.IntrospectTokenResponse{
+        Active:    true,
+        TokenType: info.TokenType,
+        Subject:   info.Subject,
+        ClientId:  info.ClientID,
+        Scopes:    info.Scopes,
+        Roles:     info.Roles,
+        ExpiresAt: info.ExpiresAt.Format(time.RFC3339),
+    }
+    if !info.IssuedAt.IsZero() {
+        out.IssuedAt = info.IssuedAt.Format(time.RFC3339)
+    }
+    return out, nil
+}
+
+// RevokeToken เพิกถอน access token หรือ refresh token
+func (s *Server) RevokeToken(ctx context.Context, req *pb.RevokeTokenRequest) (*pb.Empty, error) {
+    if err := s.authSvc.RevokeToken(ctx, req.Token, req.TokenTypeHint); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
 // RequestPasswordReset สั่งสร้าง reset token
 func (s *Server) RequestPasswordReset(ctx context.Context, req *pb.PasswordResetRequest) (*pb.Empty, error) {
     if err := s.authSvc.RequestPasswordReset(ctx, req.Email); err != nil {
@@ -110,6 +295,138 @@ func (s *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest
     return &pb.Empty{}, nil
 }
 
+// VerifyMFA ขั้นที่สองของ Login
+func (s *Server) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.AuthResponse, error) {
+    tokens, err := s.authSvc.VerifyMFA(ctx, req.MfaToken, req.Code)
+    if err != nil {
+        return nil, err
+    }
+    return toAuthResponse(tokens), nil
+}
+
+// EnrollTOTP เริ่มเปิดใช้ TOTP
+func (s *Server) EnrollTOTP(ctx context.Context, _ *pb.Empty) (*pb.EnrollTOTPResponse, error) {
+    secret, uri, err := s.authSvc.EnrollTOTP(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.EnrollTOTPResponse{Secret: secret, OtpauthUri: uri}, nil
+}
+
+// ConfirmTOTP ยืนยันและเปิดใช้ TOTP
+func (s *Server) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+    codes, err := s.authSvc.ConfirmTOTP(ctx, req.Code)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.ConfirmTOTPResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP ปิด TOTP
+func (s *Server) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*pb.Empty, error) {
+    if err := s.authSvc.DisableTOTP(ctx, req.Code); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
+// BeginPasskeyRegistration เริ่มลงทะเบียน passkey
+func (s *Server) BeginPasskeyRegistration(ctx context.Context, _ *pb.Empty) (*pb.PasskeyChallenge, error) {
+    options, sessionID, err := s.authSvc.BeginPasskeyRegistration(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.PasskeyChallenge{SessionId: sessionID, OptionsJson: string(options)}, nil
+}
+
+// FinishPasskeyRegistration บันทึก passkey ใหม่
+func (s *Server) FinishPasskeyRegistration(ctx context.Context, req *pb.FinishPasskeyRegistrationRequest) (*pb.Empty, error) {
+    if err := s.authSvc.FinishPasskeyRegistration(ctx, req.SessionId, []byte(req.CredentialJson), req.Name); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
+// BeginPasskeyLogin เริ่ม login ด้วย passkey
+func (s *Server) BeginPasskeyLogin(ctx context.Context, req *pb.BeginPasskeyLoginRequest) (*pb.PasskeyChallenge, error) {
+    options, sessionID, err := s.authSvc.BeginPasskeyLogin(ctx, req.Email)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.PasskeyChallenge{SessionId: sessionID, OptionsJson: string(options)}, nil
+}
+
+// FinishPasskeyLogin ตรวจ assertion แล้วคืน token
+func (s *Server) FinishPasskeyLogin(ctx context.Context, req *pb.FinishPasskeyLoginRequest) (*pb.AuthResponse, error) {
+    tokens, err := s.authSvc.FinishPasskeyLogin(ctx, req.SessionId, []byte(req.CredentialJson))
+    if err != nil {
+        return nil, err
+    }
+    return toAuthResponse(tokens), nil
+}
+
+// GetJWKS คืน public key สำหรับตรวจสอบ JWT
+func (s *Server) GetJWKS(ctx context.Context, _ *pb.Empty) (*pb.JWKS, error) {
+    set := s.authSvc.JWKS()
+    out := &pb.JWKS{Keys: make([]*pb.JWK, len(set.Keys))}
+    for i, k := range set.Keys {
+        out.Keys[i] = &pb.JWK{
+            Kty: k.Kty,
+            Kid: k.Kid,
+            Use: k.Use,
+            Alg: k.Alg,
+            N:   k.N,
+            E:   k.E,
+            Crv: k.Crv,
+            X:   k.X,
+            Y:   k.Y,
+        }
+    }
+    return out, nil
+}
+
+// toAuthResponse แปลง TokenPair เป็น pb.AuthResponse
+func toAuthResponse(t *service.TokenPair) *pb.AuthResponse {
+    if t.MFAToken != "" {
+        return &pb.AuthResponse{MfaRequired: true, MfaToken: t.MFAToken}
+    }
+    if t.VerificationRequired {
+        return &pb.AuthResponse{EmailVerificationRequired: true}
+    }
+    return &pb.AuthResponse{
+        Token:        t.AccessToken,
+        RefreshToken: t.RefreshToken,
+        ExpiresIn:    int64(t.ExpiresIn / time.Second),
+    }
+}
+
+// SendVerificationEmail ส่ง token ยืนยันอีเมลอีกครั้ง
+func (s *Server) SendVerificationEmail(ctx context.Context, req *pb.SendVerificationEmailRequest) (*pb.Empty, error) {
+    if err := s.authSvc.SendVerificationEmail(ctx, req.Email); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
+// VerifyEmail ยืนยันอีเมลด้วย token
+func (s *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.Empty, error) {
+    if err := s.authSvc.VerifyEmail(ctx, req.Token); err != nil {
+        return nil, err
+    }
+    return &pb.Empty{}, nil
+}
+
+// toPBUser แปลง domain.User เป็น pb.User
+func toPBUser(u domain.User) *pb.User {
+    return &pb.User{
+        Id:            u.ID,
+        Email:         u.Email,
+        CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+        EmailVerified: u.EmailVerified,
+        Roles:         u.Roles,
+    }
+}
+
 // RegisterAuthServiceServer ช่วย register ใน main.go
 func RegisterAuthServiceServer(grpcServer *grpc.Server, srv pb.AuthServiceServer) {
     pb.RegisterAuthServiceServer(grpcServer, srv)
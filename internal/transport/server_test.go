@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LengLKR/auth-microservice/internal/domain"
+	"github.com/LengLKR/auth-microservice/internal/keys"
+	"github.com/LengLKR/auth-microservice/internal/mail"
+	"github.com/LengLKR/auth-microservice/internal/repository"
+	"github.com/LengLKR/auth-microservice/internal/repository/memory"
+	"github.com/LengLKR/auth-microservice/internal/service"
+	pb "github.com/LengLKR/auth-microservice/internal/transport/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// blockingUsers ครอบ UserRepository ให้ FindByEmail ค้างจนกว่า ctx จะถูกยกเลิก
+// แล้วส่ง ctx.Err() ที่เห็นออกทาง seen เหมือน driver ของฐานข้อมูลจริงที่ query ถูกยกเลิก
+type blockingUsers struct {
+	repository.UserRepository
+	entered chan struct{}
+	seen    chan error
+}
+
+func (r *blockingUsers) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	close(r.entered)
+	<-ctx.Done()
+	r.seen <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+// newBufconnClient เปิด gRPC server บน bufconn ด้วย interceptor ชุดเดียวกับ main
+// handlerCode รับ status code ที่ server คืนจริง (แยกจากที่ฝั่ง client สรุปเอง)
+func newBufconnClient(t *testing.T, svc *service.AuthService, handlerCode chan<- codes.Code) pb.AuthServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	record := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		handlerCode <- status.Code(err)
+		return resp, err
+	}
+	auth := NewAuthInterceptor(svc, PublicMethods, ScopedMethods)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(record, UnaryErrorInterceptor, NewClientInterceptor("").Unary(), auth.Unary()))
+	RegisterAuthServiceServer(srv, NewServer(svc))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewAuthServiceClient(conn)
+}
+
+func TestClientCancellationReachesRepository(t *testing.T) {
+	users := &blockingUsers{
+		UserRepository: memory.NewUserRepository(),
+		entered:        make(chan struct{}),
+		seen:           make(chan error, 1),
+	}
+	k, err := keys.NewHMACKey("test", strings.Repeat("k", 40))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+	ring, err := keys.NewRing(k, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	svc := service.NewAuthService(users, memory.NewTokenRepository(), memory.NewPasswordResetRepository(),
+		memory.NewEmailVerificationRepository(), memory.NewRefreshTokenRepository(), ring, mail.NewMemoryMailer())
+	handlerCode := make(chan codes.Code, 1)
+	client := newBufconnClient(t, svc, handlerCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-users.entered
+		cancel()
+	}()
+	_, err = client.Login(ctx, &pb.LoginRequest{Email: "alice@example.com", Password: "correct-horse-battery-9"})
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("Login = %v, want codes.Canceled", err)
+	}
+
+	select {
+	case seen := <-users.seen:
+		if seen != context.Canceled {
+			t.Fatalf("repository saw ctx.Err() = %v, want context.Canceled", seen)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("repository never saw the cancellation")
+	}
+	select {
+	case code := <-handlerCode:
+		if code != codes.Canceled {
+			t.Fatalf("server returned %v, want codes.Canceled", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server handler never returned")
+	}
+}